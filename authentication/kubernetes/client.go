@@ -35,8 +35,8 @@ func init() {
 	}
 }
 
-// GetKubeClient returns a kubernetes client.
-func GetKubeClient() (*kubernetes.Clientset, error) {
+// GetKubeConfig returns the configuration of the kubernetes clients, in cluster or from the kubeconfig file.
+func GetKubeConfig() (*rest.Config, error) {
 	flag.Parse()
 	conf, err := rest.InClusterConfig()
 	if err != nil {
@@ -45,6 +45,16 @@ func GetKubeClient() (*kubernetes.Clientset, error) {
 			return nil, err
 		}
 	}
+
+	return conf, nil
+}
+
+// GetKubeClient returns a kubernetes client.
+func GetKubeClient() (*kubernetes.Clientset, error) {
+	conf, err := GetKubeConfig()
+	if err != nil {
+		return nil, err
+	}
 	clientset, err := kubernetes.NewForConfig(conf)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compression wraps queue-style bindings, like kafka, rabbitmq or mqtt, to compress the payload of messages.
+package compression
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/component/compression"
+)
+
+type compressingOutput struct {
+	bindings.OutputBinding
+	settings compression.Settings
+}
+
+// NewOutput returns an output binding compressing the data of create operations according to the
+// `compression` and `compressionMinSize` metadata. The encoding is recorded in the `contentEncoding`
+// metadata of the request, and in a header of the data too with `compressionFramed`.
+func NewOutput(inner bindings.OutputBinding) bindings.OutputBinding {
+	return &compressingOutput{OutputBinding: inner}
+}
+
+func (c *compressingOutput) Init(metadata bindings.Metadata) error {
+	settings, err := compression.ParseSettings(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	c.settings = settings
+
+	return c.OutputBinding.Init(metadata)
+}
+
+func (c *compressingOutput) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req.Operation != bindings.CreateOperation {
+		return c.OutputBinding.Invoke(ctx, req)
+	}
+
+	data, metadata, err := c.settings.Compress(req.Data, req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("error compressing message: %w", err)
+	}
+
+	compressed := *req
+	compressed.Data = data
+	compressed.Metadata = metadata
+
+	return c.OutputBinding.Invoke(ctx, &compressed)
+}
+
+type decompressingInput struct {
+	bindings.InputBinding
+	settings compression.Settings
+}
+
+// NewInput returns an input binding decompressing the data of the messages whose `contentEncoding`
+// metadata records an encoding, or, with `compressionFramed`, starting with the header recording it,
+// up to `compressionMaxSize` bytes.
+func NewInput(inner bindings.InputBinding) bindings.InputBinding {
+	return &decompressingInput{InputBinding: inner}
+}
+
+func (d *decompressingInput) Init(metadata bindings.Metadata) error {
+	settings, err := compression.ParseSettings(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	d.settings = settings
+
+	return d.InputBinding.Init(metadata)
+}
+
+func (d *decompressingInput) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	return d.InputBinding.Read(func(ctx context.Context, resp *bindings.ReadResponse) ([]byte, error) {
+		data, metadata, err := d.settings.Decompress(resp.Data, resp.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing message: %w", err)
+		}
+
+		decompressed := *resp
+		decompressed.Data = data
+		decompressed.Metadata = metadata
+
+		return handler(ctx, &decompressed)
+	})
+}
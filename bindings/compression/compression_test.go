@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+// queue is an output and input binding delivering the invoked messages to its reader.
+type queue struct {
+	messages []*bindings.InvokeRequest
+}
+
+func (q *queue) Init(metadata bindings.Metadata) error {
+	return nil
+}
+
+func (q *queue) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{bindings.CreateOperation}
+}
+
+func (q *queue) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	q.messages = append(q.messages, req)
+
+	return nil, nil
+}
+
+func (q *queue) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	for _, msg := range q.messages {
+		if _, err := handler(context.Background(), &bindings.ReadResponse{Data: msg.Data, Metadata: msg.Metadata}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestCompressingBindings(t *testing.T) {
+	q := &queue{}
+	output := NewOutput(q)
+	require.NoError(t, output.Init(bindings.Metadata{Properties: map[string]string{"compression": "gzip"}}))
+
+	_, err := output.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte("hello world"),
+		Metadata:  map[string]string{"key": "value"},
+	})
+	require.NoError(t, err)
+	_, err = output.Invoke(context.Background(), &bindings.InvokeRequest{Operation: "get", Data: []byte("plain")})
+	require.NoError(t, err)
+
+	require.Len(t, q.messages, 2)
+	assert.NotEqual(t, []byte("hello world"), q.messages[0].Data)
+	assert.Equal(t, "gzip", q.messages[0].Metadata["contentEncoding"])
+	assert.Equal(t, []byte("plain"), q.messages[1].Data)
+
+	var read []*bindings.ReadResponse
+	input := NewInput(q)
+	require.NoError(t, input.Init(bindings.Metadata{Properties: map[string]string{"compressionMaxSize": "1024"}}))
+	err = input.Read(func(ctx context.Context, resp *bindings.ReadResponse) ([]byte, error) {
+		read = append(read, resp)
+
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, read, 2)
+	assert.Equal(t, []byte("hello world"), read[0].Data)
+	assert.Equal(t, map[string]string{"key": "value"}, read[0].Metadata)
+	assert.Equal(t, []byte("plain"), read[1].Data)
+}
@@ -16,26 +16,54 @@ package cron
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	cron "github.com/robfig/cron/v3"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/lock"
 	"github.com/dapr/kit/logger"
 )
 
+const (
+	defaultLockTTL = 60 * time.Second
+)
+
 // Binding represents Cron input binding.
 type Binding struct {
 	logger   logger.Logger
 	name     string
 	schedule string
 	parser   cron.Parser
+	sched    cron.Schedule
+	location *time.Location
+	jitter   time.Duration
+
+	// leaderElection fires each tick on the replica acquiring its lock in lockStore.
+	leaderElection bool
+	lockStore      lock.Store
+	lockOwner      string
+	lockTTL        time.Duration
+}
+
+// read is a running Read, stopped by cancel.
+type read struct {
+	cancel context.CancelFunc
 }
 
 var (
-	_      = bindings.InputBinding(&Binding{})
-	stopCh = make(map[string]chan bool)
+	_ = bindings.InputBinding(&Binding{})
+	_ = bindings.LockStoreSetter(&Binding{})
+
+	// reads holds the running reads by component name, so that the output binding of a component
+	// stops the schedule read by its input binding.
+	reads     = make(map[string]map[*read]struct{})
+	readsLock sync.Mutex
 )
 
 // NewCron returns a new Cron event input binding.
@@ -45,53 +73,175 @@ func NewCron(logger logger.Logger) *Binding {
 		parser: cron.NewParser(
 			cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
 		),
+		location: time.Local,
+		lockTTL:  defaultLockTTL,
 	}
 }
 
+// SetLockStore sets the lock store used to elect the replica firing each tick
+// when leaderElection is enabled. The host calls it before Init, see bindings.LockStoreSetter.
+func (b *Binding) SetLockStore(store lock.Store) {
+	b.lockStore = store
+}
+
 // Init initializes the Cron binding
 // Examples from https://godoc.org/github.com/robfig/cron:
 //   "15 * * * * *" - Every 15 sec
 //   "0 30 * * * *" - Every 30 min
 func (b *Binding) Init(metadata bindings.Metadata) error {
-	if _, ok := stopCh[metadata.Name]; !ok {
-		stopCh[metadata.Name] = make(chan bool)
-	}
 	b.name = metadata.Name
 	s, f := metadata.Properties["schedule"]
 	if !f || s == "" {
 		return fmt.Errorf("schedule not set")
 	}
-	_, err := b.parser.Parse(s)
+	sched, err := b.parser.Parse(s)
 	if err != nil {
 		return errors.Wrapf(err, "invalid schedule format: %s", s)
 	}
 	b.schedule = s
+	b.sched = sched
+
+	if tz := metadata.Properties["timeZone"]; tz != "" {
+		if b.location, err = time.LoadLocation(tz); err != nil {
+			return errors.Wrapf(err, "invalid time zone: %s", tz)
+		}
+	}
+
+	if val := metadata.Properties["jitter"]; val != "" {
+		if b.jitter, err = time.ParseDuration(val); err != nil || b.jitter < 0 {
+			return errors.Errorf("invalid jitter: %s", val)
+		}
+	}
+
+	if val := metadata.Properties["leaderElection"]; val != "" {
+		if b.leaderElection, err = strconv.ParseBool(val); err != nil {
+			return errors.Wrapf(err, "invalid leaderElection: %s", val)
+		}
+	}
+	if !b.leaderElection {
+		return nil
+	}
+	if b.lockStore == nil {
+		return errors.New("leader election requires a lock store, none was set by the host")
+	}
+	if val := metadata.Properties["lockTTLInSeconds"]; val != "" {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || seconds <= 0 {
+			return errors.Errorf("invalid lockTTLInSeconds: %s", val)
+		}
+		b.lockTTL = time.Duration(seconds) * time.Second
+	}
+	if b.lockOwner = metadata.Properties["lockOwner"]; b.lockOwner == "" {
+		b.lockOwner = uuid.New().String()
+	}
+	// The ticks of @every schedules follow the start of each replica, they are aligned
+	// so that the replicas compete for the same ticks.
+	if constant, ok := b.sched.(cron.ConstantDelaySchedule); ok {
+		b.sched = alignedSchedule{delay: constant.Delay}
+	}
 
 	return nil
 }
 
-// Read triggers the Cron scheduler.
+// alignedSchedule fires every delay, at the multiples of delay since the zero time.
+type alignedSchedule struct {
+	delay time.Duration
+}
+
+func (s alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.delay).Add(s.delay)
+}
+
+// Read triggers the Cron scheduler until the schedule is deleted.
 func (b *Binding) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
-	c := cron.New(cron.WithParser(b.parser))
-	id, err := c.AddFunc(b.schedule, func() {
-		b.logger.Debugf("name: %s, schedule fired: %v", b.name, time.Now())
-		handler(context.TODO(), &bindings.ReadResponse{
-			Metadata: map[string]string{
-				"timeZone":    c.Location().String(),
-				"readTimeUTC": time.Now().UTC().String(),
-			},
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &read{cancel: cancel}
+	readsLock.Lock()
+	if reads[b.name] == nil {
+		reads[b.name] = make(map[*read]struct{})
+	}
+	reads[b.name][r] = struct{}{}
+	readsLock.Unlock()
+	defer func() {
+		readsLock.Lock()
+		delete(reads[b.name], r)
+		if len(reads[b.name]) == 0 {
+			delete(reads, b.name)
+		}
+		readsLock.Unlock()
+		cancel()
+	}()
+
+	last := time.Now()
+	for {
+		now := time.Now()
+		if now.Before(last) {
+			now = last
+		}
+		scheduled := b.sched.Next(now.In(b.location))
+		if scheduled.IsZero() {
+			b.logger.Debugf("name: %s, schedule has no next run: %s", b.name, b.schedule)
+
+			return nil
+		}
+		b.logger.Debugf("name: %s, next run: %v", b.name, time.Until(scheduled))
+
+		timer := time.NewTimer(time.Until(scheduled) + b.randomJitter())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			b.logger.Debugf("name: %s, stopping schedule: %s", b.name, b.schedule)
+
+			return nil
+		case <-timer.C:
+		}
+		last = scheduled
+
+		go b.fire(ctx, scheduled, handler)
+	}
+}
+
+// fire calls handler for the tick scheduled at scheduled, unless another replica fired it.
+func (b *Binding) fire(ctx context.Context, scheduled time.Time, handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) {
+	if b.leaderElection {
+		// The lock of each tick is left to expire, so that replicas firing late do not acquire it again.
+		resp, err := b.lockStore.TryLock(&lock.TryLockRequest{
+			ResourceID:      fmt.Sprintf("%s||%d", b.name, scheduled.Unix()),
+			LockOwner:       b.lockOwner,
+			ExpiryInSeconds: int32(b.lockTTL / time.Second),
 		})
+		if err != nil {
+			b.logger.Errorf("name: %s, error acquiring the lock of the run scheduled at %v: %s", b.name, scheduled, err)
+
+			return
+		}
+		if !resp.Success {
+			b.logger.Debugf("name: %s, run scheduled at %v fired by another replica", b.name, scheduled)
+
+			return
+		}
+	}
+
+	fireTime := time.Now()
+	b.logger.Debugf("name: %s, schedule fired: %v", b.name, fireTime)
+	handler(ctx, &bindings.ReadResponse{
+		Metadata: map[string]string{
+			"timeZone":      b.location.String(),
+			"readTimeUTC":   fireTime.UTC().String(),
+			"scheduledTime": scheduled.Format(time.RFC3339),
+			"fireTime":      fireTime.In(b.location).Format(time.RFC3339Nano),
+		},
 	})
-	if err != nil {
-		return errors.Wrapf(err, "name: %s, error scheduling %s", b.name, b.schedule)
+}
+
+// randomJitter returns a random delay up to the jitter, spreading the runs of the replicas.
+func (b *Binding) randomJitter() time.Duration {
+	if b.jitter <= 0 {
+		return 0
 	}
-	c.Start()
-	b.logger.Debugf("name: %s, next run: %v", b.name, time.Until(c.Entry(id).Next))
-	<-stopCh[b.name]
-	b.logger.Debugf("name: %s, stopping schedule: %s", b.name, b.schedule)
-	c.Stop()
 
-	return nil
+	//nolint:gosec
+	return time.Duration(rand.Int63n(int64(b.jitter)))
 }
 
 // Invoke exposes way to stop previously started cron.
@@ -101,7 +251,11 @@ func (b *Binding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bin
 		return nil, fmt.Errorf("invalid operation: '%v', only '%v' supported",
 			req.Operation, bindings.DeleteOperation)
 	}
-	stopCh[b.name] <- true
+	readsLock.Lock()
+	for r := range reads[b.name] {
+		r.cancel()
+	}
+	readsLock.Unlock()
 
 	return &bindings.InvokeResponse{
 		Metadata: map[string]string{
@@ -16,11 +16,16 @@ package cron
 import (
 	"context"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/lock"
 	"github.com/dapr/kit/logger"
 )
 
@@ -97,3 +102,148 @@ func TestCronInvokeInvalidOperation(t *testing.T) {
 	})
 	assert.Error(t, err)
 }
+
+func TestCronInitOptions(t *testing.T) {
+	m := getTestMetadata("@every 1s")
+	m.Properties["timeZone"] = "America/New_York"
+	m.Properties["jitter"] = "500ms"
+	c := getNewCron()
+	require.NoError(t, c.Init(m))
+	assert.Equal(t, "America/New_York", c.location.String())
+	assert.Equal(t, 500*time.Millisecond, c.jitter)
+
+	for key, val := range map[string]string{
+		"timeZone":       "Mars/Olympus",
+		"jitter":         "soon",
+		"leaderElection": "maybe",
+	} {
+		m := getTestMetadata("@every 1s")
+		m.Properties[key] = val
+		assert.Errorf(t, getNewCron().Init(m), "no error for invalid %s", key)
+	}
+
+	m = getTestMetadata("@every 1s")
+	m.Properties["leaderElection"] = "true"
+	assert.Error(t, getNewCron().Init(m), "no error for leader election without lock store")
+}
+
+func TestCronReadResponseTimes(t *testing.T) {
+	m := getTestMetadata("@every 1s")
+	m.Name = "times"
+	m.Properties["timeZone"] = "Asia/Tokyo"
+	c := getNewCron()
+	require.NoError(t, c.Init(m))
+
+	var res *bindings.ReadResponse
+	err := c.Read(func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+		res = r
+		_, err := c.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: bindings.DeleteOperation})
+		assert.NoError(t, err)
+
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, res)
+
+	assert.Equal(t, "Asia/Tokyo", res.Metadata["timeZone"])
+	scheduled, err := time.Parse(time.RFC3339, res.Metadata["scheduledTime"])
+	require.NoError(t, err)
+	fired, err := time.Parse(time.RFC3339Nano, res.Metadata["fireTime"])
+	require.NoError(t, err)
+	assert.False(t, fired.Before(scheduled))
+	_, offset := fired.Zone()
+	assert.Equal(t, 9*60*60, offset)
+}
+
+// fakeLockStore is an in-memory lock store.
+type fakeLockStore struct {
+	owners map[string]string
+	lock   sync.Mutex
+}
+
+func (f *fakeLockStore) InitLockStore(metadata lock.Metadata) error {
+	return nil
+}
+
+func (f *fakeLockStore) TryLock(req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.owners[req.ResourceID]; ok {
+		return &lock.TryLockResponse{Success: false}, nil
+	}
+	f.owners[req.ResourceID] = req.LockOwner
+
+	return &lock.TryLockResponse{Success: true}, nil
+}
+
+func (f *fakeLockStore) Unlock(req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.owners, req.ResourceID)
+
+	return &lock.UnlockResponse{Status: lock.Success}, nil
+}
+
+func TestCronLeaderElection(t *testing.T) {
+	store := &fakeLockStore{owners: make(map[string]string)}
+	m := getTestMetadata("@every 1s")
+	m.Name = "leader"
+	m.Properties["leaderElection"] = "true"
+	m.Properties["jitter"] = "100ms"
+
+	// Two replicas of the same component.
+	replicas := []*Binding{getNewCron(), getNewCron()}
+	for _, c := range replicas {
+		c.SetLockStore(store)
+		require.NoError(t, c.Init(m))
+	}
+
+	var fired int32
+	var wg sync.WaitGroup
+	for _, c := range replicas {
+		wg.Add(1)
+		go func(c *Binding) {
+			defer wg.Done()
+			assert.NoError(t, c.Read(func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+				atomic.AddInt32(&fired, 1)
+
+				return nil, nil
+			}))
+		}(c)
+	}
+
+	time.Sleep(3500 * time.Millisecond)
+	_, err := replicas[0].Invoke(context.TODO(), &bindings.InvokeRequest{Operation: bindings.DeleteOperation})
+	require.NoError(t, err)
+	wg.Wait()
+
+	store.lock.Lock()
+	ticks := len(store.owners)
+	store.lock.Unlock()
+	assert.GreaterOrEqual(t, ticks, 3)
+	assert.Equal(t, int32(ticks), atomic.LoadInt32(&fired))
+}
+
+func TestCronLeaderElectionAlignsConstantDelay(t *testing.T) {
+	m := getTestMetadata("@every 10s")
+	m.Properties["leaderElection"] = "true"
+
+	// Replicas started a few seconds apart compute the same ticks.
+	start := time.Date(2022, 3, 1, 10, 0, 3, 0, time.UTC)
+	var ticks []time.Time
+	for _, offset := range []time.Duration{0, 4 * time.Second} {
+		c := getNewCron()
+		c.SetLockStore(&fakeLockStore{owners: make(map[string]string)})
+		require.NoError(t, c.Init(m))
+		ticks = append(ticks, c.sched.Next(start.Add(offset)))
+	}
+	assert.Equal(t, time.Date(2022, 3, 1, 10, 0, 10, 0, time.UTC), ticks[0])
+	assert.Equal(t, ticks[0], ticks[1])
+
+	// Without leader election, @every schedules keep following the start.
+	c := getNewCron()
+	require.NoError(t, c.Init(getTestMetadata("@every 10s")))
+	assert.Equal(t, start.Add(10*time.Second), c.sched.Next(start))
+}
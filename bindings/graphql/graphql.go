@@ -14,15 +14,17 @@ limitations under the License.
 package graphql
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
-	graphql "github.com/machinebox/graphql"
-
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
 )
@@ -45,18 +47,49 @@ const (
 	MutationOperation bindings.OperationKind = "mutation"
 )
 
-// GraphQL represents GraphQL output bindings.
+// GraphQL represents GraphQL output bindings, and the input binding of a subscription.
 type GraphQL struct {
-	client *graphql.Client
-	header map[string]string
-	logger logger.Logger
+	endpoint     string
+	client       *http.Client
+	header       map[string]string
+	subscription *subscription
+	logger       logger.Logger
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
 }
 
-var _ = bindings.OutputBinding(&GraphQL{})
+// payload is the optional JSON data of the invoke requests.
+type payload struct {
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// request is the body of the GraphQL requests.
+type request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// response is a GraphQL response.
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphqlError  `json:"errors"`
+}
+
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+var (
+	_ = bindings.OutputBinding(&GraphQL{})
+	_ = bindings.InputBinding(&GraphQL{})
+)
 
 // NewGraphQL returns a new GraphQL binding instance.
 func NewGraphQL(logger logger.Logger) *GraphQL {
-	return &GraphQL{logger: logger}
+	return &GraphQL{logger: logger, closeCh: make(chan struct{})}
 }
 
 // Init initializes the GraphQL binding.
@@ -69,10 +102,8 @@ func (gql *GraphQL) Init(metadata bindings.Metadata) error {
 		return fmt.Errorf("GraphQL Error: Missing GraphQL URL")
 	}
 
-	// Connect to GraphQL Server
-	client := graphql.NewClient(ep)
-
-	gql.client = client
+	gql.endpoint = ep
+	gql.client = &http.Client{}
 	gql.header = make(map[string]string)
 	for k, v := range p {
 		if strings.HasPrefix(k, "header:") {
@@ -80,6 +111,12 @@ func (gql *GraphQL) Init(metadata bindings.Metadata) error {
 		}
 	}
 
+	sub, err := parseSubscription(p, ep)
+	if err != nil {
+		return err
+	}
+	gql.subscription = sub
+
 	return nil
 }
 
@@ -116,12 +153,12 @@ func (gql *GraphQL) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*b
 
 	switch req.Operation { // nolint: exhaustive
 	case QueryOperation:
-		if err := gql.runRequest(commandQuery, req, &graphqlResponse); err != nil {
+		if err := gql.runRequest(ctx, commandQuery, req, &graphqlResponse); err != nil {
 			return nil, err
 		}
 
 	case MutationOperation:
-		if err := gql.runRequest(commandMutation, req, &graphqlResponse); err != nil {
+		if err := gql.runRequest(ctx, commandMutation, req, &graphqlResponse); err != nil {
 			return nil, err
 		}
 
@@ -144,7 +181,7 @@ func (gql *GraphQL) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*b
 	return resp, nil
 }
 
-func (gql *GraphQL) runRequest(requestKey string, req *bindings.InvokeRequest, response interface{}) error {
+func (gql *GraphQL) runRequest(ctx context.Context, requestKey string, req *bindings.InvokeRequest, response interface{}) error {
 	requestString, ok := req.Metadata[requestKey]
 	if !ok || requestString == "" {
 		return fmt.Errorf("GraphQL Error: required %q not set", requestKey)
@@ -158,21 +195,72 @@ func (gql *GraphQL) runRequest(requestKey string, req *bindings.InvokeRequest, r
 		return fmt.Errorf("GraphQL Error: command is not a %s", requestKey)
 	}
 
-	request := graphql.NewRequest(requestString)
+	body := request{Query: requestString}
+	if len(req.Data) > 0 {
+		var p payload
+		if err := json.Unmarshal(req.Data, &p); err != nil {
+			return fmt.Errorf("GraphQL Error: invalid payload, expected variables and operationName: %w", err)
+		}
+		body.Variables = p.Variables
+		body.OperationName = p.OperationName
+	}
 
+	header := http.Header{}
 	for headerKey, headerValue := range gql.header {
-		request.Header.Set(headerKey, headerValue)
+		header.Set(headerKey, headerValue)
 	}
 
 	for k, v := range req.Metadata {
 		if strings.HasPrefix(k, "header:") {
-			request.Header.Set(strings.TrimPrefix(k, "header:"), v)
+			header.Set(strings.TrimPrefix(k, "header:"), v)
 		}
 	}
 
-	if err := gql.client.Run(context.Background(), request, response); err != nil {
+	if err := gql.run(ctx, body, header, response); err != nil {
 		return fmt.Errorf("GraphQL Error: %w", err)
 	}
 
 	return nil
 }
+
+// run posts the request and decodes the data of its response into data.
+func (gql *GraphQL) run(ctx context.Context, body request, header http.Header, data interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, gql.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	httpReq.Header = header
+	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	httpReq.Header.Set("Accept", "application/json; charset=utf-8")
+
+	httpResp, err := gql.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("reading body: %w", err)
+	}
+	var resp response
+	if err = json.Unmarshal(respBody, &resp); err != nil {
+		if httpResp.StatusCode != http.StatusOK {
+			return fmt.Errorf("graphql: server returned a non-200 status code: %v", httpResp.StatusCode)
+		}
+
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", resp.Errors[0].Message)
+	}
+	if len(resp.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Data, data)
+}
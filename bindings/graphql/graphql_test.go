@@ -14,9 +14,20 @@ limitations under the License.
 package graphql
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
 )
 
 func TestOperations(t *testing.T) {
@@ -29,3 +40,190 @@ func TestOperations(t *testing.T) {
 		assert.Equal(t, 2, len(l))
 	})
 }
+
+// newTestServer returns a GraphQL server answering the queries with their request, and
+// sending the events of a graphql-ws subscription.
+func newTestServer(t *testing.T, events []string) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{Subprotocols: []string{graphqlWSProtocol}}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			var msg wsMessage
+			require.NoError(t, conn.ReadJSON(&msg))
+			assert.Equal(t, gqlConnectionInit, msg.Type)
+			assert.JSONEq(t, `{"Authorization":"Bearer token"}`, string(msg.Payload))
+			require.NoError(t, conn.WriteJSON(wsMessage{Type: gqlConnectionKeepAlive}))
+			require.NoError(t, conn.WriteJSON(wsMessage{Type: gqlConnectionAck}))
+
+			require.NoError(t, conn.ReadJSON(&msg))
+			assert.Equal(t, gqlStart, msg.Type)
+			var req request
+			require.NoError(t, json.Unmarshal(msg.Payload, &req))
+			assert.Equal(t, "subscription OnReview($episode: Episode) { reviewAdded(episode: $episode) { stars } }", req.Query)
+			assert.Equal(t, map[string]interface{}{"episode": "JEDI"}, req.Variables)
+
+			for _, event := range events {
+				require.NoError(t, conn.WriteJSON(wsMessage{ID: msg.ID, Type: gqlData, Payload: json.RawMessage(event)}))
+			}
+			// Wait for the stop of the client.
+			for {
+				if err := conn.ReadJSON(&msg); err != nil || msg.Type == gqlConnectionTerminate {
+					return
+				}
+			}
+		}
+
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var req request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.OperationName == "Fail" {
+			w.Write([]byte(`{"errors":[{"message":"unknown operation"}]}`))
+
+			return
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"data": map[string]interface{}{
+				"query":         req.Query,
+				"variables":     req.Variables,
+				"operationName": req.OperationName,
+				"tenant":        r.Header.Get("X-Tenant"),
+			},
+		})
+		w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newTestGraphQL(t *testing.T, properties map[string]string) *GraphQL {
+	t.Helper()
+
+	gql := NewGraphQL(logger.NewLogger("test"))
+	require.NoError(t, gql.Init(bindings.Metadata{Properties: properties}))
+	t.Cleanup(func() { gql.Close() })
+
+	return gql
+}
+
+func TestInvokeWithVariables(t *testing.T) {
+	s := newTestServer(t, nil)
+	gql := newTestGraphQL(t, map[string]string{
+		connectionEndPointKey:  s.URL,
+		"header:Authorization": "Bearer token",
+	})
+
+	query := "query Hero($episode: Episode) { hero(episode: $episode) { name } }"
+	resp, err := gql.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+		Data:      []byte(`{"variables": {"episode": "JEDI"}, "operationName": "Hero"}`),
+		Metadata:  map[string]string{commandQuery: query, "header:X-Tenant": "acme"},
+	})
+	require.NoError(t, err)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.Equal(t, query, data["query"])
+	assert.Equal(t, map[string]interface{}{"episode": "JEDI"}, data["variables"])
+	assert.Equal(t, "Hero", data["operationName"])
+	assert.Equal(t, "acme", data["tenant"])
+
+	_, err = gql.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+		Data:      []byte(`{"operationName": "Fail"}`),
+		Metadata:  map[string]string{commandQuery: "query Fail { hero }"},
+	})
+	assert.EqualError(t, err, "GraphQL Error: graphql: unknown operation")
+
+	_, err = gql.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: MutationOperation,
+		Data:      []byte(`not json`),
+		Metadata:  map[string]string{commandMutation: "mutation { addReview }"},
+	})
+	assert.Error(t, err)
+}
+
+func TestSubscription(t *testing.T) {
+	s := newTestServer(t, []string{
+		`{"data":{"reviewAdded":{"stars":5}}}`,
+		`{"errors":[{"message":"skipped"}]}`,
+		`{"data":{"reviewAdded":{"stars":3}}}`,
+	})
+	gql := newTestGraphQL(t, map[string]string{
+		connectionEndPointKey:    s.URL,
+		"header:Authorization":   "Bearer token",
+		subscriptionKey:          "subscription OnReview($episode: Episode) { reviewAdded(episode: $episode) { stars } }",
+		subscriptionVariablesKey: `{"episode": "JEDI"}`,
+	})
+	assert.Equal(t, "ws"+strings.TrimPrefix(s.URL, "http"), gql.subscription.endpoint)
+
+	events := make(chan string, 10)
+	require.NoError(t, gql.Read(func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+		events <- string(r.Data)
+
+		return nil, nil
+	}))
+
+	for _, expected := range []string{`{"reviewAdded":{"stars":5}}`, `{"reviewAdded":{"stars":3}}`} {
+		select {
+		case event := <-events:
+			assert.JSONEq(t, expected, event)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no subscription event")
+		}
+	}
+}
+
+func TestSubscriptionReadTimeout(t *testing.T) {
+	// The server stops sending messages after the event, without closing the connection.
+	s := newTestServer(t, []string{`{"data":{"reviewAdded":{"stars":5}}}`})
+	gql := newTestGraphQL(t, map[string]string{
+		connectionEndPointKey:    s.URL,
+		"header:Authorization":   "Bearer token",
+		subscriptionKey:          "subscription OnReview($episode: Episode) { reviewAdded(episode: $episode) { stars } }",
+		subscriptionVariablesKey: `{"episode": "JEDI"}`,
+		readTimeoutKey:           "1",
+		reconnectWaitKey:         "0",
+	})
+
+	events := make(chan string, 10)
+	require.NoError(t, gql.Read(func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+		events <- string(r.Data)
+
+		return nil, nil
+	}))
+
+	// The event is received again once subscribed again after the timeout.
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-events:
+			assert.JSONEq(t, `{"reviewAdded":{"stars":5}}`, event)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no subscription event")
+		}
+	}
+}
+
+func TestSubscriptionMetadata(t *testing.T) {
+	gql := NewGraphQL(logger.NewLogger("test"))
+	require.NoError(t, gql.Init(bindings.Metadata{Properties: map[string]string{connectionEndPointKey: "https://example.com/graphql"}}))
+	assert.Nil(t, gql.subscription)
+	assert.Error(t, gql.Read(nil))
+
+	for name, properties := range map[string]map[string]string{
+		"invalid variables":      {subscriptionVariablesKey: "{"},
+		"invalid reconnect wait": {reconnectWaitKey: "soon"},
+		"invalid read timeout":   {readTimeoutKey: "0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			properties[connectionEndPointKey] = "https://example.com/graphql"
+			properties[subscriptionKey] = "subscription { reviewAdded { stars } }"
+			assert.Error(t, NewGraphQL(logger.NewLogger("test")).Init(bindings.Metadata{Properties: properties}))
+		})
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	// configurations of the subscription of the input binding.
+	subscriptionKey              = "subscription"
+	subscriptionVariablesKey     = "subscriptionVariables"
+	subscriptionOperationNameKey = "subscriptionOperationName"
+	subscriptionEndpointKey      = "subscriptionEndpoint"
+	reconnectWaitKey             = "reconnectWaitSeconds"
+	readTimeoutKey               = "readTimeoutSeconds"
+
+	defaultReconnectWait = 5 * time.Second
+	// defaultReadTimeout leaves room for the keep-alives servers send every few seconds.
+	defaultReadTimeout = time.Minute
+	handshakeTimeout   = 10 * time.Second
+
+	// graphqlWSProtocol is the websocket sub-protocol of subscriptions-transport-ws.
+	graphqlWSProtocol = "graphql-ws"
+	subscriptionID    = "1"
+)
+
+// Message types of the graphql-ws protocol.
+const (
+	gqlConnectionInit      = "connection_init"
+	gqlConnectionAck       = "connection_ack"
+	gqlConnectionError     = "connection_error"
+	gqlConnectionKeepAlive = "ka"
+	gqlConnectionTerminate = "connection_terminate"
+	gqlStart               = "start"
+	gqlStop                = "stop"
+	gqlData                = "data"
+	gqlError               = "error"
+	gqlComplete            = "complete"
+)
+
+// subscription is the subscription whose events are read by the input binding.
+type subscription struct {
+	endpoint      string
+	request       request
+	reconnectWait time.Duration
+	// readTimeout is the longest time without message, keep-alives included, before reconnecting.
+	readTimeout time.Duration
+}
+
+// wsMessage is a message of the graphql-ws protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// parseSubscription returns the subscription of the metadata, nil if there is none.
+// The websocket endpoint defaults to the endpoint with a ws or wss scheme.
+func parseSubscription(p map[string]string, endpoint string) (*subscription, error) {
+	query := strings.TrimSpace(p[subscriptionKey])
+	if query == "" {
+		return nil, nil
+	}
+
+	sub := &subscription{
+		endpoint:      p[subscriptionEndpointKey],
+		request:       request{Query: query, OperationName: p[subscriptionOperationNameKey]},
+		reconnectWait: defaultReconnectWait,
+		readTimeout:   defaultReadTimeout,
+	}
+	if sub.endpoint == "" {
+		sub.endpoint = endpoint
+		if strings.HasPrefix(endpoint, "http") {
+			sub.endpoint = "ws" + strings.TrimPrefix(endpoint, "http")
+		}
+	}
+
+	if val := p[subscriptionVariablesKey]; val != "" {
+		if err := json.Unmarshal([]byte(val), &sub.request.Variables); err != nil {
+			return nil, fmt.Errorf("GraphQL Error: invalid %s: %w", subscriptionVariablesKey, err)
+		}
+	}
+
+	if val := p[reconnectWaitKey]; val != "" {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("GraphQL Error: invalid %s %s", reconnectWaitKey, val)
+		}
+		sub.reconnectWait = time.Duration(seconds) * time.Second
+	}
+
+	if val := p[readTimeoutKey]; val != "" {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("GraphQL Error: invalid %s %s", readTimeoutKey, val)
+		}
+		sub.readTimeout = time.Duration(seconds) * time.Second
+	}
+
+	return sub, nil
+}
+
+// Read opens the graphql-ws subscription and delivers the data of each of its events to handler.
+// The subscription is opened again when it fails or is completed by the server, until the binding is closed.
+func (gql *GraphQL) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	if gql.subscription == nil {
+		return fmt.Errorf("GraphQL Error: %s not set", subscriptionKey)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-gql.closeCh
+		cancel()
+	}()
+
+	go func() {
+		for {
+			err := gql.subscribe(ctx, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			gql.logger.Warnf("GraphQL Error: subscription interrupted, subscribing again in %s: %v", gql.subscription.reconnectWait, err)
+
+			select {
+			case <-time.After(gql.subscription.reconnectWait):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// subscribe runs the subscription until it fails, is completed, or ctx is done.
+func (gql *GraphQL) subscribe(ctx context.Context, handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	header := http.Header{}
+	initPayload := make(map[string]interface{}, len(gql.header))
+	for k, v := range gql.header {
+		header.Set(k, v)
+		initPayload[k] = v
+	}
+
+	dialer := websocket.Dialer{
+		Subprotocols:     []string{graphqlWSProtocol},
+		HandshakeTimeout: handshakeTimeout,
+		Proxy:            http.ProxyFromEnvironment,
+	}
+	conn, _, err := dialer.DialContext(ctx, gql.subscription.endpoint, header)
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", gql.subscription.endpoint, err)
+	}
+	defer conn.Close()
+
+	var writeLock sync.Mutex
+	write := func(msg wsMessage) error {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+
+		return conn.WriteJSON(msg)
+	}
+
+	// Stopping the subscription on close unblocks the reads.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			write(wsMessage{ID: subscriptionID, Type: gqlStop})
+			write(wsMessage{Type: gqlConnectionTerminate})
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// The headers are also sent in the init payload, where servers read the credentials.
+	initData, err := json.Marshal(initPayload)
+	if err != nil {
+		return err
+	}
+	if err = write(wsMessage{Type: gqlConnectionInit, Payload: initData}); err != nil {
+		return err
+	}
+	// A connection the server stopped answering on is dropped after the read timeout.
+	read := func(msg *wsMessage) error {
+		if err := conn.SetReadDeadline(time.Now().Add(gql.subscription.readTimeout)); err != nil {
+			return err
+		}
+
+		return conn.ReadJSON(msg)
+	}
+	if err = waitAck(read); err != nil {
+		return err
+	}
+
+	startData, err := json.Marshal(gql.subscription.request)
+	if err != nil {
+		return err
+	}
+	if err = write(wsMessage{ID: subscriptionID, Type: gqlStart, Payload: startData}); err != nil {
+		return err
+	}
+	gql.logger.Debugf("GraphQL: subscribed to %s", gql.subscription.endpoint)
+
+	for {
+		var msg wsMessage
+		if err = read(&msg); err != nil {
+			return err
+		}
+
+		switch msg.Type {
+		case gqlData:
+			var resp response
+			if err = json.Unmarshal(msg.Payload, &resp); err != nil {
+				gql.logger.Errorf("GraphQL Error: invalid subscription event: %v", err)
+
+				continue
+			}
+			if len(resp.Errors) > 0 {
+				gql.logger.Errorf("GraphQL Error: subscription event error: %s", resp.Errors[0].Message)
+			}
+			if len(resp.Data) == 0 || string(resp.Data) == "null" {
+				continue
+			}
+			if _, err = handler(ctx, &bindings.ReadResponse{Data: resp.Data}); err != nil {
+				gql.logger.Errorf("GraphQL Error: error handling subscription event: %v", err)
+			}
+		case gqlError:
+			return fmt.Errorf("subscription error: %s", msg.Payload)
+		case gqlComplete:
+			return errors.New("subscription completed by the server")
+		case gqlConnectionError:
+			return fmt.Errorf("connection error: %s", msg.Payload)
+		}
+	}
+}
+
+// waitAck waits for the acknowledgment of the connection, skipping keep-alives.
+func waitAck(read func(*wsMessage) error) error {
+	for {
+		var msg wsMessage
+		if err := read(&msg); err != nil {
+			return err
+		}
+		switch msg.Type {
+		case gqlConnectionAck:
+			return nil
+		case gqlConnectionKeepAlive:
+		case gqlConnectionError:
+			return fmt.Errorf("connection error: %s", msg.Payload)
+		default:
+			return fmt.Errorf("unexpected %s message before %s", msg.Type, gqlConnectionAck)
+		}
+	}
+}
+
+// Close stops the subscription.
+func (gql *GraphQL) Close() error {
+	gql.closeOnce.Do(func() { close(gql.closeCh) })
+
+	return nil
+}
@@ -16,6 +16,10 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,23 +30,61 @@ import (
 	"time"
 	"unicode"
 
-	"github.com/mitchellh/mapstructure"
+	"github.com/cenkalti/backoff/v4"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/config"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
+)
+
+const (
+	// timeoutKey is the metadata key of the timeout of a request, overriding the timeout of the binding.
+	timeoutKey = "timeout"
+
+	defaultTimeout = 10 * time.Second
 )
 
 // HTTPSource is a binding for an http url endpoint invocation
 //revive:disable-next-line
 type HTTPSource struct {
-	metadata httpMetadata
-	client   *http.Client
+	metadata      httpMetadata
+	headers       map[string]string
+	backOffConfig retry.Config
+	client        *http.Client
 
 	logger logger.Logger
 }
 
+// httpMetadata are the properties of the binding. The certificates and credentials can be
+// set inline or from a secret store with secretKeyRef.
 type httpMetadata struct {
 	URL string `mapstructure:"url"`
+	// Timeout of the requests, including reading the response.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Headers is a JSON object of the headers set on every request.
+	Headers string `mapstructure:"headers"`
+
+	// PEM encoded certificates, to authenticate the server and the client with mTLS.
+	CACert     string `mapstructure:"caCert"`
+	ClientCert string `mapstructure:"clientCert"`
+	ClientKey  string `mapstructure:"clientKey"`
+
+	// Basic or bearer authentication of the requests.
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	BearerToken string `mapstructure:"bearerToken"`
+}
+
+// StatusError is returned by Invoke when the server responds with a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received status code %d", e.StatusCode)
 }
 
 // NewHTTP returns a new HTTPSource.
@@ -52,7 +94,34 @@ func NewHTTP(logger logger.Logger) *HTTPSource {
 
 // Init performs metadata parsing.
 func (h *HTTPSource) Init(metadata bindings.Metadata) error {
-	if err := mapstructure.Decode(metadata.Properties, &h.metadata); err != nil {
+	h.metadata = httpMetadata{Timeout: defaultTimeout}
+	if err := config.Decode(metadata.Properties, &h.metadata); err != nil {
+		return err
+	}
+	if h.metadata.Timeout <= 0 {
+		return fmt.Errorf("invalid timeout: %s", h.metadata.Timeout)
+	}
+
+	h.headers = nil
+	if h.metadata.Headers != "" {
+		if err := json.Unmarshal([]byte(h.metadata.Headers), &h.headers); err != nil {
+			return fmt.Errorf("invalid headers, expected a JSON object of strings: %w", err)
+		}
+	}
+
+	if h.metadata.BearerToken != "" && h.metadata.Username != "" {
+		return errors.New("username and bearerToken are exclusive")
+	}
+
+	// Requests are not retried unless backOff properties are set.
+	// Only the requests with idempotent methods are retried.
+	h.backOffConfig = retry.DefaultConfigWithNoRetry()
+	if err := retry.DecodeConfigWithPrefix(&h.backOffConfig, metadata.Properties, "backOff"); err != nil {
+		return fmt.Errorf("retry configuration error: %w", err)
+	}
+
+	tlsConfig, err := h.tlsConfig()
+	if err != nil {
 		return err
 	}
 
@@ -64,15 +133,44 @@ func (h *HTTPSource) Init(metadata bindings.Metadata) error {
 	netTransport := &http.Transport{
 		Dial:                dialer.Dial,
 		TLSHandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:     tlsConfig,
 	}
+	// The timeout is set on the context of each request, as it can be overridden.
 	h.client = &http.Client{
-		Timeout:   time.Second * 10,
 		Transport: netTransport,
 	}
 
 	return nil
 }
 
+// tlsConfig returns the TLS configuration of the client, or nil to use the default one.
+func (h *HTTPSource) tlsConfig() (*tls.Config, error) {
+	m := h.metadata
+	if m.CACert == "" && m.ClientCert == "" && m.ClientKey == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if m.CACert != "" {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM([]byte(m.CACert)) {
+			return nil, errors.New("invalid caCert")
+		}
+	}
+	if m.ClientCert != "" || m.ClientKey != "" {
+		if m.ClientCert == "" || m.ClientKey == "" {
+			return nil, errors.New("clientCert and clientKey must be set together")
+		}
+		cert, err := tls.X509KeyPair([]byte(m.ClientCert), []byte(m.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
 // Operations returns the supported operations for this binding.
 func (h *HTTPSource) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
@@ -101,47 +199,111 @@ func (h *HTTPSource) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		}
 	}
 
-	var body io.Reader
+	hasBody := false
+	idempotent := true
 	method := strings.ToUpper(string(req.Operation))
 	// For backward compatibility
 	if method == "CREATE" {
 		method = "POST"
 	}
 	switch method {
-	case "PUT", "POST", "PATCH":
-		body = bytes.NewBuffer(req.Data)
+	case "POST", "PATCH":
+		hasBody = true
+		idempotent = false
+	case "PUT":
+		hasBody = true
 	case "GET", "HEAD", "DELETE", "OPTIONS", "TRACE":
 	default:
 		return nil, fmt.Errorf("invalid operation: %s", req.Operation)
 	}
 
-	request, err := http.NewRequest(method, u, body)
+	timeout := h.metadata.Timeout
+	if val := req.Metadata[timeoutKey]; val != "" {
+		var err error
+		if timeout, err = time.ParseDuration(val); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout: %s", val)
+		}
+	}
+
+	send := func() (*bindings.InvokeResponse, error) {
+		var body io.Reader
+		if hasBody {
+			body = bytes.NewReader(req.Data)
+		}
+		request, err := h.newRequest(ctx, method, u, body, req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		return h.send(request, timeout)
+	}
+
+	if !idempotent || h.backOffConfig.MaxRetries == 0 {
+		return send()
+	}
+
+	var resp *bindings.InvokeResponse
+	b := backoff.WithContext(h.backOffConfig.NewBackOff(), ctx)
+	err := retry.NotifyRecover(func() error {
+		var err error
+		resp, err = send()
+		if err != nil && !retriable(err) {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}, b, func(err error, d time.Duration) {
+		h.logger.Warnf("http binding: error invoking %s %s, retrying in %s: %s", method, u, d, err)
+	}, func() {
+		h.logger.Infof("http binding: %s %s succeeded after retrying", method, u)
+	})
+
+	return resp, err
+}
+
+// newRequest returns the request with the headers of the binding and of the request metadata.
+func (h *HTTPSource) newRequest(ctx context.Context, method, u string, body io.Reader, metadata map[string]string) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, method, u, body)
 	if err != nil {
 		return nil, err
 	}
-	request = request.WithContext(ctx)
 
 	// Set default values for Content-Type and Accept headers.
 	if body != nil {
-		if _, ok := req.Metadata["Content-Type"]; !ok {
-			request.Header.Set("Content-Type", "application/json; charset=utf-8")
-		}
+		request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	request.Header.Set("Accept", "application/json; charset=utf-8")
+
+	switch {
+	case h.metadata.Username != "":
+		request.SetBasicAuth(h.metadata.Username, h.metadata.Password)
+	case h.metadata.BearerToken != "":
+		request.Header.Set("Authorization", "Bearer "+h.metadata.BearerToken)
 	}
-	if _, ok := req.Metadata["Accept"]; !ok {
-		request.Header.Set("Accept", "application/json; charset=utf-8")
+
+	for key, value := range h.headers {
+		request.Header.Set(key, value)
 	}
 
 	// Any metadata keys that start with a capital letter
 	// are treated as request headers
-	for mdKey, mdValue := range req.Metadata {
+	for mdKey, mdValue := range metadata {
 		keyAsRunes := []rune(mdKey)
 		if len(keyAsRunes) > 0 && unicode.IsUpper(keyAsRunes[0]) {
 			request.Header.Set(mdKey, mdValue)
 		}
 	}
 
+	return request, nil
+}
+
+// send sends request and reads its response within timeout.
+func (h *HTTPSource) send(request *http.Request, timeout time.Duration) (*bindings.InvokeResponse, error) {
+	ctx, cancel := context.WithTimeout(request.Context(), timeout)
+	defer cancel()
+
 	// Send the question
-	resp, err := h.client.Do(request)
+	resp, err := h.client.Do(request.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +329,11 @@ func (h *HTTPSource) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 
 	// Create an error for non-200 status codes.
 	if resp.StatusCode/100 != 2 {
-		err = fmt.Errorf("received status code %d", resp.StatusCode)
+		err = &StatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       b,
+		}
 	}
 
 	return &bindings.InvokeResponse{
@@ -175,3 +341,19 @@ func (h *HTTPSource) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		Metadata: metadata,
 	}, err
 }
+
+// retriable returns true if the request failing with err can succeed when sent again.
+func retriable(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		// Connection errors and timeouts.
+		return true
+	}
+
+	switch statusErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -15,11 +15,22 @@ package http_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -192,3 +203,186 @@ func TestInit(t *testing.T) {
 		})
 	}
 }
+
+func newHTTP(t *testing.T, properties map[string]string) *binding_http.HTTPSource {
+	t.Helper()
+
+	hs := binding_http.NewHTTP(logger.NewLogger("test"))
+	require.NoError(t, hs.Init(bindings.Metadata{Properties: properties}))
+
+	return hs
+}
+
+func TestInitErrors(t *testing.T) {
+	for name, properties := range map[string]map[string]string{
+		"invalid timeout":       {"timeout": "soon"},
+		"invalid headers":       {"headers": "X-Key: value"},
+		"basic and bearer auth": {"username": "user", "bearerToken": "token"},
+		"invalid ca":            {"caCert": "not a certificate"},
+		"client cert alone":     {"clientCert": "cert"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			properties["url"] = "http://localhost"
+			hs := binding_http.NewHTTP(logger.NewLogger("test"))
+			assert.Error(t, hs.Init(bindings.Metadata{Properties: properties}))
+		})
+	}
+}
+
+func TestHeadersAndAuth(t *testing.T) {
+	var header http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header
+	}))
+	defer s.Close()
+
+	hs := newHTTP(t, map[string]string{
+		"url":         s.URL,
+		"headers":     `{"X-Api-Version": "2", "X-Tenant": "default"}`,
+		"bearerToken": "token",
+	})
+	_, err := hs.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: "get",
+		Metadata:  map[string]string{"X-Tenant": "acme"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	assert.Equal(t, "2", header.Get("X-Api-Version"))
+	assert.Equal(t, "acme", header.Get("X-Tenant"))
+
+	hs = newHTTP(t, map[string]string{"url": s.URL, "username": "user", "password": "secret"})
+	_, err = hs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: "get"})
+	require.NoError(t, err)
+	req := http.Request{Header: header}
+	username, password, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+}
+
+func TestTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer s.Close()
+
+	hs := newHTTP(t, map[string]string{"url": s.URL, "timeout": "50ms"})
+	_, err := hs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: "get"})
+	assert.Error(t, err)
+
+	_, err = hs.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: "get",
+		Metadata:  map[string]string{"timeout": "1s"},
+	})
+	assert.NoError(t, err)
+
+	_, err = hs.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: "get",
+		Metadata:  map[string]string{"timeout": "never"},
+	})
+	assert.Error(t, err)
+}
+
+func TestRetry(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch {
+		case req.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case n <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer s.Close()
+
+	hs := newHTTP(t, map[string]string{
+		"url":               s.URL,
+		"backOffDuration":   "10ms",
+		"backOffMaxRetries": "3",
+		"backOffPolicy":     "constant",
+	})
+
+	t.Run("idempotent method retried", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		resp, err := hs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: "get"})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(resp.Data))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("post not retried", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		resp, err := hs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: "post", Data: []byte("{}")})
+		var statusErr *binding_http.StatusError
+		require.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+		assert.Equal(t, "503", resp.Metadata["statusCode"])
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("client error not retried", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 10)
+		_, err := hs.Invoke(context.TODO(), &bindings.InvokeRequest{
+			Operation: "get",
+			Metadata:  map[string]string{"path": "/missing"},
+		})
+		var statusErr *binding_http.StatusError
+		require.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		assert.Equal(t, int32(11), atomic.LoadInt32(&calls))
+	})
+}
+
+// newCert returns a self-signed PEM certificate and its key.
+func newCert(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestMutualTLS(t *testing.T) {
+	var clientCN string
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		clientCN = req.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	s.StartTLS()
+	defer s.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+	clientCert, clientKey := newCert(t)
+
+	// Without a client certificate the handshake fails.
+	hs := newHTTP(t, map[string]string{"url": s.URL, "caCert": caCert})
+	_, err := hs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: "get"})
+	assert.Error(t, err)
+
+	hs = newHTTP(t, map[string]string{
+		"url":        s.URL,
+		"caCert":     caCert,
+		"clientCert": clientCert,
+		"clientKey":  clientKey,
+	})
+	_, err = hs.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: "get"})
+	require.NoError(t, err)
+	assert.Equal(t, "client", clientCN)
+}
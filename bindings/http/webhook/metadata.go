@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	portKey               = "port"
+	pathKey               = "path"
+	maxBodySizeKey        = "maxBodySize"
+	readHeaderTimeoutKey  = "readHeaderTimeoutSeconds"
+	readTimeoutKey        = "readTimeoutSeconds"
+	signatureSchemeKey    = "signatureScheme"
+	signatureHeaderKey    = "signatureHeader"
+	signatureToleranceKey = "signatureToleranceSeconds"
+	secretKey             = "secret"
+	secretKeyNameKey      = "secretKeyName"
+
+	defaultPath               = "/"
+	defaultMaxBodySize        = 4 << 20
+	defaultReadHeaderTimeout  = 10 * time.Second
+	defaultReadTimeout        = time.Minute
+	defaultSignatureTolerance = 5 * time.Minute
+	defaultHMACHeader         = "X-Signature"
+)
+
+type metadata struct {
+	port        int
+	path        string
+	maxBodySize int64
+	// readHeaderTimeout and readTimeout bound the time the server waits for the headers,
+	// and the whole request, of clients.
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+
+	// signatureScheme verifies the HMAC signatures of requests, none if empty.
+	signatureScheme    string
+	signatureHeader    string
+	signatureTolerance time.Duration
+	// secret signs the requests. It is set inline or read from the secret store with secretKeyName.
+	secret        string
+	secretKeyName string
+}
+
+func parseMetadata(meta bindings.Metadata) (*metadata, error) {
+	m := metadata{
+		path:               defaultPath,
+		maxBodySize:        defaultMaxBodySize,
+		readHeaderTimeout:  defaultReadHeaderTimeout,
+		readTimeout:        defaultReadTimeout,
+		signatureTolerance: defaultSignatureTolerance,
+	}
+
+	val, ok := meta.Properties[portKey]
+	if !ok || val == "" {
+		return nil, errors.New("webhook binding: missing port")
+	}
+	port, err := strconv.Atoi(val)
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("webhook binding: invalid port %s", val)
+	}
+	m.port = port
+
+	if val = meta.Properties[pathKey]; val != "" {
+		if !strings.HasPrefix(val, "/") {
+			return nil, fmt.Errorf("webhook binding: invalid path %s, expected an absolute path", val)
+		}
+		m.path = val
+	}
+
+	if val = meta.Properties[maxBodySizeKey]; val != "" {
+		if m.maxBodySize, err = strconv.ParseInt(val, 10, 64); err != nil || m.maxBodySize <= 0 {
+			return nil, fmt.Errorf("webhook binding: invalid %s %s", maxBodySizeKey, val)
+		}
+	}
+
+	for key, timeout := range map[string]*time.Duration{readHeaderTimeoutKey: &m.readHeaderTimeout, readTimeoutKey: &m.readTimeout} {
+		if val = meta.Properties[key]; val != "" {
+			seconds, err := strconv.Atoi(val)
+			if err != nil || seconds <= 0 {
+				return nil, fmt.Errorf("webhook binding: invalid %s %s", key, val)
+			}
+			*timeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	m.signatureScheme = meta.Properties[signatureSchemeKey]
+	if m.signatureScheme == "" {
+		return &m, nil
+	}
+	if _, ok := verifiers[m.signatureScheme]; !ok {
+		return nil, fmt.Errorf("webhook binding: invalid %s %s, expected %s, %s or %s", signatureSchemeKey, m.signatureScheme, schemeHMAC, schemeGitHub, schemeStripe)
+	}
+
+	m.signatureHeader = meta.Properties[signatureHeaderKey]
+	if m.signatureHeader == "" {
+		m.signatureHeader = defaultHMACHeader
+	}
+
+	if val = meta.Properties[signatureToleranceKey]; val != "" {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("webhook binding: invalid %s %s", signatureToleranceKey, val)
+		}
+		m.signatureTolerance = time.Duration(seconds) * time.Second
+	}
+
+	m.secret = meta.Properties[secretKey]
+	m.secretKeyName = meta.Properties[secretKeyNameKey]
+	if (m.secret == "") == (m.secretKeyName == "") {
+		return nil, fmt.Errorf("webhook binding: signatures require either %s or %s", secretKey, secretKeyNameKey)
+	}
+
+	return &m, nil
+}
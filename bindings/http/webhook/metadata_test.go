@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+func TestParseMetadata(t *testing.T) {
+	m, err := parseMetadata(bindings.Metadata{Properties: map[string]string{portKey: "8080"}})
+	require.NoError(t, err)
+	assert.Equal(t, 8080, m.port)
+	assert.Equal(t, defaultPath, m.path)
+	assert.Equal(t, int64(defaultMaxBodySize), m.maxBodySize)
+	assert.Equal(t, defaultReadHeaderTimeout, m.readHeaderTimeout)
+	assert.Equal(t, defaultReadTimeout, m.readTimeout)
+	assert.Empty(t, m.signatureScheme)
+
+	m, err = parseMetadata(bindings.Metadata{Properties: map[string]string{
+		portKey:               "8080",
+		pathKey:               "/stripe",
+		maxBodySizeKey:        "1024",
+		readHeaderTimeoutKey:  "2",
+		readTimeoutKey:        "30",
+		signatureSchemeKey:    schemeStripe,
+		signatureToleranceKey: "60",
+		secretKey:             "whsec",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "/stripe", m.path)
+	assert.Equal(t, int64(1024), m.maxBodySize)
+	assert.Equal(t, 2*time.Second, m.readHeaderTimeout)
+	assert.Equal(t, 30*time.Second, m.readTimeout)
+	assert.Equal(t, time.Minute, m.signatureTolerance)
+	assert.Equal(t, "whsec", m.secret)
+
+	for name, properties := range map[string]map[string]string{
+		"missing port":      {},
+		"invalid port":      {portKey: "http"},
+		"relative path":     {portKey: "8080", pathKey: "hooks"},
+		"invalid body size": {portKey: "8080", maxBodySizeKey: "-1"},
+		"invalid timeout":   {portKey: "8080", readTimeoutKey: "0"},
+		"invalid scheme":    {portKey: "8080", signatureSchemeKey: "md5"},
+		"missing secret":    {portKey: "8080", signatureSchemeKey: schemeGitHub},
+		"two secrets":       {portKey: "8080", signatureSchemeKey: schemeGitHub, secretKey: "a", secretKeyNameKey: "b"},
+		"invalid tolerance": {portKey: "8080", signatureSchemeKey: schemeStripe, secretKey: "a", signatureToleranceKey: "0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMetadata(bindings.Metadata{Properties: properties})
+			assert.Error(t, err)
+		})
+	}
+}
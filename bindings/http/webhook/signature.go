@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature schemes.
+const (
+	// schemeHMAC is the hex HMAC-SHA256 of the body in signatureHeader, optionally prefixed by "sha256=".
+	schemeHMAC = "hmac-sha256"
+	// schemeGitHub is the signature of GitHub webhooks, in the X-Hub-Signature-256 header.
+	schemeGitHub = "github"
+	// schemeStripe is the signature of Stripe webhooks, in the Stripe-Signature header.
+	schemeStripe = "stripe"
+
+	gitHubHeader = "X-Hub-Signature-256"
+	stripeHeader = "Stripe-Signature"
+)
+
+// verifier returns an error if the request with header and body is not signed with secret.
+type verifier func(m *metadata, header http.Header, body, secret []byte) error
+
+var verifiers = map[string]verifier{
+	schemeHMAC:   verifyHMAC,
+	schemeGitHub: verifyGitHub,
+	schemeStripe: verifyStripe,
+}
+
+func verifyHMAC(m *metadata, header http.Header, body, secret []byte) error {
+	return verifyHex(header.Get(m.signatureHeader), body, secret)
+}
+
+func verifyGitHub(m *metadata, header http.Header, body, secret []byte) error {
+	val := header.Get(gitHubHeader)
+	if !strings.HasPrefix(val, "sha256=") {
+		return fmt.Errorf("missing %s header", gitHubHeader)
+	}
+
+	return verifyHex(val, body, secret)
+}
+
+// verifyStripe verifies the signatures of the timestamped payload, rejecting the requests
+// signed more than the signature tolerance ago to prevent replays.
+func verifyStripe(m *metadata, header http.Header, body, secret []byte) error {
+	val := header.Get(stripeHeader)
+	if val == "" {
+		return fmt.Errorf("missing %s header", stripeHeader)
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, item := range strings.Split(val, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp in %s header", stripeHeader)
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > m.signatureTolerance || age < -m.signatureTolerance {
+		return errors.New("signature timestamp out of tolerance")
+	}
+
+	payload := make([]byte, 0, len(timestamp)+1+len(body))
+	payload = append(payload, timestamp...)
+	payload = append(payload, '.')
+	payload = append(payload, body...)
+	for _, signature := range signatures {
+		if verifyHex(signature, payload, secret) == nil {
+			return nil
+		}
+	}
+
+	return errors.New("no matching signature")
+}
+
+// verifyHex verifies the hex HMAC-SHA256 signature of payload, optionally prefixed by "sha256=".
+func verifyHex(signature string, payload, secret []byte) error {
+	if signature == "" {
+		return errors.New("missing signature")
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(expected, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
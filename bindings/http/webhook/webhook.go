@@ -0,0 +1,213 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook is an input binding receiving HTTP calls, like the webhooks of SaaS services.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Webhook is an input binding listening on a port for the POST requests of a path.
+// The body and headers of a request are read by the handler, whose response is sent back.
+type Webhook struct {
+	metadata    metadata
+	secretStore secretstores.SecretStore
+	secret      []byte
+	verifier    verifier
+
+	server *http.Server
+	lock   sync.Mutex
+	logger logger.Logger
+}
+
+var (
+	_ = bindings.InputBinding(&Webhook{})
+	_ = bindings.SecretStoreSetter(&Webhook{})
+)
+
+// NewWebhook returns a new webhook input binding.
+func NewWebhook(logger logger.Logger) *Webhook {
+	return &Webhook{logger: logger}
+}
+
+// SetSecretStore sets the secret store the signing secret is read from with secretKeyName.
+// The host calls it before Init, see bindings.SecretStoreSetter.
+func (w *Webhook) SetSecretStore(store secretstores.SecretStore) {
+	w.secretStore = store
+}
+
+// Init parses the metadata and reads the signing secret.
+func (w *Webhook) Init(metadata bindings.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	w.metadata = *m
+
+	if w.metadata.signatureScheme == "" {
+		return nil
+	}
+	w.verifier = verifiers[w.metadata.signatureScheme]
+
+	w.secret = []byte(w.metadata.secret)
+	if w.metadata.secretKeyName != "" {
+		if w.secret, err = w.readSecret(w.metadata.secretKeyName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSecret returns the value of the secret named name.
+func (w *Webhook) readSecret(name string) ([]byte, error) {
+	if w.secretStore == nil {
+		return nil, fmt.Errorf("webhook binding: no secret store to read %s from", name)
+	}
+	resp, err := w.secretStore.GetSecret(secretstores.GetSecretRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("webhook binding: error reading secret %s: %w", name, err)
+	}
+
+	value, ok := resp.Data[name]
+	if !ok && len(resp.Data) == 1 {
+		for _, v := range resp.Data {
+			value = v
+		}
+	}
+	if value == "" {
+		return nil, fmt.Errorf("webhook binding: secret %s not found", name)
+	}
+
+	return []byte(value), nil
+}
+
+// Read starts listening for requests, delivered to handler until the binding is closed.
+func (w *Webhook) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.server != nil {
+		return errors.New("webhook binding: already reading")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", w.metadata.port))
+	if err != nil {
+		return fmt.Errorf("webhook binding: error listening on port %d: %w", w.metadata.port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(w.metadata.path, w.handle(handler))
+	w.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: w.metadata.readHeaderTimeout,
+		ReadTimeout:       w.metadata.readTimeout,
+	}
+
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			w.logger.Errorf("webhook binding: error serving requests: %s", err)
+		}
+	}(w.server)
+	w.logger.Infof("webhook binding: listening on port %d for %s", w.metadata.port, w.metadata.path)
+
+	return nil
+}
+
+// handle returns the HTTP handler delivering the requests to handler.
+func (w *Webhook) handle(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != w.metadata.path {
+			http.NotFound(rw, req)
+
+			return
+		}
+		if req.Method != http.MethodPost {
+			rw.Header().Set("Allow", http.MethodPost)
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(rw, req.Body, w.metadata.maxBodySize))
+		if err != nil {
+			http.Error(rw, "request body too large", http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		if w.verifier != nil {
+			if err = w.verifier(&w.metadata, req.Header, body, w.secret); err != nil {
+				w.logger.Warnf("webhook binding: rejecting request from %s: %s", req.RemoteAddr, err)
+				http.Error(rw, "invalid signature", http.StatusUnauthorized)
+
+				return
+			}
+		}
+
+		// Headers with multiple values are delimited with ", ".
+		md := make(map[string]string, len(req.Header)+2)
+		for key, values := range req.Header {
+			md[key] = strings.Join(values, ", ")
+		}
+		md["path"] = req.URL.Path
+		md["query"] = req.URL.RawQuery
+
+		data, err := handler(req.Context(), &bindings.ReadResponse{
+			Data:     body,
+			Metadata: md,
+		})
+		if err != nil {
+			// The error may expose internals of the application to the caller.
+			w.logger.Errorf("webhook binding: error handling request: %s", err)
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(data)
+	}
+}
+
+// Close stops listening, waiting for the requests being handled.
+func (w *Webhook) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := w.server.Shutdown(ctx)
+	w.server = nil
+
+	return err
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeSecretStore map[string]string
+
+func (f fakeSecretStore) Init(metadata secretstores.Metadata) error {
+	return nil
+}
+
+func (f fakeSecretStore) GetSecret(req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	return secretstores.GetSecretResponse{Data: map[string]string{req.Name: f[req.Name]}}, nil
+}
+
+func (f fakeSecretStore) BulkGetSecret(req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	return secretstores.BulkGetSecretResponse{}, nil
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startWebhook starts a webhook binding and returns its url.
+func startWebhook(t *testing.T, w *Webhook, properties map[string]string, handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) string {
+	t.Helper()
+
+	port := freePort(t)
+	properties[portKey] = strconv.Itoa(port)
+	require.NoError(t, w.Init(bindings.Metadata{Properties: properties}))
+	require.NoError(t, w.Read(handler))
+	t.Cleanup(func() { w.Close() })
+
+	return fmt.Sprintf("http://127.0.0.1:%d%s", port, w.metadata.path)
+}
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func post(t *testing.T, url string, body []byte, header http.Header) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(data)
+}
+
+func TestRead(t *testing.T) {
+	var received *bindings.ReadResponse
+	url := startWebhook(t, NewWebhook(logger.NewLogger("test")), map[string]string{pathKey: "/hooks"},
+		func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+			if string(r.Data) == "fail" {
+				return nil, errors.New("handler failed")
+			}
+			received = r
+
+			return []byte("handled"), nil
+		})
+
+	status, body := post(t, url+"?source=ci", []byte(`{"event":"push"}`), http.Header{"X-Event": {"push"}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "handled", body)
+	require.NotNil(t, received)
+	assert.Equal(t, `{"event":"push"}`, string(received.Data))
+	assert.Equal(t, "push", received.Metadata["X-Event"])
+	assert.Equal(t, "/hooks", received.Metadata["path"])
+	assert.Equal(t, "source=ci", received.Metadata["query"])
+
+	status, body = post(t, url, []byte("fail"), nil)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.NotContains(t, body, "handler failed")
+
+	status, _ = post(t, url+"/other", nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestSignatures(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	handler := func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+		return nil, nil
+	}
+
+	t.Run("github with secret store", func(t *testing.T) {
+		w := NewWebhook(logger.NewLogger("test"))
+		w.SetSecretStore(fakeSecretStore{"github-webhook": "s3cr3t"})
+		url := startWebhook(t, w, map[string]string{
+			signatureSchemeKey: schemeGitHub,
+			secretKeyNameKey:   "github-webhook",
+		}, handler)
+
+		status, _ := post(t, url, body, http.Header{gitHubHeader: {"sha256=" + sign("s3cr3t", body)}})
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = post(t, url, body, http.Header{gitHubHeader: {"sha256=" + sign("other", body)}})
+		assert.Equal(t, http.StatusUnauthorized, status)
+		status, _ = post(t, url, body, nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("stripe", func(t *testing.T) {
+		url := startWebhook(t, NewWebhook(logger.NewLogger("test")), map[string]string{
+			signatureSchemeKey: schemeStripe,
+			secretKey:          "whsec",
+		}, handler)
+
+		stripeSignature := func(at time.Time) string {
+			ts := strconv.FormatInt(at.Unix(), 10)
+
+			return fmt.Sprintf("t=%s,v1=%s,v1=%s", ts, sign("old", []byte(ts+"."+string(body))), sign("whsec", []byte(ts+"."+string(body))))
+		}
+		status, _ := post(t, url, body, http.Header{stripeHeader: {stripeSignature(time.Now())}})
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = post(t, url, body, http.Header{stripeHeader: {stripeSignature(time.Now().Add(-time.Hour))}})
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("hmac", func(t *testing.T) {
+		url := startWebhook(t, NewWebhook(logger.NewLogger("test")), map[string]string{
+			signatureSchemeKey: schemeHMAC,
+			signatureHeaderKey: "X-Webhook-Signature",
+			secretKey:          "key",
+		}, handler)
+
+		status, _ := post(t, url, body, http.Header{"X-Webhook-Signature": {sign("key", body)}})
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = post(t, url, []byte("tampered"), http.Header{"X-Webhook-Signature": {sign("key", body)}})
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
+
+func TestSecretNotFound(t *testing.T) {
+	w := NewWebhook(logger.NewLogger("test"))
+	w.SetSecretStore(fakeSecretStore{})
+	err := w.Init(bindings.Metadata{Properties: map[string]string{
+		portKey:            "8080",
+		signatureSchemeKey: schemeGitHub,
+		secretKeyNameKey:   "missing",
+	}})
+	assert.Error(t, err)
+}
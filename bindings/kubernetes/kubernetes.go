@@ -17,15 +17,23 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/cache"
 
 	kubeclient "github.com/dapr/components-contrib/authentication/kubernetes"
@@ -33,42 +41,111 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
-type kubernetesInput struct {
-	kubeClient        kubernetes.Interface
-	namespace         string
+const (
+	// allNamespaces in namespaces watches the resources of all namespaces.
+	allNamespaces = "*"
+
+	defaultAPIVersion = "v1"
+	defaultKind       = "Event"
+)
+
+// kubernetesBinding watches the resources of a kind, and gets, lists, applies and deletes them.
+// The core events are watched by default.
+type kubernetesBinding struct {
+	dynamicClient     dynamic.Interface
+	discoveryClient   discovery.DiscoveryInterface
+	namespaces        []string
 	resyncPeriodInSec time.Duration
-	logger            logger.Logger
+	gvk               schema.GroupVersionKind
+	labelSelector     string
+	fieldSelector     string
+
+	// resource and namespaced are resolved from gvk on Init.
+	resource   schema.GroupVersionResource
+	namespaced bool
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	logger    logger.Logger
 }
 
+// EventResponse is the data read for the addition, update or deletion of a resource.
 type EventResponse struct {
-	Event  string   `json:"event"`
-	OldVal v1.Event `json:"oldVal"`
-	NewVal v1.Event `json:"newVal"`
+	Event  string                     `json:"event"`
+	OldVal *unstructured.Unstructured `json:"oldVal"`
+	NewVal *unstructured.Unstructured `json:"newVal"`
 }
 
-var _ = bindings.InputBinding(&kubernetesInput{})
+var (
+	_ = bindings.InputBinding(&kubernetesBinding{})
+	_ = bindings.OutputBinding(&kubernetesBinding{})
+)
 
-// NewKubernetes returns a new Kubernetes event input binding.
+// NewKubernetes returns a new Kubernetes input binding, watching the resources of a kind.
 func NewKubernetes(logger logger.Logger) bindings.InputBinding {
-	return &kubernetesInput{logger: logger}
+	return newKubernetes(logger)
+}
+
+// NewKubernetesOutput returns a new Kubernetes output binding, managing the resources of a kind.
+func NewKubernetesOutput(logger logger.Logger) bindings.OutputBinding {
+	return newKubernetes(logger)
 }
 
-func (k *kubernetesInput) Init(metadata bindings.Metadata) error {
-	client, err := kubeclient.GetKubeClient()
+func newKubernetes(logger logger.Logger) *kubernetesBinding {
+	return &kubernetesBinding{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+func (k *kubernetesBinding) Init(metadata bindings.Metadata) error {
+	if err := k.parseMetadata(metadata); err != nil {
+		return err
+	}
+
+	conf, err := kubeclient.GetKubeConfig()
 	if err != nil {
 		return err
 	}
-	k.kubeClient = client
+	if k.dynamicClient, err = dynamic.NewForConfig(conf); err != nil {
+		return err
+	}
+	if k.discoveryClient, err = discovery.NewDiscoveryClientForConfig(conf); err != nil {
+		return err
+	}
 
-	return k.parseMetadata(metadata)
+	return k.resolveResource()
 }
 
-func (k *kubernetesInput) parseMetadata(metadata bindings.Metadata) error {
-	if val, ok := metadata.Properties["namespace"]; ok && val != "" {
-		k.namespace = val
-	} else {
-		return errors.New("namespace is missing in metadata")
+func (k *kubernetesBinding) parseMetadata(metadata bindings.Metadata) error {
+	for _, key := range []string{"namespace", "namespaces"} {
+		for _, ns := range strings.Split(metadata.Properties[key], ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				k.namespaces = append(k.namespaces, ns)
+			}
+		}
+	}
+
+	apiVersion, kind := metadata.Properties["apiVersion"], metadata.Properties["kind"]
+	if (apiVersion == "") != (kind == "") {
+		return errors.New("apiVersion and kind must be set together")
+	}
+	if kind == "" {
+		// The core events are namespaced.
+		if len(k.namespaces) == 0 {
+			return errors.New("namespace is missing in metadata")
+		}
+		apiVersion, kind = defaultAPIVersion, defaultKind
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return fmt.Errorf("invalid apiVersion %s: %w", apiVersion, err)
 	}
+	k.gvk = gv.WithKind(kind)
+
+	k.labelSelector = metadata.Properties["labelSelector"]
+	k.fieldSelector = metadata.Properties["fieldSelector"]
+
 	if val, ok := metadata.Properties["resyncPeriodInSec"]; ok && val != "" {
 		intval, err := strconv.ParseInt(val, 10, 64)
 		if err != nil {
@@ -82,74 +159,115 @@ func (k *kubernetesInput) parseMetadata(metadata bindings.Metadata) error {
 	return nil
 }
 
-func (k *kubernetesInput) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
-	watchlist := cache.NewListWatchFromClient(
-		k.kubeClient.CoreV1().RESTClient(),
-		"events",
-		k.namespace,
-		fields.Everything())
-	var resultChan chan EventResponse = make(chan EventResponse)
-	_, controller := cache.NewInformer(
-		watchlist,
-		&v1.Event{},
-		k.resyncPeriodInSec,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				if obj != nil {
-					resultChan <- EventResponse{
-						Event:  "add",
-						NewVal: *(obj.(*v1.Event)),
-						OldVal: v1.Event{},
-					}
-				} else {
-					k.logger.Warnf("Nil Object in Add handle %v", obj)
-				}
-			},
-			DeleteFunc: func(obj interface{}) {
-				if obj != nil {
-					resultChan <- EventResponse{
-						Event:  "delete",
-						OldVal: *(obj.(*v1.Event)),
-						NewVal: v1.Event{},
-					}
-				} else {
-					k.logger.Warnf("Nil Object in Delete handle %v", obj)
-				}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				if oldObj != nil && newObj != nil {
-					resultChan <- EventResponse{
-						Event:  "update",
-						OldVal: *(oldObj.(*v1.Event)),
-						NewVal: *(newObj.(*v1.Event)),
-					}
-				} else {
-					k.logger.Warnf("Nil Objects in Update handle %v %v", oldObj, newObj)
-				}
-			},
-		},
-	)
+// resolveResource resolves the resource of the kind with the discovery API.
+func (k *kubernetesBinding) resolveResource() error {
+	groupResources, err := restmapper.GetAPIGroupResources(k.discoveryClient)
+	if err != nil {
+		return fmt.Errorf("error discovering the API resources: %w", err)
+	}
+	mapping, err := restmapper.NewDiscoveryRESTMapper(groupResources).RESTMapping(k.gvk.GroupKind(), k.gvk.Version)
+	if err != nil {
+		return fmt.Errorf("unknown kind %s: %w", k.gvk, err)
+	}
+
+	k.resource = mapping.Resource
+	k.namespaced = mapping.Scope.Name() == meta.RESTScopeNameNamespace
+	if k.namespaced && len(k.namespaces) == 0 {
+		return fmt.Errorf("namespace is missing in metadata, %s is namespaced", k.gvk.Kind)
+	}
+
+	return nil
+}
+
+// watchedNamespaces returns the namespaces of the informers, all of them for cluster scoped resources.
+func (k *kubernetesBinding) watchedNamespaces() []string {
+	if !k.namespaced {
+		return []string{metav1.NamespaceAll}
+	}
+	for _, ns := range k.namespaces {
+		if ns == allNamespaces {
+			return []string{metav1.NamespaceAll}
+		}
+	}
+
+	return k.namespaces
+}
+
+func (k *kubernetesBinding) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	resultChan := make(chan EventResponse)
 	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	send := func(event EventResponse) {
+		select {
+		case resultChan <- event:
+		case <-stopCh:
+		}
+	}
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				send(EventResponse{Event: "add", NewVal: u})
+			} else {
+				k.logger.Warnf("Unexpected Object in Add handle %v", obj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				send(EventResponse{Event: "delete", OldVal: u})
+			} else {
+				k.logger.Warnf("Unexpected Object in Delete handle %v", obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldU, oldOK := oldObj.(*unstructured.Unstructured)
+			newU, newOK := newObj.(*unstructured.Unstructured)
+			if oldOK && newOK {
+				send(EventResponse{Event: "update", OldVal: oldU, NewVal: newU})
+			} else {
+				k.logger.Warnf("Unexpected Objects in Update handle %v %v", oldObj, newObj)
+			}
+		},
+	}
+
+	tweak := func(options *metav1.ListOptions) {
+		options.LabelSelector = k.labelSelector
+		options.FieldSelector = k.fieldSelector
+	}
+	for _, ns := range k.watchedNamespaces() {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(k.dynamicClient, k.resyncPeriodInSec, ns, tweak)
+		factory.ForResource(k.resource).Informer().AddEventHandler(handlers)
+		factory.Start(stopCh)
+	}
+
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
-	go controller.Run(stopCh)
-	done := false
-	for !done {
+	defer signal.Stop(sigterm)
+	for {
 		select {
 		case obj := <-resultChan:
 			data, err := json.Marshal(obj)
 			if err != nil {
-				k.logger.Errorf("Error marshalling event %w", err)
+				k.logger.Errorf("Error marshalling event %v", err)
 			} else {
 				handler(context.TODO(), &bindings.ReadResponse{
 					Data: data,
 				})
 			}
 		case <-sigterm:
-			done = true
-			close(stopCh)
+			return nil
+		case <-k.closeCh:
+			return nil
 		}
 	}
+}
+
+// Close stops watching the resources.
+func (k *kubernetesBinding) Close() error {
+	k.closeOnce.Do(func() { close(k.closeCh) })
 
 	return nil
 }
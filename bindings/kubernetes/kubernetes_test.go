@@ -30,17 +30,17 @@ func TestParseMetadata(t *testing.T) {
 		m := bindings.Metadata{}
 		m.Properties = map[string]string{"namespace": nsName, "resyncPeriodInSec": "15"}
 
-		i := kubernetesInput{logger: logger.NewLogger("test")}
+		i := kubernetesBinding{logger: logger.NewLogger("test")}
 		i.parseMetadata(m)
 
-		assert.Equal(t, nsName, i.namespace, "The namespaces should be the same.")
+		assert.Equal(t, []string{nsName}, i.namespaces, "The namespaces should be the same.")
 		assert.Equal(t, resyncPeriod, i.resyncPeriodInSec, "The resyncPeriod should be the same.")
 	})
 	t.Run("parse metadata no namespace", func(t *testing.T) {
 		m := bindings.Metadata{}
 		m.Properties = map[string]string{"resyncPeriodInSec": "15"}
 
-		i := kubernetesInput{logger: logger.NewLogger("test")}
+		i := kubernetesBinding{logger: logger.NewLogger("test")}
 		err := i.parseMetadata(m)
 
 		assert.NotNil(t, err, "Expected err to be returned.")
@@ -50,11 +50,11 @@ func TestParseMetadata(t *testing.T) {
 		m := bindings.Metadata{}
 		m.Properties = map[string]string{"namespace": nsName, "resyncPeriodInSec": "invalid"}
 
-		i := kubernetesInput{logger: logger.NewLogger("test")}
+		i := kubernetesBinding{logger: logger.NewLogger("test")}
 		err := i.parseMetadata(m)
 
 		assert.Nil(t, err, "Expected err to be nil.")
-		assert.Equal(t, nsName, i.namespace, "The namespaces should be the same.")
+		assert.Equal(t, []string{nsName}, i.namespaces, "The namespaces should be the same.")
 		assert.Equal(t, time.Second*10, i.resyncPeriodInSec, "The resyncPeriod should be the same.")
 	})
 }
@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	applyOperation bindings.OperationKind = "apply"
+
+	nameMetadataKey          = "name"
+	namespaceMetadataKey     = "namespace"
+	labelSelectorMetadataKey = "labelSelector"
+	fieldSelectorMetadataKey = "fieldSelector"
+
+	// fieldManager is the field manager of the resources applied by the binding.
+	fieldManager = "dapr"
+)
+
+// Operations returns the operations on the resources of the kind.
+func (k *kubernetesBinding) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		bindings.GetOperation,
+		bindings.ListOperation,
+		applyOperation,
+		bindings.DeleteOperation,
+	}
+}
+
+// Invoke gets, lists, applies or deletes resources of the kind.
+// The name and namespace of the resource are set in the metadata of req, the namespace defaulting
+// to the namespace of the binding. The data of apply is the JSON or YAML resource.
+func (k *kubernetesBinding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case bindings.GetOperation:
+		return k.get(ctx, req)
+	case bindings.ListOperation:
+		return k.list(ctx, req)
+	case applyOperation:
+		return k.apply(ctx, req)
+	case bindings.DeleteOperation:
+		return k.delete(ctx, req)
+	default:
+		return nil, fmt.Errorf("invalid operation %s, expected %s, %s, %s or %s",
+			req.Operation, bindings.GetOperation, bindings.ListOperation, applyOperation, bindings.DeleteOperation)
+	}
+}
+
+// client returns the client of the resources of the namespace, which must be one of the binding.
+func (k *kubernetesBinding) client(namespace string) (dynamic.ResourceInterface, error) {
+	if !k.namespaced {
+		return k.dynamicClient.Resource(k.resource), nil
+	}
+
+	if namespace == "" {
+		if len(k.namespaces) != 1 || k.namespaces[0] == allNamespaces {
+			return nil, fmt.Errorf("%s is required, the binding has several namespaces", namespaceMetadataKey)
+		}
+		namespace = k.namespaces[0]
+	}
+	if !k.allowedNamespace(namespace) {
+		return nil, fmt.Errorf("namespace %s is not one of the binding", namespace)
+	}
+
+	return k.dynamicClient.Resource(k.resource).Namespace(namespace), nil
+}
+
+// allowedNamespace checks that the binding has the namespace, or all namespaces.
+func (k *kubernetesBinding) allowedNamespace(namespace string) bool {
+	for _, ns := range k.namespaces {
+		if ns == namespace || ns == allNamespaces {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (k *kubernetesBinding) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	name := req.Metadata[nameMetadataKey]
+	if name == "" {
+		return nil, errors.New("name is required to get a resource")
+	}
+	client, err := k.client(req.Metadata[namespaceMetadataKey])
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return objectResponse(obj)
+}
+
+// list lists the resources of the namespace, or of all namespaces if the namespace is "*" and the
+// binding has all namespaces. The selectors of the request default to those of the binding.
+func (k *kubernetesBinding) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var client dynamic.ResourceInterface
+	if ns := req.Metadata[namespaceMetadataKey]; k.namespaced && ns == allNamespaces {
+		if !k.allowedNamespace(allNamespaces) {
+			return nil, errors.New("the binding does not have all namespaces")
+		}
+		client = k.dynamicClient.Resource(k.resource)
+	} else {
+		var err error
+		if client, err = k.client(ns); err != nil {
+			return nil, err
+		}
+	}
+
+	options := metav1.ListOptions{
+		LabelSelector: k.labelSelector,
+		FieldSelector: k.fieldSelector,
+	}
+	if val, ok := req.Metadata[labelSelectorMetadataKey]; ok {
+		options.LabelSelector = val
+	}
+	if val, ok := req.Metadata[fieldSelectorMetadataKey]; ok {
+		options.FieldSelector = val
+	}
+
+	list, err := client.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	items := list.Items
+	if items == nil {
+		items = []unstructured.Unstructured{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{Data: data}, nil
+}
+
+// apply creates or updates the resource with a server-side apply, managing its fields as dapr.
+func (k *kubernetesBinding) apply(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	obj := &unstructured.Unstructured{}
+	data, err := yaml.YAMLToJSON(req.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource: %w", err)
+	}
+	if err = obj.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("invalid resource: %w", err)
+	}
+	if obj.GroupVersionKind() != k.gvk {
+		return nil, fmt.Errorf("invalid resource kind %s, the binding manages %s", obj.GroupVersionKind(), k.gvk)
+	}
+	if obj.GetName() == "" {
+		return nil, errors.New("the resource has no name")
+	}
+
+	namespace := obj.GetNamespace()
+	if val := req.Metadata[namespaceMetadataKey]; val != "" {
+		namespace = val
+	}
+	client, err := k.client(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if k.namespaced {
+		obj.SetNamespace(namespace)
+		if namespace == "" {
+			obj.SetNamespace(k.namespaces[0])
+		}
+	}
+
+	if data, err = obj.MarshalJSON(); err != nil {
+		return nil, err
+	}
+	obj, err = client.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: fieldManager})
+	if err != nil {
+		return nil, err
+	}
+
+	return objectResponse(obj)
+}
+
+func (k *kubernetesBinding) delete(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	name := req.Metadata[nameMetadataKey]
+	if name == "" {
+		return nil, errors.New("name is required to delete a resource")
+	}
+	client, err := k.client(req.Metadata[namespaceMetadataKey])
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func objectResponse(obj *unstructured.Unstructured) (*bindings.InvokeResponse, error) {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{Data: data}, nil
+}
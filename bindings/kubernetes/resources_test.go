@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+var (
+	configMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	widgets    = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	namespaces = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+// newTestBinding returns a binding using fake clients, knowing config maps, namespaces and widgets.
+func newTestBinding(t *testing.T, properties map[string]string, objects ...runtime.Object) (*kubernetesBinding, *fakedynamic.FakeDynamicClient) {
+	t.Helper()
+
+	k := newKubernetes(logger.NewLogger("test"))
+	require.NoError(t, k.parseMetadata(bindings.Metadata{Properties: properties}))
+
+	discovery := fakeclientset.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+				{Name: "events", Kind: "Event", Namespaced: true},
+				{Name: "namespaces", Kind: "Namespace"},
+			},
+		},
+		{
+			GroupVersion: "example.com/v1",
+			APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true}},
+		},
+	}
+	k.discoveryClient = discovery
+	require.NoError(t, k.resolveResource())
+
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMaps: "ConfigMapList",
+		widgets:    "WidgetList",
+		namespaces: "NamespaceList",
+	}, objects...)
+	k.dynamicClient = client
+
+	return k, client
+}
+
+// reactToApply makes the fake client create or replace the objects of apply patches, which it does not support.
+func reactToApply(client *fakedynamic.FakeDynamicClient) {
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		_, err := client.Tracker().Get(patch.GetResource(), patch.GetNamespace(), patch.GetName())
+		switch {
+		case apierrors.IsNotFound(err):
+			err = client.Tracker().Create(patch.GetResource(), obj, patch.GetNamespace())
+		case err == nil:
+			err = client.Tracker().Update(patch.GetResource(), obj, patch.GetNamespace())
+		}
+
+		return true, obj, err
+	})
+}
+
+func configMap(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(labels)
+
+	return obj
+}
+
+func TestResolveResource(t *testing.T) {
+	k, _ := newTestBinding(t, map[string]string{"apiVersion": "example.com/v1", "kind": "Widget", "namespaces": "a, b"})
+	assert.Equal(t, widgets, k.resource)
+	assert.True(t, k.namespaced)
+	assert.Equal(t, []string{"a", "b"}, k.watchedNamespaces())
+
+	k, _ = newTestBinding(t, map[string]string{"apiVersion": "v1", "kind": "Namespace"})
+	assert.False(t, k.namespaced)
+	assert.Equal(t, []string{metav1.NamespaceAll}, k.watchedNamespaces())
+
+	k, _ = newTestBinding(t, map[string]string{"namespace": "default"})
+	assert.Equal(t, "events", k.resource.Resource)
+
+	k = newKubernetes(logger.NewLogger("test"))
+	assert.Error(t, k.parseMetadata(bindings.Metadata{Properties: map[string]string{"kind": "ConfigMap"}}))
+}
+
+func TestReadResources(t *testing.T) {
+	k, client := newTestBinding(t, map[string]string{"apiVersion": "v1", "kind": "ConfigMap", "namespaces": "a,b"},
+		configMap("a", "existing", nil))
+
+	events := make(chan EventResponse, 10)
+	done := make(chan error)
+	go func() {
+		done <- k.Read(func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+			var event EventResponse
+			assert.NoError(t, json.Unmarshal(r.Data, &event))
+			events <- event
+
+			return nil, nil
+		})
+	}()
+
+	next := func() EventResponse {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no event")
+
+			return EventResponse{}
+		}
+	}
+
+	e := next()
+	assert.Equal(t, "add", e.Event)
+	assert.Equal(t, "existing", e.NewVal.GetName())
+	assert.Nil(t, e.OldVal)
+
+	ctx := context.Background()
+	// Not watched.
+	_, err := client.Resource(configMaps).Namespace("c").Create(ctx, configMap("c", "ignored", nil), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = client.Resource(configMaps).Namespace("b").Create(ctx, configMap("b", "settings", nil), metav1.CreateOptions{})
+	require.NoError(t, err)
+	e = next()
+	assert.Equal(t, "add", e.Event)
+	assert.Equal(t, "b", e.NewVal.GetNamespace())
+
+	_, err = client.Resource(configMaps).Namespace("b").Update(ctx, configMap("b", "settings", map[string]string{"v": "2"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	e = next()
+	assert.Equal(t, "update", e.Event)
+	assert.Empty(t, e.OldVal.GetLabels())
+	assert.Equal(t, "2", e.NewVal.GetLabels()["v"])
+
+	require.NoError(t, client.Resource(configMaps).Namespace("b").Delete(ctx, "settings", metav1.DeleteOptions{}))
+	e = next()
+	assert.Equal(t, "delete", e.Event)
+	assert.Equal(t, "settings", e.OldVal.GetName())
+	assert.Nil(t, e.NewVal)
+
+	require.NoError(t, k.Close())
+	require.NoError(t, <-done)
+	assert.Empty(t, events)
+}
+
+func TestInvokeResources(t *testing.T) {
+	objects := []runtime.Object{
+		configMap("a", "app", map[string]string{"tier": "web"}),
+		configMap("a", "db", map[string]string{"tier": "data"}),
+		configMap("b", "other", map[string]string{"tier": "web"}),
+	}
+	k, client := newTestBinding(t, map[string]string{"apiVersion": "v1", "kind": "ConfigMap", "namespace": "a"}, objects...)
+	reactToApply(client)
+	ctx := context.Background()
+
+	resp, err := k.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.GetOperation, Metadata: map[string]string{nameMetadataKey: "app"}})
+	require.NoError(t, err)
+	obj := &unstructured.Unstructured{}
+	require.NoError(t, obj.UnmarshalJSON(resp.Data))
+	assert.Equal(t, "app", obj.GetName())
+
+	list := func(md map[string]string) []unstructured.Unstructured {
+		resp, err := k.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.ListOperation, Metadata: md})
+		require.NoError(t, err)
+		var items []unstructured.Unstructured
+		require.NoError(t, json.Unmarshal(resp.Data, &items))
+
+		return items
+	}
+	assert.Len(t, list(nil), 2)
+	web := list(map[string]string{labelSelectorMetadataKey: "tier=web"})
+	require.Len(t, web, 1)
+	assert.Equal(t, "app", web[0].GetName())
+
+	// Created, then replaced.
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: new\ndata:\n  key: one\n"
+	_, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: applyOperation, Data: []byte(manifest)})
+	require.NoError(t, err)
+	resp, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: applyOperation, Data: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"new"},"data":{"key":"two"}}`)})
+	require.NoError(t, err)
+	require.NoError(t, obj.UnmarshalJSON(resp.Data))
+	assert.Equal(t, "a", obj.GetNamespace())
+	value, _, _ := unstructured.NestedString(obj.Object, "data", "key")
+	assert.Equal(t, "two", value)
+
+	_, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: applyOperation, Data: []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s"}}`)})
+	assert.Error(t, err, "other kind")
+
+	_, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.DeleteOperation, Metadata: map[string]string{nameMetadataKey: "new"}})
+	require.NoError(t, err)
+	_, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.GetOperation, Metadata: map[string]string{nameMetadataKey: "new"}})
+	assert.Error(t, err)
+
+	_, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.CreateOperation})
+	assert.Error(t, err)
+
+	t.Run("other namespaces", func(t *testing.T) {
+		_, err := k.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.GetOperation, Metadata: map[string]string{nameMetadataKey: "other", namespaceMetadataKey: "b"}})
+		assert.Error(t, err)
+		_, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.ListOperation, Metadata: map[string]string{namespaceMetadataKey: allNamespaces}})
+		assert.Error(t, err)
+		_, err = k.Invoke(ctx, &bindings.InvokeRequest{Operation: applyOperation, Data: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"new","namespace":"b"}}`)})
+		assert.Error(t, err)
+
+		all, _ := newTestBinding(t, map[string]string{"apiVersion": "v1", "kind": "ConfigMap", "namespaces": allNamespaces}, objects...)
+		resp, err := all.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.ListOperation, Metadata: map[string]string{namespaceMetadataKey: allNamespaces}})
+		require.NoError(t, err)
+		var items []unstructured.Unstructured
+		require.NoError(t, json.Unmarshal(resp.Data, &items))
+		assert.Len(t, items, 3)
+		_, err = all.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.GetOperation, Metadata: map[string]string{nameMetadataKey: "other", namespaceMetadataKey: "b"}})
+		assert.NoError(t, err)
+	})
+}
@@ -19,10 +19,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/google/uuid"
@@ -33,23 +37,75 @@ import (
 
 const (
 	fileNameMetadataKey = "fileName"
+	encodingMetadataKey = "encoding"
+	offsetMetadataKey   = "offset"
+	lengthMetadataKey   = "length"
+	markerMetadataKey   = "marker"
+	numberMetadataKey   = "number"
+
+	appendOperation bindings.OperationKind = "append"
+	statOperation   bindings.OperationKind = "stat"
 )
 
-// LocalStorage allows saving files to disk.
+// Encodings of the data of the requests.
+const (
+	// encodingAuto unquotes the data and decodes it from base64 when it can, for backward compatibility.
+	encodingAuto = ""
+	// encodingRaw writes and reads the data as it is.
+	encodingRaw = "raw"
+	// encodingBase64 decodes the data written from base64 and encodes the data read to base64.
+	encodingBase64 = "base64"
+)
+
+// LocalStorage allows saving files to disk, and reading the events of the files of rootPath.
 type LocalStorage struct {
-	metadata *Metadata
-	logger   logger.Logger
+	metadata       *Metadata
+	include        []string
+	debounce       time.Duration
+	deliverContent bool
+
+	watcher *watcher
+	lock    sync.Mutex
+	logger  logger.Logger
 }
 
 // Metadata defines the metadata.
 type Metadata struct {
 	RootPath string `json:"rootPath"`
+	// Include are the comma separated glob patterns of the files whose events are read, all if empty.
+	Include string `json:"include"`
+	// Debounce is the delay without events after which the event of a file is read,
+	// so that files being written are read once complete.
+	Debounce string `json:"debounce"`
+	// DeliverContent reads the content of created and modified files with their events.
+	DeliverContent string `json:"deliverContent"`
+	// Encoding is the encoding of the data of the requests, which can be overridden by their metadata.
+	Encoding string `json:"encoding"`
 }
 
 type createResponse struct {
 	FileName string `json:"fileName"`
 }
 
+// fileStat is the response of stat, and the items of the detailed list.
+type fileStat struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Mode    string    `json:"mode"`
+	IsDir   bool      `json:"isDir"`
+}
+
+// listPayload is the optional payload of list.
+type listPayload struct {
+	// Marker is the marker of the response of the previous page, to list the next one.
+	Marker string `json:"marker"`
+	// MaxResults is the number of files of a page, all the files if zero.
+	MaxResults int `json:"maxResults"`
+	// Stat lists the stats of the files instead of their names.
+	Stat bool `json:"stat"`
+}
+
 // NewLocalStorage returns a new LocalStorage instance.
 func NewLocalStorage(logger logger.Logger) *LocalStorage {
 	return &LocalStorage{logger: logger}
@@ -63,6 +119,35 @@ func (ls *LocalStorage) Init(metadata bindings.Metadata) error {
 	}
 	ls.metadata = m
 
+	ls.include = nil
+	for _, pattern := range strings.Split(m.Include, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if _, err = filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid 'include' pattern: %s", pattern)
+		}
+		ls.include = append(ls.include, pattern)
+	}
+
+	ls.debounce = 0
+	if m.Debounce != "" {
+		if ls.debounce, err = time.ParseDuration(m.Debounce); err != nil || ls.debounce < 0 {
+			return fmt.Errorf("invalid 'debounce': %s", m.Debounce)
+		}
+	}
+
+	ls.deliverContent = false
+	if m.DeliverContent != "" {
+		if ls.deliverContent, err = strconv.ParseBool(m.DeliverContent); err != nil {
+			return fmt.Errorf("invalid 'deliverContent': %s", m.DeliverContent)
+		}
+	}
+
+	if _, err = decode(nil, m.Encoding); err != nil {
+		return err
+	}
+
 	err = os.MkdirAll(ls.metadata.RootPath, 0o777)
 	if err != nil {
 		return fmt.Errorf("unable to create directory specified by 'rootPath': %s", ls.metadata.RootPath)
@@ -94,18 +179,66 @@ func (ls *LocalStorage) Operations() []bindings.OperationKind {
 		bindings.GetOperation,
 		bindings.ListOperation,
 		bindings.DeleteOperation,
+		appendOperation,
+		statOperation,
+	}
+}
+
+// encoding returns the encoding of the data of req.
+func (ls *LocalStorage) encoding(req *bindings.InvokeRequest) string {
+	if val, ok := req.Metadata[encodingMetadataKey]; ok {
+		return val
+	}
+
+	return ls.metadata.Encoding
+}
+
+// decode returns the data of a request written with encoding.
+func decode(data []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case encodingAuto:
+		d, err := strconv.Unquote(string(data))
+		if err == nil {
+			data = []byte(d)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err == nil {
+			data = decoded
+		}
+
+		return data, nil
+	case encodingRaw:
+		return data, nil
+	case encodingBase64:
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 data: %w", err)
+		}
+
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("invalid encoding %s, expected %s or %s", encoding, encodingRaw, encodingBase64)
 	}
 }
 
 func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	d, err := strconv.Unquote(string(req.Data))
-	if err == nil {
-		req.Data = []byte(d)
+	return ls.write(filename, req, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+}
+
+func (ls *LocalStorage) append(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("%s is required to append to a file", fileNameMetadataKey)
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(string(req.Data))
-	if err == nil {
-		req.Data = decoded
+	return ls.write(filename, req, os.O_CREATE|os.O_APPEND|os.O_WRONLY)
+}
+
+// write writes the data of req to the file opened with flag.
+func (ls *LocalStorage) write(filename string, req *bindings.InvokeRequest, flag int) (*bindings.InvokeResponse, error) {
+	data, err := decode(req.Data, ls.encoding(req))
+	if err != nil {
+		return nil, err
 	}
 
 	absPath, relPath, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
@@ -118,13 +251,13 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 		return nil, err
 	}
 
-	f, err := os.Create(absPath)
+	f, err := os.OpenFile(absPath, flag, 0o666)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	numBytes, err := f.Write(req.Data)
+	numBytes, err := f.Write(data)
 	if err != nil {
 		return nil, err
 	}
@@ -145,7 +278,26 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 	}, nil
 }
 
+// get reads the file, or the range of length bytes from offset set in the metadata of req.
 func (ls *LocalStorage) get(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	encoding := ls.encoding(req)
+	if _, err := decode(nil, encoding); err != nil {
+		return nil, err
+	}
+
+	var offset, length int64
+	var err error
+	if val := req.Metadata[offsetMetadataKey]; val != "" {
+		if offset, err = strconv.ParseInt(val, 10, 64); err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid %s: %s", offsetMetadataKey, val)
+		}
+	}
+	if val := req.Metadata[lengthMetadataKey]; val != "" {
+		if length, err = strconv.ParseInt(val, 10, 64); err != nil || length <= 0 {
+			return nil, fmt.Errorf("invalid %s: %s", lengthMetadataKey, val)
+		}
+	}
+
 	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
 	if err != nil {
 		return nil, err
@@ -157,8 +309,19 @@ func (ls *LocalStorage) get(filename string, req *bindings.InvokeRequest) (*bind
 
 		return nil, err
 	}
+	defer f.Close()
+
+	if offset > 0 {
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	var r io.Reader = f
+	if length > 0 {
+		r = io.LimitReader(f, length)
+	}
 
-	b, err := ioutil.ReadAll(f)
+	b, err := ioutil.ReadAll(r)
 	if err != nil {
 		ls.logger.Debugf("%s", err)
 
@@ -167,6 +330,12 @@ func (ls *LocalStorage) get(filename string, req *bindings.InvokeRequest) (*bind
 
 	ls.logger.Debugf("read file: %s. size: %d bytes", absPath, len(b))
 
+	if encoding == encodingBase64 {
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(b)))
+		base64.StdEncoding.Encode(encoded, b)
+		b = encoded
+	}
+
 	return &bindings.InvokeResponse{
 		Data: b,
 	}, nil
@@ -188,7 +357,41 @@ func (ls *LocalStorage) delete(filename string, req *bindings.InvokeRequest) (*b
 	return nil, nil
 }
 
+func (ls *LocalStorage) stat(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	absPath, relPath, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(newFileStat(relPath, fi))
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+// list lists the files of the directory, by page when the payload of req sets maxResults.
+// The marker metadata of the response lists the next page, it is unset on the last page.
+// Pages are in the walk order of the directory, which stops once the page is full.
 func (ls *LocalStorage) list(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload listPayload
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &payload); err != nil {
+			return nil, fmt.Errorf("invalid list payload: %w", err)
+		}
+		if payload.MaxResults < 0 {
+			return nil, fmt.Errorf("invalid maxResults: %d", payload.MaxResults)
+		}
+	}
+
 	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
 	if err != nil {
 		return nil, err
@@ -205,21 +408,65 @@ func (ls *LocalStorage) list(filename string, req *bindings.InvokeRequest) (*bin
 		return nil, errors.New(msg)
 	}
 
-	files, err := walkPath(absPath)
+	files, more, err := walkPage(ls.metadata.RootPath, absPath, payload.Marker, payload.MaxResults)
 	if err != nil {
 		return nil, err
 	}
 
-	b, err := json.Marshal(files)
+	metadata := map[string]string{}
+	if payload.Marker != "" || payload.MaxResults > 0 {
+		// The marker is the name of the last file of the page.
+		if more {
+			metadata[markerMetadataKey] = files[len(files)-1].name
+		}
+		metadata[numberMetadataKey] = strconv.Itoa(len(files))
+	}
+
+	var result interface{}
+	if payload.Stat {
+		stats := make([]fileStat, 0, len(files))
+		for _, file := range files {
+			fi, err := os.Stat(file.path)
+			if err != nil {
+				// Removed since listed.
+				if os.IsNotExist(err) {
+					continue
+				}
+
+				return nil, err
+			}
+			stats = append(stats, newFileStat(file.name, fi))
+		}
+		result = stats
+	} else {
+		paths := make([]string, 0, len(files))
+		for _, file := range files {
+			paths = append(paths, file.path)
+		}
+		result = paths
+	}
+
+	b, err := json.Marshal(result)
 	if err != nil {
 		return nil, err
 	}
 
 	return &bindings.InvokeResponse{
-		Data: b,
+		Data:     b,
+		Metadata: metadata,
 	}, nil
 }
 
+func newFileStat(name string, fi os.FileInfo) fileStat {
+	return fileStat{
+		Name:    name,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+		Mode:    fi.Mode().String(),
+		IsDir:   fi.IsDir(),
+	}
+}
+
 func getSecureAbsRelPath(rootPath string, filename string) (absPath string, relPath string, err error) {
 	absPath, err = securejoin.SecureJoin(rootPath, filename)
 	if err != nil {
@@ -233,17 +480,64 @@ func getSecureAbsRelPath(rootPath string, filename string) (absPath string, relP
 	return
 }
 
-func walkPath(root string) ([]string, error) {
-	var files []string
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			files = append(files, path)
+// listedFile is a file found by walkPage, name is its path relative to the root path.
+type listedFile struct {
+	path string
+	name string
+}
+
+var errPageFull = errors.New("page full")
+
+// walkPage walks dir, a directory of rootPath, for up to max files following the file named marker,
+// max being unlimited if zero. It returns whether more files follow.
+func walkPage(rootPath, dir, marker string, max int) (files []listedFile, more bool, err error) {
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
+		name, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+
+		if marker != "" && comparePaths(name, marker) <= 0 {
+			// Skip the directories listed by previous pages.
+			if info.IsDir() && name != "." && !strings.HasPrefix(marker, name+string(filepath.Separator)) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if max > 0 && len(files) == max {
+			more = true
+
+			return errPageFull
+		}
+		files = append(files, listedFile{path: path, name: name})
 
 		return nil
 	})
+	if errors.Is(err, errPageFull) {
+		err = nil
+	}
+
+	return files, more, err
+}
+
+// comparePaths compares paths element by element, in the order of filepath.Walk.
+func comparePaths(a, b string) int {
+	as := strings.Split(a, string(filepath.Separator))
+	bs := strings.Split(b, string(filepath.Separator))
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
 
-	return files, err
+	return len(as) - len(bs)
 }
 
 // Invoke is called for output bindings.
@@ -264,6 +558,10 @@ func (ls *LocalStorage) Invoke(ctx context.Context, req *bindings.InvokeRequest)
 		return ls.delete(filename, req)
 	case bindings.ListOperation:
 		return ls.list(filename, req)
+	case appendOperation:
+		return ls.append(filename, req)
+	case statOperation:
+		return ls.stat(filename, req)
 	default:
 		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
 	}
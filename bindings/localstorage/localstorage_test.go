@@ -14,9 +14,15 @@ limitations under the License.
 package localstorage
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
@@ -30,3 +36,149 @@ func TestParseMetadata(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "/files", meta.RootPath)
 }
+
+func newLocalStorage(t *testing.T, properties map[string]string) *LocalStorage {
+	t.Helper()
+
+	properties["rootPath"] = t.TempDir()
+	ls := NewLocalStorage(logger.NewLogger("test"))
+	require.NoError(t, ls.Init(bindings.Metadata{Properties: properties}))
+
+	return ls
+}
+
+func invoke(t *testing.T, ls *LocalStorage, op bindings.OperationKind, data string, metadata map[string]string) *bindings.InvokeResponse {
+	t.Helper()
+
+	resp, err := ls.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: op, Data: []byte(data), Metadata: metadata})
+	require.NoError(t, err)
+
+	return resp
+}
+
+func TestEncoding(t *testing.T) {
+	// Valid base64, decoded for backward compatibility unless the encoding is set.
+	data := "ZGFwcg=="
+
+	ls := newLocalStorage(t, map[string]string{})
+	invoke(t, ls, bindings.CreateOperation, data, map[string]string{fileNameMetadataKey: "auto"})
+	assert.Equal(t, "dapr", string(invoke(t, ls, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "auto"}).Data))
+
+	invoke(t, ls, bindings.CreateOperation, data, map[string]string{fileNameMetadataKey: "raw", encodingMetadataKey: encodingRaw})
+	assert.Equal(t, data, string(invoke(t, ls, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "raw"}).Data))
+
+	ls = newLocalStorage(t, map[string]string{"encoding": encodingBase64})
+	invoke(t, ls, bindings.CreateOperation, data, map[string]string{fileNameMetadataKey: "b64"})
+	assert.Equal(t, data, string(invoke(t, ls, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "b64"}).Data))
+	assert.Equal(t, "dapr", string(invoke(t, ls, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "b64", encodingMetadataKey: encodingRaw}).Data))
+
+	_, err := ls.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte("not base64!"),
+		Metadata:  map[string]string{fileNameMetadataKey: "invalid"},
+	})
+	assert.Error(t, err)
+
+	assert.Error(t, NewLocalStorage(logger.NewLogger("test")).Init(bindings.Metadata{Properties: map[string]string{
+		"rootPath": t.TempDir(),
+		"encoding": "utf-16",
+	}}))
+}
+
+func TestAppendAndRangeReads(t *testing.T) {
+	ls := newLocalStorage(t, map[string]string{"encoding": encodingRaw})
+	md := map[string]string{fileNameMetadataKey: "logs/app.log"}
+
+	invoke(t, ls, appendOperation, "first\n", md)
+	invoke(t, ls, appendOperation, "second\n", md)
+	assert.Equal(t, "first\nsecond\n", string(invoke(t, ls, bindings.GetOperation, "", md).Data))
+
+	get := func(offset, length string) string {
+		return string(invoke(t, ls, bindings.GetOperation, "", map[string]string{
+			fileNameMetadataKey: "logs/app.log",
+			offsetMetadataKey:   offset,
+			lengthMetadataKey:   length,
+		}).Data)
+	}
+	assert.Equal(t, "second\n", get("6", ""))
+	assert.Equal(t, "sec", get("6", "3"))
+	assert.Equal(t, "", get("100", "3"))
+
+	_, err := ls.Invoke(context.TODO(), &bindings.InvokeRequest{Operation: appendOperation, Data: []byte("x")})
+	assert.Error(t, err, "append without file name")
+	_, err = ls.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: bindings.GetOperation,
+		Metadata:  map[string]string{fileNameMetadataKey: "logs/app.log", offsetMetadataKey: "-1"},
+	})
+	assert.Error(t, err)
+}
+
+func TestStatAndList(t *testing.T) {
+	ls := newLocalStorage(t, map[string]string{"encoding": encodingRaw})
+	for _, name := range []string{"c.txt", "a.txt", "dir/b.txt"} {
+		invoke(t, ls, bindings.CreateOperation, name, map[string]string{fileNameMetadataKey: name})
+	}
+	root := ls.metadata.RootPath
+
+	var stat fileStat
+	require.NoError(t, json.Unmarshal(invoke(t, ls, statOperation, "", map[string]string{fileNameMetadataKey: "dir/b.txt"}).Data, &stat))
+	assert.Equal(t, "dir/b.txt", stat.Name)
+	assert.Equal(t, int64(len("dir/b.txt")), stat.Size)
+	assert.Equal(t, "-", stat.Mode[:1])
+	assert.False(t, stat.IsDir)
+	assert.False(t, stat.ModTime.IsZero())
+
+	// Pages of two files.
+	resp := invoke(t, ls, bindings.ListOperation, `{"maxResults": 2}`, nil)
+	var names []string
+	require.NoError(t, json.Unmarshal(resp.Data, &names))
+	assert.Equal(t, []string{filepath.Join(root, "a.txt"), filepath.Join(root, "c.txt")}, names)
+	assert.Equal(t, "c.txt", resp.Metadata[markerMetadataKey])
+
+	payload, _ := json.Marshal(listPayload{Marker: resp.Metadata[markerMetadataKey], MaxResults: 2, Stat: true})
+	resp = invoke(t, ls, bindings.ListOperation, string(payload), nil)
+	var stats []fileStat
+	require.NoError(t, json.Unmarshal(resp.Data, &stats))
+	require.Len(t, stats, 1)
+	assert.Equal(t, filepath.Join("dir", "b.txt"), stats[0].Name)
+	assert.Empty(t, resp.Metadata[markerMetadataKey])
+
+	// Without payload, all the files.
+	require.NoError(t, json.Unmarshal(invoke(t, ls, bindings.ListOperation, "", nil).Data, &names))
+	assert.Len(t, names, 3)
+}
+
+func TestWalkPage(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a-c", "a/b", "a/d/e", "f"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+	}
+	page := func(marker string, max int) ([]string, bool) {
+		files, more, err := walkPage(root, root, filepath.FromSlash(marker), max)
+		require.NoError(t, err)
+		names := make([]string, 0, len(files))
+		for _, file := range files {
+			names = append(names, filepath.ToSlash(file.name))
+		}
+
+		return names, more
+	}
+
+	names, more := page("", 2)
+	assert.Equal(t, []string{"a/b", "a/d/e"}, names)
+	assert.True(t, more)
+	names, more = page("a/d/e", 2)
+	assert.Equal(t, []string{"a-c", "f"}, names)
+	assert.False(t, more)
+	names, _ = page("a/b", 0)
+	assert.Equal(t, []string{"a/d/e", "a-c", "f"}, names)
+}
+
+func TestGetBase64Encoded(t *testing.T) {
+	ls := newLocalStorage(t, map[string]string{"encoding": encodingRaw})
+	invoke(t, ls, bindings.CreateOperation, "\x00\x01binary", map[string]string{fileNameMetadataKey: "bin"})
+	data := invoke(t, ls, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "bin", encodingMetadataKey: encodingBase64}).Data
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("\x00\x01binary")), string(data))
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	eventMetadataKey = "event"
+
+	eventCreate = "create"
+	eventModify = "modify"
+	eventDelete = "delete"
+	eventRename = "rename"
+)
+
+var _ = bindings.InputBinding(&LocalStorage{})
+
+// watcher watches the files of rootPath recursively and delivers their events, once no other
+// event happened to a file during the debounce delay.
+type watcher struct {
+	ls      *LocalStorage
+	fs      *fsnotify.Watcher
+	handler func(context.Context, *bindings.ReadResponse) ([]byte, error)
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending map[string]*pendingEvent
+	lock    sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// pendingEvent is the event of a file waiting for the debounce delay.
+type pendingEvent struct {
+	event string
+	timer *time.Timer
+}
+
+// Read watches rootPath and delivers the create, modify, delete and rename events of its files
+// matching the include patterns. The new name of a renamed file is delivered as a create event.
+func (ls *LocalStorage) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	if ls.watcher != nil {
+		return errors.New("already watching rootPath")
+	}
+
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating the watcher of %s: %w", ls.metadata.RootPath, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &watcher{
+		ls:      ls,
+		fs:      fs,
+		handler: handler,
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[string]*pendingEvent),
+	}
+	if err = w.addRecursive(ls.metadata.RootPath, false); err != nil {
+		fs.Close()
+		cancel()
+
+		return err
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	ls.watcher = w
+
+	return nil
+}
+
+// Close stops watching rootPath. Events waiting for the debounce delay are dropped.
+func (ls *LocalStorage) Close() error {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	if ls.watcher == nil {
+		return nil
+	}
+	err := ls.watcher.close()
+	ls.watcher = nil
+
+	return err
+}
+
+// addRecursive watches dir and its sub-directories. With created set, the files found in
+// them are delivered as create events, as they were written before being watched.
+func (w *watcher) addRecursive(dir string, created bool) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Removed while walking.
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+		if !info.IsDir() {
+			if created {
+				w.emit(path, eventCreate)
+			}
+
+			return nil
+		}
+		if err := w.fs.Add(path); err != nil {
+			return fmt.Errorf("error watching %s: %w", path, err)
+		}
+
+		return nil
+	})
+}
+
+func (w *watcher) run() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			w.ls.logger.Errorf("error watching %s: %s", w.ls.metadata.RootPath, err)
+		case e, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.handle(e)
+		}
+	}
+}
+
+func (w *watcher) handle(e fsnotify.Event) {
+	var event string
+	switch {
+	case e.Op&fsnotify.Create != 0:
+		event = eventCreate
+		if info, err := os.Stat(e.Name); err == nil && info.IsDir() {
+			// A directory moved in, or written to before being watched, already has files.
+			if err = w.addRecursive(e.Name, true); err != nil {
+				w.ls.logger.Errorf("%s", err)
+			}
+
+			return
+		}
+	case e.Op&fsnotify.Write != 0:
+		event = eventModify
+	case e.Op&fsnotify.Remove != 0:
+		event = eventDelete
+	case e.Op&fsnotify.Rename != 0:
+		event = eventRename
+	default:
+		return
+	}
+
+	w.emit(e.Name, event)
+}
+
+// emit debounces the event of path if it matches the include patterns.
+func (w *watcher) emit(path, event string) {
+	relPath, err := filepath.Rel(w.ls.metadata.RootPath, path)
+	if err != nil || !w.ls.included(relPath) {
+		return
+	}
+
+	w.debounce(path, event)
+}
+
+// debounce delivers the event of path once no other event happened to it during the debounce delay.
+func (w *watcher) debounce(path, event string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	p, ok := w.pending[path]
+	if !ok {
+		p = &pendingEvent{event: event}
+		w.pending[path] = p
+		p.timer = time.AfterFunc(w.ls.debounce, func() { w.deliver(path, p) })
+
+		return
+	}
+
+	switch {
+	case p.event == eventCreate && event == eventModify:
+		// Still being written.
+	case p.event == eventCreate && (event == eventDelete || event == eventRename):
+		// The file was gone before being delivered.
+		p.timer.Stop()
+		delete(w.pending, path)
+
+		return
+	default:
+		p.event = event
+	}
+	p.timer.Reset(w.ls.debounce)
+}
+
+func (w *watcher) deliver(path string, p *pendingEvent) {
+	w.lock.Lock()
+	if w.pending[path] != p {
+		w.lock.Unlock()
+
+		return
+	}
+	delete(w.pending, path)
+	event := p.event
+	w.lock.Unlock()
+
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	relPath, _ := filepath.Rel(w.ls.metadata.RootPath, path)
+	resp := &bindings.ReadResponse{
+		Metadata: map[string]string{
+			eventMetadataKey:    event,
+			fileNameMetadataKey: filepath.ToSlash(relPath),
+		},
+	}
+	if w.ls.deliverContent && (event == eventCreate || event == eventModify) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			w.ls.logger.Warnf("error reading %s: %s", path, err)
+
+			return
+		}
+		resp.Data = data
+	}
+
+	w.ls.logger.Debugf("file %s: %s", event, path)
+	if _, err := w.handler(w.ctx, resp); err != nil {
+		w.ls.logger.Errorf("error handling %s event of %s: %s", event, path, err)
+	}
+}
+
+func (w *watcher) close() error {
+	w.cancel()
+	err := w.fs.Close()
+	w.wg.Wait()
+
+	w.lock.Lock()
+	for path, p := range w.pending {
+		p.timer.Stop()
+		delete(w.pending, path)
+	}
+	w.lock.Unlock()
+
+	return err
+}
+
+// included returns true if relPath matches an include pattern, or if there are none.
+// Patterns with a separator match the path relative to rootPath, others the file name.
+func (ls *LocalStorage) included(relPath string) bool {
+	if len(ls.include) == 0 {
+		return true
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	name := filepath.Base(relPath)
+	for _, pattern := range ls.include {
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = relPath
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstorage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+// watch starts reading the events of a new root path.
+func watch(t *testing.T, properties map[string]string) (string, <-chan *bindings.ReadResponse) {
+	t.Helper()
+
+	root := t.TempDir()
+	properties["rootPath"] = root
+	ls := NewLocalStorage(logger.NewLogger("test"))
+	require.NoError(t, ls.Init(bindings.Metadata{Properties: properties}))
+
+	events := make(chan *bindings.ReadResponse, 100)
+	require.NoError(t, ls.Read(func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+		events <- r
+
+		return nil, nil
+	}))
+	t.Cleanup(func() { ls.Close() })
+
+	return root, events
+}
+
+func nextEvent(t *testing.T, events <-chan *bindings.ReadResponse) *bindings.ReadResponse {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event")
+
+		return nil
+	}
+}
+
+func assertNoEvent(t *testing.T, events <-chan *bindings.ReadResponse) {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		assert.Failf(t, "unexpected event", "%v", e.Metadata)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	root, events := watch(t, map[string]string{"debounce": "100ms"})
+
+	// Created then written: one create event once complete.
+	f, err := os.Create(filepath.Join(root, "a.csv"))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = f.WriteString("line\n")
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+	}
+	require.NoError(t, f.Close())
+
+	e := nextEvent(t, events)
+	assert.Equal(t, map[string]string{eventMetadataKey: eventCreate, fileNameMetadataKey: "a.csv"}, e.Metadata)
+	assert.Nil(t, e.Data)
+	assertNoEvent(t, events)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "a.csv"), []byte("new"), 0o600))
+	assert.Equal(t, eventModify, nextEvent(t, events).Metadata[eventMetadataKey])
+
+	require.NoError(t, os.Rename(filepath.Join(root, "a.csv"), filepath.Join(root, "b.csv")))
+	renamed := map[string]string{}
+	for i := 0; i < 2; i++ {
+		e = nextEvent(t, events)
+		renamed[e.Metadata[fileNameMetadataKey]] = e.Metadata[eventMetadataKey]
+	}
+	assert.Equal(t, map[string]string{"a.csv": eventRename, "b.csv": eventCreate}, renamed)
+
+	require.NoError(t, os.Remove(filepath.Join(root, "b.csv")))
+	assert.Equal(t, eventDelete, nextEvent(t, events).Metadata[eventMetadataKey])
+}
+
+func TestWatchRecursiveWithFilterAndContent(t *testing.T) {
+	root, events := watch(t, map[string]string{
+		"include":        "*.csv, imports/*.json",
+		"deliverContent": "true",
+		"debounce":       "50ms",
+	})
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "imports"), 0o777))
+	// Let the new directory be watched.
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "imports", "skipped.txt"), []byte("txt"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "imports", "batch.json"), []byte(`{"id":1}`), 0o600))
+
+	e := nextEvent(t, events)
+	assert.Equal(t, "imports/batch.json", e.Metadata[fileNameMetadataKey])
+	assert.Equal(t, `{"id":1}`, string(e.Data))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "imports", "nested.csv"), []byte("a,b"), 0o600))
+	e = nextEvent(t, events)
+	assert.Equal(t, "imports/nested.csv", e.Metadata[fileNameMetadataKey])
+	assert.Equal(t, "a,b", string(e.Data))
+	assertNoEvent(t, events)
+}
+
+func TestInitWatchOptions(t *testing.T) {
+	for name, properties := range map[string]map[string]string{
+		"invalid include":        {"include": "[a-"},
+		"invalid debounce":       {"debounce": "later"},
+		"invalid deliverContent": {"deliverContent": "yes please"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			properties["rootPath"] = t.TempDir()
+			ls := NewLocalStorage(logger.NewLogger("test"))
+			assert.Error(t, ls.Init(bindings.Metadata{Properties: properties}))
+		})
+	}
+}
+
+func TestIncluded(t *testing.T) {
+	ls := &LocalStorage{include: []string{"*.csv", "imports/*.json"}}
+	assert.True(t, ls.included("a.csv"))
+	assert.True(t, ls.included("dir/a.csv"))
+	assert.True(t, ls.included("imports/a.json"))
+	assert.False(t, ls.included("a.json"))
+	assert.False(t, ls.included("a.txt"))
+	assert.True(t, (&LocalStorage{}).included("a.txt"))
+}
+
+func TestWatchMovedDirectory(t *testing.T) {
+	root, events := watch(t, map[string]string{"include": "*.csv", "debounce": "50ms"})
+
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(outside, "batch", "nested"), 0o777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "batch", "a.csv"), []byte("a"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "batch", "nested", "b.csv"), []byte("b"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "batch", "skipped.txt"), []byte("txt"), 0o600))
+
+	// The files of a directory moved in are delivered as created.
+	require.NoError(t, os.Rename(filepath.Join(outside, "batch"), filepath.Join(root, "batch")))
+	created := map[string]string{}
+	for i := 0; i < 2; i++ {
+		e := nextEvent(t, events)
+		created[e.Metadata[fileNameMetadataKey]] = e.Metadata[eventMetadataKey]
+	}
+	assert.Equal(t, map[string]string{"batch/a.csv": eventCreate, "batch/nested/b.csv": eventCreate}, created)
+	assertNoEvent(t, events)
+}
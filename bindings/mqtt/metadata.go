@@ -22,6 +22,7 @@ type metadata struct {
 	cleanSession      bool
 	backOffMaxRetries int
 	topic             string
+	protocolVersion   int
 }
 
 type tlsCfg struct {
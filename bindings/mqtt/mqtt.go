@@ -27,9 +27,11 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/eclipse/paho.golang/autopaho"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/component/mqtt5"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
 )
@@ -59,10 +61,12 @@ const (
 
 // MQTT allows sending and receiving data to/from an MQTT broker.
 type MQTT struct {
-	producer mqtt.Client
-	consumer mqtt.Client
-	metadata *metadata
-	logger   logger.Logger
+	producer  mqtt.Client
+	consumer  mqtt.Client
+	producer5 *autopaho.ConnectionManager
+	consumer5 *autopaho.ConnectionManager
+	metadata  *metadata
+	logger    logger.Logger
 
 	ctx     context.Context
 	cancel  context.CancelFunc
@@ -158,6 +162,12 @@ func parseMQTTMetaData(md bindings.Metadata) (*metadata, error) {
 		m.backOffMaxRetries = backOffMaxRetriesInt
 	}
 
+	protocolVersion, err := mqtt5.ParseProtocolVersion(md.Properties)
+	if err != nil {
+		return &m, fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+	m.protocolVersion = protocolVersion
+
 	return &m, nil
 }
 
@@ -169,19 +179,25 @@ func (m *MQTT) Init(metadata bindings.Metadata) error {
 	}
 	m.metadata = mqttMeta
 
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+
+	// TODO: Make the backoff configurable for constant or exponential
+	b := backoff.NewConstantBackOff(5 * time.Second)
+	m.backOff = backoff.WithContext(b, m.ctx)
+
+	if m.metadata.protocolVersion == 5 {
+		return m.initV5()
+	}
+
 	// mqtt broker allows only one connection at a given time from a clientID.
 	producerClientID := fmt.Sprintf("%s-producer", m.metadata.clientID)
 	p, err := m.connect(producerClientID)
 	if err != nil {
+		m.cancel()
+
 		return err
 	}
 
-	m.ctx, m.cancel = context.WithCancel(context.Background())
-
-	// TODO: Make the backoff configurable for constant or exponential
-	b := backoff.NewConstantBackOff(5 * time.Second)
-	m.backOff = backoff.WithContext(b, m.ctx)
-
 	m.producer = p
 
 	m.logger.Debug("mqtt message bus initialization complete")
@@ -194,6 +210,10 @@ func (m *MQTT) Operations() []bindings.OperationKind {
 }
 
 func (m *MQTT) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if m.metadata.protocolVersion == 5 {
+		return nil, m.invokeV5(ctx, req)
+	}
+
 	// MQTT client Publish() has an internal race condition in the default autoreconnect config.
 	// To mitigate sporadic failures on the Dapr side, this implementation retries 3 times at
 	// a fixed 200ms interval. This is not configurable to keep this as an implementation detail
@@ -228,6 +248,16 @@ func (m *MQTT) handleMessage(handler func(context.Context, *bindings.ReadRespons
 		Metadata: map[string]string{mqttTopic: mqttMsg.Topic()},
 	}
 
+	if err := m.runHandler(handler, &msg); err != nil {
+		return err
+	}
+	mqttMsg.Ack()
+
+	return nil
+}
+
+// runHandler runs the handler of a message until it completes or the component is closed.
+func (m *MQTT) runHandler(handler func(context.Context, *bindings.ReadResponse) ([]byte, error), msg *bindings.ReadResponse) error {
 	// paho.mqtt.golang requires that handlers never block or it can deadlock on client.Disconnect.
 	// To ensure that the Dapr runtime does not hang on teardown on of the component, run the app's
 	// handling code in a goroutine so that this handler function is always cancellable on Close().
@@ -236,15 +266,11 @@ func (m *MQTT) handleMessage(handler func(context.Context, *bindings.ReadRespons
 		defer close(ch)
 		_, err := handler(context.TODO(), m)
 		ch <- err
-	}(&msg)
+	}(msg)
 
 	select {
 	case handlerErr := <-ch:
-		if handlerErr != nil {
-			return handlerErr
-		}
-		mqttMsg.Ack()
-		return nil
+		return handlerErr
 	case <-m.ctx.Done():
 		m.logger.Infof("Read context cancelled: %v", m.ctx.Err())
 		return m.ctx.Err()
@@ -255,6 +281,15 @@ func (m *MQTT) Read(handler func(context.Context, *bindings.ReadResponse) ([]byt
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, os.Interrupt, syscall.SIGTERM)
 
+	if m.metadata.protocolVersion == 5 {
+		if err := m.readV5(handler); err != nil {
+			return err
+		}
+		<-sigterm
+
+		return nil
+	}
+
 	// reset synchronization
 	if m.consumer != nil {
 		m.logger.Warnf("re-initializing the subscriber")
@@ -364,6 +399,10 @@ func (m *MQTT) Close() error {
 	// Cancel any read callback handlers before Disconnect to prevent deadlocks.
 	m.cancel()
 
+	if m.metadata.protocolVersion == 5 {
+		return m.closeV5()
+	}
+
 	if m.consumer != nil {
 		m.consumer.Disconnect(1)
 	}
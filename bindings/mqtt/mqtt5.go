@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/component/mqtt5"
+	"github.com/dapr/kit/retry"
+)
+
+// initV5 connects the producer when the binding uses MQTT 5.
+func (m *MQTT) initV5() error {
+	// mqtt broker allows only one connection at a given time from a clientID.
+	connCtx, connCancel := context.WithTimeout(m.ctx, defaultWait)
+	defer connCancel()
+	p, err := mqtt5.Connect(connCtx, m.connectionConfigV5(fmt.Sprintf("%s-producer", m.metadata.clientID), nil, nil))
+	if err != nil {
+		m.cancel()
+
+		return fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+	m.producer5 = p
+
+	m.logger.Debug("mqtt 5 message bus initialization complete")
+
+	return nil
+}
+
+func (m *MQTT) connectionConfigV5(clientID string, router paho.Router, onConnectionUp func(*autopaho.ConnectionManager)) mqtt5.ConnectionConfig {
+	return mqtt5.ConnectionConfig{
+		URL:            m.metadata.url,
+		ClientID:       clientID,
+		CleanSession:   m.metadata.cleanSession,
+		TLSConfig:      m.newTLSConfig(),
+		Router:         router,
+		OnConnectionUp: onConnectionUp,
+		Logger:         m.logger,
+	}
+}
+
+// invokeV5 publishes the request data with the request metadata, except the topic, as MQTT 5 properties.
+func (m *MQTT) invokeV5(ctx context.Context, req *bindings.InvokeRequest) error {
+	topic := m.metadata.topic
+	metadata := make(map[string]string, len(req.Metadata))
+	for k, v := range req.Metadata {
+		if k == mqttTopic {
+			if v != "" {
+				topic = v
+			}
+
+			continue
+		}
+		metadata[k] = v
+	}
+
+	props, err := mqtt5.PublishProperties(metadata, nil)
+	if err != nil {
+		return fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+
+	m.logger.Debugf("mqtt publishing topic %s with data: %v", topic, req.Data)
+	publishCtx, cancel := context.WithTimeout(ctx, defaultWait)
+	defer cancel()
+	resp, err := m.producer5.Publish(publishCtx, &paho.Publish{
+		Topic:      topic,
+		QoS:        m.metadata.qos,
+		Retain:     m.metadata.retain,
+		Payload:    req.Data,
+		Properties: props,
+	})
+	if err = mqtt5.PublishError(resp, err); err != nil {
+		return fmt.Errorf("mqtt error from publish: %v", err)
+	}
+
+	return nil
+}
+
+// readV5 subscribes to the topic of the binding, the subscription is restored after reconnections.
+func (m *MQTT) readV5(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	if m.consumer5 != nil {
+		m.logger.Warnf("re-initializing the subscriber")
+		_ = m.consumer5.Disconnect(context.Background())
+		m.consumer5 = nil
+	}
+
+	router := paho.NewStandardRouter()
+	router.RegisterHandler(mqtt5.RouteFilter(m.metadata.topic), m.onMessageV5(handler))
+	if m.metadata.qos > 0 {
+		m.logger.Warnf("mqtt 5 subscriptions acknowledge messages even when their handler fails, messages are delivered at most once")
+	}
+
+	subscribe := func(cm *autopaho.ConnectionManager) error {
+		m.logger.Debugf("mqtt subscribing to topic %s", m.metadata.topic)
+		ctx, cancel := context.WithTimeout(m.ctx, defaultWait)
+		defer cancel()
+		_, err := cm.Subscribe(ctx, &paho.Subscribe{
+			Subscriptions: map[string]paho.SubscribeOptions{
+				m.metadata.topic: {QoS: m.metadata.qos},
+			},
+		})
+
+		return err
+	}
+
+	// mqtt broker allows only one connection at a given time from a clientID.
+	consumerClientID := fmt.Sprintf("%s-consumer", m.metadata.clientID)
+	connCtx, connCancel := context.WithTimeout(m.ctx, defaultWait)
+	defer connCancel()
+	c, err := mqtt5.Connect(connCtx, m.connectionConfigV5(consumerClientID, router, func(cm *autopaho.ConnectionManager) {
+		if err := subscribe(cm); err != nil {
+			m.logger.Errorf("mqtt error from subscribe: %v", err)
+		}
+	}))
+	if err != nil {
+		return fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+	m.consumer5 = c
+
+	// The subscription of the first connection may not be done yet.
+	if err = subscribe(c); err != nil {
+		m.logger.Errorf("mqtt error from subscribe: %v", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// onMessageV5 returns the callback delivering MQTT 5 messages to the handler, with the message
+// properties in the metadata. The client acknowledges the message when the callback returns,
+// whether the handler succeeded or not: delivery is at most once.
+func (m *MQTT) onMessageV5(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) paho.MessageHandler {
+	return func(p *paho.Publish) {
+		msg := bindings.ReadResponse{
+			Data:     p.Payload,
+			Metadata: mqtt5.MessageMetadata(p),
+		}
+		msg.Metadata[mqttTopic] = p.Topic
+		if p.Properties != nil && p.Properties.ContentType != "" {
+			contentType := p.Properties.ContentType
+			msg.ContentType = &contentType
+		}
+
+		b := m.backOff
+		if m.metadata.backOffMaxRetries >= 0 {
+			b = backoff.WithMaxRetries(m.backOff, uint64(m.metadata.backOffMaxRetries))
+		}
+
+		if err := retry.NotifyRecover(func() error {
+			m.logger.Debugf("Processing MQTT message %s/%d", p.Topic, p.PacketID)
+
+			return m.runHandler(handler, &msg)
+		}, b, func(err error, d time.Duration) {
+			m.logger.Errorf("Error processing MQTT message: %s/%d. Retrying...", p.Topic, p.PacketID)
+		}, func() {
+			m.logger.Infof("Successfully processed MQTT message after it previously failed: %s/%d", p.Topic, p.PacketID)
+		}); err != nil {
+			m.logger.Errorf("Failed processing MQTT message: %s/%d, the message is acknowledged and will not be delivered again: %v", p.Topic, p.PacketID, err)
+		}
+	}
+}
+
+func (m *MQTT) closeV5() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultWait)
+	defer cancel()
+
+	if m.consumer5 != nil {
+		_ = m.consumer5.Disconnect(ctx)
+	}
+	if m.producer5 != nil {
+		return m.producer5.Disconnect(ctx)
+	}
+
+	return nil
+}
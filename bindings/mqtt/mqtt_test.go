@@ -53,6 +53,28 @@ func TestParseMetadata(t *testing.T) {
 		assert.Equal(t, byte(1), m.qos)
 		assert.Equal(t, true, m.retain)
 		assert.Equal(t, false, m.cleanSession)
+		assert.Equal(t, 3, m.protocolVersion)
+	})
+
+	t.Run("protocol version 5", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeMetaData := bindings.Metadata{Properties: fakeProperties}
+		fakeMetaData.Properties["protocolVersion"] = "5"
+		m, err := parseMQTTMetaData(fakeMetaData)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 5, m.protocolVersion)
+	})
+
+	t.Run("invalid protocol version", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeMetaData := bindings.Metadata{Properties: fakeProperties}
+		fakeMetaData.Properties["protocolVersion"] = "4.5"
+		_, err := parseMQTTMetaData(fakeMetaData)
+
+		// assert
+		assert.Contains(t, err.Error(), "invalid protocolVersion")
 	})
 
 	t.Run("missing topic", func(t *testing.T) {
@@ -30,14 +30,16 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/dapr/components-contrib/bindings"
+	contrib_sql "github.com/dapr/components-contrib/internal/component/sql"
 	"github.com/dapr/kit/logger"
 )
 
 const (
 	// list of operations.
-	execOperation  bindings.OperationKind = "exec"
-	queryOperation bindings.OperationKind = "query"
-	closeOperation bindings.OperationKind = "close"
+	execOperation        bindings.OperationKind = "exec"
+	queryOperation       bindings.OperationKind = "query"
+	transactionOperation bindings.OperationKind = "transaction"
+	closeOperation       bindings.OperationKind = "close"
 
 	// configurations to connect to Mysql, either a data source name represent by URL.
 	connectionURLKey = "url"
@@ -137,44 +139,60 @@ func (m *Mysql) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		return nil, m.db.Close()
 	}
 
-	if req.Metadata == nil {
-		return nil, errors.Errorf("metadata required")
-	}
 	m.logger.Debugf("operation: %v", req.Operation)
 
-	s, ok := req.Metadata[commandSQLKey]
-	if !ok || s == "" {
-		return nil, errors.Errorf("required metadata not set: %s", commandSQLKey)
-	}
-
 	startTime := time.Now().UTC()
 
 	resp := &bindings.InvokeResponse{
 		Metadata: map[string]string{
 			respOpKey:        string(req.Operation),
-			respSQLKey:       s,
 			respStartTimeKey: startTime.Format(time.RFC3339Nano),
 		},
 	}
 
 	switch req.Operation { // nolint: exhaustive
-	case execOperation:
-		r, err := m.exec(s)
+	case execOperation, queryOperation:
+		if req.Metadata == nil {
+			return nil, errors.Errorf("metadata required")
+		}
+		s, ok := req.Metadata[commandSQLKey]
+		if !ok || s == "" {
+			return nil, errors.Errorf("required metadata not set: %s", commandSQLKey)
+		}
+		params, err := contrib_sql.ParseParams(req.Metadata[contrib_sql.ParamsKey])
 		if err != nil {
 			return nil, err
 		}
-		resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+		resp.Metadata[respSQLKey] = s
+
+		if req.Operation == execOperation {
+			r, err := m.exec(s, params...)
+			if err != nil {
+				return nil, err
+			}
+			resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+		} else {
+			d, err := m.query(s, params...)
+			if err != nil {
+				return nil, err
+			}
+			resp.Data = d
+		}
 
-	case queryOperation:
-		d, err := m.query(s)
+	case transactionOperation:
+		statements, err := contrib_sql.ParseStatements(req.Data)
+		if err != nil {
+			return nil, err
+		}
+		r, err := m.transaction(ctx, statements)
 		if err != nil {
 			return nil, err
 		}
-		resp.Data = d
+		resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
 
 	default:
-		return nil, errors.Errorf("invalid operation type: %s. Expected %s, %s, or %s",
-			req.Operation, execOperation, queryOperation, closeOperation)
+		return nil, errors.Errorf("invalid operation type: %s. Expected %s, %s, %s, or %s",
+			req.Operation, execOperation, queryOperation, transactionOperation, closeOperation)
 	}
 
 	endTime := time.Now().UTC()
@@ -189,6 +207,7 @@ func (m *Mysql) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
 		execOperation,
 		queryOperation,
+		transactionOperation,
 		closeOperation,
 	}
 }
@@ -202,10 +221,10 @@ func (m *Mysql) Close() error {
 	return nil
 }
 
-func (m *Mysql) query(sql string) ([]byte, error) {
+func (m *Mysql) query(sql string, params ...interface{}) ([]byte, error) {
 	m.logger.Debugf("query: %s", sql)
 
-	rows, err := m.db.Query(sql)
+	rows, err := m.db.Query(sql, params...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error executing %s", sql)
 	}
@@ -223,10 +242,10 @@ func (m *Mysql) query(sql string) ([]byte, error) {
 	return result, nil
 }
 
-func (m *Mysql) exec(sql string) (int64, error) {
+func (m *Mysql) exec(sql string, params ...interface{}) (int64, error) {
 	m.logger.Debugf("exec: %s", sql)
 
-	res, err := m.db.Exec(sql)
+	res, err := m.db.Exec(sql, params...)
 	if err != nil {
 		return 0, errors.Wrapf(err, "error executing %s", sql)
 	}
@@ -234,6 +253,40 @@ func (m *Mysql) exec(sql string) (int64, error) {
 	return res.RowsAffected()
 }
 
+// transaction executes the statements in a transaction, rolled back if one of them fails.
+// It returns the number of rows affected by all statements.
+func (m *Mysql) transaction(ctx context.Context, statements []contrib_sql.Statement) (int64, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "error starting transaction")
+	}
+
+	var total int64
+	for _, s := range statements {
+		m.logger.Debugf("exec in transaction: %s", s.SQL)
+
+		res, err := tx.ExecContext(ctx, s.SQL, s.Params...)
+		if err != nil {
+			_ = tx.Rollback()
+
+			return 0, errors.Wrapf(err, "error executing %s", s.SQL)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+
+			return 0, errors.Wrapf(err, "error executing %s", s.SQL)
+		}
+		total += affected
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "error committing transaction")
+	}
+
+	return total, nil
+}
+
 func propertyToInt(props map[string]string, key string, setter func(int)) error {
 	if v, ok := props[key]; ok {
 		if i, err := strconv.Atoi(v); err == nil {
@@ -52,8 +52,9 @@ func TestOperations(t *testing.T) {
 		b := NewMysql(nil)
 		assert.NotNil(t, b)
 		l := b.Operations()
-		assert.Equal(t, 3, len(l))
+		assert.Equal(t, 4, len(l))
 		assert.Contains(t, l, execOperation)
+		assert.Contains(t, l, transactionOperation)
 		assert.Contains(t, l, closeOperation)
 		assert.Contains(t, l, queryOperation)
 	})
@@ -153,6 +153,72 @@ func TestInvoke(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
+	t.Run("exec operation with params", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO foo \\(id, v1\\) VALUES \\(\\?, \\?\\)").
+			WithArgs(int64(1), "test-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		metadata := map[string]string{
+			commandSQLKey: "INSERT INTO foo (id, v1) VALUES (?, ?)",
+			"params":      `[1, "test-1"]`,
+		}
+		req := &bindings.InvokeRequest{
+			Metadata:  metadata,
+			Operation: execOperation,
+		}
+		resp, err := m.Invoke(context.TODO(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", resp.Metadata[respRowsAffectedKey])
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		metadata := map[string]string{
+			commandSQLKey: "SELECT * FROM foo WHERE id = ?",
+			"params":      "1",
+		}
+		req := &bindings.InvokeRequest{
+			Metadata:  metadata,
+			Operation: queryOperation,
+		}
+		resp, err := m.Invoke(context.TODO(), req)
+		assert.Nil(t, resp)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("transaction operation succeeds", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO foo").WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE bar").WithArgs("value").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		req := &bindings.InvokeRequest{
+			Data: []byte(`[
+				{"sql": "INSERT INTO foo (id) VALUES (?)", "params": [1]},
+				{"sql": "UPDATE bar SET v = ?", "params": ["value"]}
+			]`),
+			Operation: transactionOperation,
+		}
+		resp, err := m.Invoke(context.TODO(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "3", resp.Metadata[respRowsAffectedKey])
+	})
+
+	t.Run("transaction operation rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO foo").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE bar").WillReturnError(errors.New("update failed"))
+		mock.ExpectRollback()
+		req := &bindings.InvokeRequest{
+			Data: []byte(`[
+				{"sql": "INSERT INTO foo (id) VALUES (?)", "params": [1]},
+				{"sql": "UPDATE bar SET v = ?", "params": ["value"]}
+			]`),
+			Operation: transactionOperation,
+		}
+		resp, err := m.Invoke(context.TODO(), req)
+		assert.Nil(t, resp)
+		assert.NotNil(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("close operation", func(t *testing.T) {
 		mock.ExpectClose()
 		req := &bindings.InvokeRequest{
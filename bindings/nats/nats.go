@@ -0,0 +1,182 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/dapr/components-contrib/bindings"
+	contrib_nats "github.com/dapr/components-contrib/internal/component/nats"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// RequestOperation sends a request and waits for its reply.
+	RequestOperation bindings.OperationKind = "request"
+
+	subjectKey     = "subject"
+	timeoutKey     = "timeout"
+	defaultTimeout = 5 * time.Second
+)
+
+// NATS sends messages and requests to NATS subjects.
+type NATS struct {
+	nc       *nats.Conn
+	metadata natsMetadata
+	logger   logger.Logger
+}
+
+type natsMetadata struct {
+	natsURL string
+	auth    contrib_nats.Auth
+	name    string
+	// subject is the default subject of requests, overridden by the subject request metadata.
+	subject string
+	// timeout is the default time to wait for replies, overridden by the timeout request metadata.
+	timeout time.Duration
+}
+
+// NewNATS returns a new NATS output binding.
+func NewNATS(logger logger.Logger) *NATS {
+	return &NATS{logger: logger}
+}
+
+// Init does metadata parsing and connection creation.
+func (n *NATS) Init(metadata bindings.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	n.metadata = m
+
+	opts := []nats.Option{nats.Name(m.name)}
+	opts = append(opts, m.auth.Options()...)
+
+	n.nc, err = nats.Connect(m.natsURL, opts...)
+	if err != nil {
+		return err
+	}
+	n.logger.Debugf("Connected to nats at %s", m.natsURL)
+
+	return nil
+}
+
+func parseMetadata(metadata bindings.Metadata) (natsMetadata, error) {
+	m := natsMetadata{
+		natsURL: metadata.Properties["natsURL"],
+		subject: metadata.Properties[subjectKey],
+		timeout: defaultTimeout,
+	}
+	if m.natsURL == "" {
+		return m, errors.New("missing nats URL")
+	}
+
+	auth, err := contrib_nats.ParseAuth(metadata.Properties)
+	if err != nil {
+		return m, err
+	}
+	m.auth = auth
+
+	if m.name = metadata.Properties["name"]; m.name == "" {
+		m.name = "dapr.io - bindings.nats"
+	}
+
+	if val := metadata.Properties[timeoutKey]; val != "" {
+		m.timeout, err = time.ParseDuration(val)
+		if err != nil || m.timeout <= 0 {
+			return m, fmt.Errorf("invalid %s %s, expected a positive duration", timeoutKey, val)
+		}
+	}
+
+	return m, nil
+}
+
+func (n *NATS) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{bindings.CreateOperation, RequestOperation}
+}
+
+// Invoke publishes the data to the subject with the create operation, or sends it as a request
+// and returns the reply with the request operation. Metadata other than subject and timeout
+// are sent as message headers, and the headers of the reply are returned as metadata.
+func (n *NATS) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	subject := n.metadata.subject
+	if val := req.Metadata[subjectKey]; val != "" {
+		subject = val
+	}
+	if err := contrib_nats.ValidatePublishSubject(subject); err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(req.Metadata))
+	for k, v := range req.Metadata {
+		if k != subjectKey && k != timeoutKey {
+			headers[k] = v
+		}
+	}
+	msg := &nats.Msg{
+		Subject: subject,
+		Data:    req.Data,
+		Header:  contrib_nats.Header(headers),
+	}
+
+	switch req.Operation {
+	case bindings.CreateOperation:
+		return nil, n.nc.PublishMsg(msg)
+	case RequestOperation:
+		return n.request(ctx, msg, req.Metadata[timeoutKey])
+	default:
+		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
+	}
+}
+
+func (n *NATS) request(ctx context.Context, msg *nats.Msg, timeoutVal string) (*bindings.InvokeResponse, error) {
+	timeout := n.metadata.timeout
+	if timeoutVal != "" {
+		var err error
+		timeout, err = time.ParseDuration(timeoutVal)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid %s %s, expected a positive duration", timeoutKey, timeoutVal)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	reply, err := n.nc.RequestMsgWithContext(ctx, msg)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("no reply on subject %s within %s", msg.Subject, timeout)
+		}
+
+		return nil, fmt.Errorf("error requesting subject %s: %w", msg.Subject, err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     reply.Data,
+		Metadata: contrib_nats.Metadata(reply.Header),
+	}, nil
+}
+
+func (n *NATS) Close() error {
+	if n.nc == nil {
+		return nil
+	}
+
+	return n.nc.Drain()
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	m, err := parseMetadata(bindings.Metadata{Properties: map[string]string{
+		"natsURL": "nats://localhost:4222",
+		"subject": "orders",
+		"timeout": "2s",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", m.subject)
+	assert.Equal(t, 2*time.Second, m.timeout)
+
+	m, err = parseMetadata(bindings.Metadata{Properties: map[string]string{"natsURL": "nats://localhost:4222"}})
+	require.NoError(t, err)
+	assert.Equal(t, defaultTimeout, m.timeout)
+
+	_, err = parseMetadata(bindings.Metadata{Properties: map[string]string{"natsURL": "nats://localhost:4222", "timeout": "soon"}})
+	assert.Error(t, err)
+}
+
+func TestRequestReply(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+
+	responder, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer responder.Close()
+	_, err = responder.Subscribe("greet", func(m *nats.Msg) {
+		reply := nats.NewMsg(m.Reply)
+		reply.Data = append([]byte("hello "), m.Data...)
+		reply.Header.Set("lang", m.Header.Get("lang"))
+		m.RespondMsg(reply)
+	})
+	require.NoError(t, err)
+	_, err = responder.Subscribe("slow", func(m *nats.Msg) {
+		time.Sleep(200 * time.Millisecond)
+		m.Respond(nil)
+	})
+	require.NoError(t, err)
+	require.NoError(t, responder.Flush())
+
+	b := NewNATS(logger.NewLogger("test"))
+	require.NoError(t, b.Init(bindings.Metadata{Properties: map[string]string{
+		"natsURL": s.ClientURL(),
+		"subject": "greet",
+	}}))
+	defer b.Close()
+
+	t.Run("reply", func(t *testing.T) {
+		resp, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: RequestOperation,
+			Data:      []byte("world"),
+			Metadata:  map[string]string{"lang": "en"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello world"), resp.Data)
+		assert.Equal(t, "en", resp.Metadata["lang"])
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: RequestOperation,
+			Metadata:  map[string]string{"subject": "slow", "timeout": "50ms"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("no responders", func(t *testing.T) {
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: RequestOperation,
+			Metadata:  map[string]string{"subject": "nobody"},
+		})
+		assert.ErrorIs(t, err, nats.ErrNoResponders)
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      []byte("fire and forget"),
+		})
+		assert.NoError(t, err)
+	})
+}
@@ -23,14 +23,16 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/dapr/components-contrib/bindings"
+	contrib_sql "github.com/dapr/components-contrib/internal/component/sql"
 	"github.com/dapr/kit/logger"
 )
 
 // List of operations.
 const (
-	execOperation  bindings.OperationKind = "exec"
-	queryOperation bindings.OperationKind = "query"
-	closeOperation bindings.OperationKind = "close"
+	execOperation        bindings.OperationKind = "exec"
+	queryOperation       bindings.OperationKind = "query"
+	transactionOperation bindings.OperationKind = "transaction"
+	closeOperation       bindings.OperationKind = "close"
 
 	connectionURLKey = "url"
 	commandSQLKey    = "sql"
@@ -74,6 +76,7 @@ func (p *Postgres) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
 		execOperation,
 		queryOperation,
+		transactionOperation,
 		closeOperation,
 	}
 }
@@ -90,44 +93,60 @@ func (p *Postgres) Invoke(ctx context.Context, req *bindings.InvokeRequest) (res
 		return nil, nil
 	}
 
-	if req.Metadata == nil {
-		return nil, errors.Errorf("metadata required")
-	}
 	p.logger.Debugf("operation: %v", req.Operation)
 
-	sql, ok := req.Metadata[commandSQLKey]
-	if !ok || sql == "" {
-		return nil, errors.Errorf("required metadata not set: %s", commandSQLKey)
-	}
-
 	startTime := time.Now().UTC()
 	resp = &bindings.InvokeResponse{
 		Metadata: map[string]string{
 			"operation":  string(req.Operation),
-			"sql":        sql,
 			"start-time": startTime.Format(time.RFC3339Nano),
 		},
 	}
 
 	switch req.Operation { // nolint: exhaustive
-	case execOperation:
-		r, err := p.exec(ctx, sql)
+	case execOperation, queryOperation:
+		if req.Metadata == nil {
+			return nil, errors.Errorf("metadata required")
+		}
+		sql, ok := req.Metadata[commandSQLKey]
+		if !ok || sql == "" {
+			return nil, errors.Errorf("required metadata not set: %s", commandSQLKey)
+		}
+		params, err := contrib_sql.ParseParams(req.Metadata[contrib_sql.ParamsKey])
 		if err != nil {
-			return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			return nil, err
+		}
+		resp.Metadata["sql"] = sql
+
+		if req.Operation == execOperation {
+			r, err := p.exec(ctx, sql, params...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			}
+			resp.Metadata["rows-affected"] = strconv.FormatInt(r, 10) // 0 if error
+		} else {
+			d, err := p.query(ctx, sql, params...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			}
+			resp.Data = d
 		}
-		resp.Metadata["rows-affected"] = strconv.FormatInt(r, 10) // 0 if error
 
-	case queryOperation:
-		d, err := p.query(ctx, sql)
+	case transactionOperation:
+		statements, err := contrib_sql.ParseStatements(req.Data)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error executing %s with %v", sql, err)
+			return nil, err
 		}
-		resp.Data = d
+		r, err := p.transaction(ctx, statements)
+		if err != nil {
+			return nil, errors.Wrap(err, "error executing transaction")
+		}
+		resp.Metadata["rows-affected"] = strconv.FormatInt(r, 10)
 
 	default:
 		return nil, errors.Errorf(
-			"invalid operation type: %s. Expected %s, %s, or %s",
-			req.Operation, execOperation, queryOperation, closeOperation,
+			"invalid operation type: %s. Expected %s, %s, %s, or %s",
+			req.Operation, execOperation, queryOperation, transactionOperation, closeOperation,
 		)
 	}
 
@@ -148,21 +167,31 @@ func (p *Postgres) Close() error {
 	return nil
 }
 
-func (p *Postgres) query(ctx context.Context, sql string) (result []byte, err error) {
+// query returns the rows of the result as JSON objects keyed by column name.
+func (p *Postgres) query(ctx context.Context, sql string, params ...interface{}) (result []byte, err error) {
 	p.logger.Debugf("query: %s", sql)
 
-	rows, err := p.db.Query(ctx, sql)
+	rows, err := p.db.Query(ctx, sql, params...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error executing %s", sql)
 	}
+	defer rows.Close()
 
+	fields := rows.FieldDescriptions()
 	rs := make([]interface{}, 0)
 	for rows.Next() {
 		val, rowErr := rows.Values()
 		if rowErr != nil {
 			return nil, errors.Wrapf(rowErr, "error parsing result: %v", rows.Err())
 		}
-		rs = append(rs, val)
+		r := make(map[string]interface{}, len(val))
+		for i, v := range val {
+			r[string(fields[i].Name)] = v
+		}
+		rs = append(rs, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error executing %s", sql)
 	}
 
 	if result, err = json.Marshal(rs); err != nil {
@@ -172,10 +201,10 @@ func (p *Postgres) query(ctx context.Context, sql string) (result []byte, err er
 	return
 }
 
-func (p *Postgres) exec(ctx context.Context, sql string) (result int64, err error) {
+func (p *Postgres) exec(ctx context.Context, sql string, params ...interface{}) (result int64, err error) {
 	p.logger.Debugf("exec: %s", sql)
 
-	res, err := p.db.Exec(ctx, sql)
+	res, err := p.db.Exec(ctx, sql, params...)
 	if err != nil {
 		return 0, errors.Wrapf(err, "error executing %s", sql)
 	}
@@ -184,3 +213,33 @@ func (p *Postgres) exec(ctx context.Context, sql string) (result int64, err erro
 
 	return
 }
+
+// transaction executes the statements in a transaction, rolled back if one of them fails.
+// It returns the number of rows affected by all statements.
+func (p *Postgres) transaction(ctx context.Context, statements []contrib_sql.Statement) (result int64, err error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "error starting transaction")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	for _, s := range statements {
+		p.logger.Debugf("exec in transaction: %s", s.SQL)
+
+		res, execErr := tx.Exec(ctx, s.SQL, s.Params...)
+		if execErr != nil {
+			return 0, errors.Wrapf(execErr, "error executing %s", s.SQL)
+		}
+		result += res.RowsAffected()
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, errors.Wrap(err, "error committing transaction")
+	}
+
+	return result, nil
+}
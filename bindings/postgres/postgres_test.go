@@ -15,6 +15,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
@@ -43,10 +44,27 @@ func TestOperations(t *testing.T) {
 		b := NewPostgres(nil)
 		assert.NotNil(t, b)
 		l := b.Operations()
-		assert.Equal(t, 3, len(l))
+		assert.Equal(t, 4, len(l))
 	})
 }
 
+func TestInvokeInvalidRequest(t *testing.T) {
+	b := NewPostgres(logger.NewLogger("test"))
+	ctx := context.TODO()
+
+	_, err := b.Invoke(ctx, &bindings.InvokeRequest{Operation: execOperation})
+	assert.Error(t, err)
+
+	_, err = b.Invoke(ctx, &bindings.InvokeRequest{
+		Operation: queryOperation,
+		Metadata:  map[string]string{commandSQLKey: testSelect, "params": "1"},
+	})
+	assert.Error(t, err)
+
+	_, err = b.Invoke(ctx, &bindings.InvokeRequest{Operation: transactionOperation, Data: []byte(`[]`)})
+	assert.Error(t, err)
+}
+
 // SETUP TESTS
 // 1. `createdb daprtest`
 // 2. `createuser daprtest`
@@ -105,6 +123,54 @@ func TestPostgresIntegration(t *testing.T) {
 		req.Metadata[commandSQLKey] = testSelect
 		res, err := b.Invoke(ctx, req)
 		assertResponse(t, res, err)
+
+		var rows []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(res.Data, &rows))
+		assert.Len(t, rows, 3)
+		assert.Contains(t, rows[0], "v1")
+	})
+
+	t.Run("Invoke select with params", func(t *testing.T) {
+		req.Operation = queryOperation
+		req.Metadata[commandSQLKey] = "SELECT * FROM foo WHERE v1 = $1"
+		req.Metadata["params"] = `["test-1"]`
+		res, err := b.Invoke(ctx, req)
+		assertResponse(t, res, err)
+		delete(req.Metadata, "params")
+
+		var rows []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(res.Data, &rows))
+		assert.Len(t, rows, 1)
+	})
+
+	t.Run("Invoke transaction", func(t *testing.T) {
+		res, err := b.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: transactionOperation,
+			Data: []byte(`[
+				{"sql": "INSERT INTO foo (id, v1) VALUES ($1, $2)", "params": [100, "tx"]},
+				{"sql": "UPDATE foo SET v1 = $1 WHERE id = $2", "params": ["tx-updated", 100]}
+			]`),
+		})
+		assertResponse(t, res, err)
+		assert.Equal(t, "2", res.Metadata["rows-affected"])
+	})
+
+	t.Run("Invoke failed transaction is rolled back", func(t *testing.T) {
+		_, err := b.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: transactionOperation,
+			Data: []byte(`[
+				{"sql": "INSERT INTO foo (id, v1) VALUES ($1, $2)", "params": [101, "tx"]},
+				{"sql": "INSERT INTO missing (id) VALUES ($1)", "params": [101]}
+			]`),
+		})
+		assert.Error(t, err)
+
+		res, err := b.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{commandSQLKey: "SELECT * FROM foo WHERE id = $1", "params": "[101]"},
+		})
+		assertResponse(t, res, err)
+		assert.Equal(t, "[]", string(res.Data))
 	})
 
 	t.Run("Invoke delete", func(t *testing.T) {
@@ -0,0 +1,240 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	// readBlockTimeout bounds the blocking reads so that Close is noticed.
+	readBlockTimeout = time.Second
+	// readErrorWait is the pause after a failed read, before trying again.
+	readErrorWait = time.Second
+	// readBatchSize is the maximum number of stream entries read at once.
+	readBatchSize = 100
+)
+
+// Read consumes the configured list or stream, delivering each value to the handler.
+// Values popped from a list are delivered at most once. Stream entries are acknowledged
+// once the handler succeeds, and are otherwise delivered again every redeliverInterval.
+// Entries left pending by other consumers for processingTimeout are claimed and delivered.
+func (r *Redis) Read(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	switch {
+	case r.metadata.inputList != "":
+		go r.readList(handler)
+	case r.metadata.inputStream != "":
+		// As in the redis streams pubsub, a new group starts from the beginning of the stream.
+		err := r.client.XGroupCreateMkStream(r.ctx, r.metadata.inputStream, r.metadata.consumerGroup, "0").Err()
+		if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+			return fmt.Errorf("redis binding: error creating consumer group %s: %s", r.metadata.consumerGroup, err)
+		}
+		go r.readStream(handler)
+	default:
+		return fmt.Errorf("redis binding: %s or %s is required to read", inputListKey, inputStreamKey)
+	}
+
+	return nil
+}
+
+func (r *Redis) readList(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) {
+	for r.ctx.Err() == nil {
+		values, err := r.client.BLPop(r.ctx, readBlockTimeout, r.metadata.inputList).Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				r.readFailed(err)
+			}
+
+			continue
+		}
+
+		// BLPOP replies with the list name followed by the value.
+		_, err = handler(r.ctx, &bindings.ReadResponse{
+			Data:     []byte(values[1]),
+			Metadata: map[string]string{keyMetadata: values[0]},
+		})
+		if err != nil {
+			r.logger.Errorf("redis binding: error handling value of list %s: %s", r.metadata.inputList, err)
+		}
+	}
+}
+
+func (r *Redis) readStream(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) {
+	// The entries left pending before a restart are delivered first.
+	var nextRedelivery time.Time
+	for r.ctx.Err() == nil {
+		if r.metadata.redeliverInterval > 0 && !time.Now().Before(nextRedelivery) {
+			if err := r.redeliverPending(handler); err != nil {
+				r.readFailed(err)
+
+				continue
+			}
+			nextRedelivery = time.Now().Add(r.metadata.redeliverInterval)
+		}
+
+		if _, err := r.readStreamMessages(handler, ">"); err != nil {
+			r.readFailed(err)
+		}
+	}
+}
+
+// redeliverPending claims the entries pending for processingTimeout in other consumers, then
+// delivers the pending entries of this consumer again.
+func (r *Redis) redeliverPending(handler func(context.Context, *bindings.ReadResponse) ([]byte, error)) error {
+	if r.metadata.processingTimeout > 0 {
+		if err := r.claimPending(); err != nil {
+			return err
+		}
+	}
+
+	for start := "0"; r.ctx.Err() == nil; {
+		last, err := r.readStreamMessages(handler, start)
+		if err != nil || last == "" {
+			return err
+		}
+		start = last
+	}
+
+	return nil
+}
+
+// claimPending moves the entries pending for processingTimeout in other consumers, which may
+// have stopped, to this consumer.
+func (r *Redis) claimPending() error {
+	pending, err := r.client.XPendingExt(r.ctx, &redis.XPendingExtArgs{
+		Stream: r.metadata.inputStream,
+		Group:  r.metadata.consumerGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  readBatchSize,
+	}).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		if p.Consumer != r.metadata.consumerName && p.Idle >= r.metadata.processingTimeout {
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Only the IDs of the claimed entries are needed, they are read from the pending entries.
+	err = r.client.XClaimJustID(r.ctx, &redis.XClaimArgs{
+		Stream:   r.metadata.inputStream,
+		Group:    r.metadata.consumerGroup,
+		Consumer: r.metadata.consumerName,
+		MinIdle:  r.metadata.processingTimeout,
+		Messages: ids,
+	}).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	return nil
+}
+
+// readStreamMessages reads the entries of the stream for the consumer group, after start, and
+// delivers them to the handler. start is ">" for the new entries, or an ID for the pending entries
+// of this consumer. It returns the ID of the last entry read.
+func (r *Redis) readStreamMessages(handler func(context.Context, *bindings.ReadResponse) ([]byte, error), start string) (string, error) {
+	args := &redis.XReadGroupArgs{
+		Group:    r.metadata.consumerGroup,
+		Consumer: r.metadata.consumerName,
+		Streams:  []string{r.metadata.inputStream, start},
+		Count:    readBatchSize,
+		Block:    readBlockTimeout,
+	}
+	if start != ">" {
+		// Pending entries are returned right away.
+		args.Block = -1
+	}
+
+	streams, err := r.client.XReadGroup(r.ctx, args).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	var last string
+	for _, s := range streams {
+		for _, msg := range s.Messages {
+			r.handleStreamMessage(handler, s.Stream, msg)
+			last = msg.ID
+		}
+	}
+
+	return last, nil
+}
+
+func (r *Redis) handleStreamMessage(handler func(context.Context, *bindings.ReadResponse) ([]byte, error), stream string, msg redis.XMessage) {
+	// Pending entries deleted from the stream, by trimming for instance, come back without values.
+	if len(msg.Values) == 0 {
+		r.ack(stream, msg.ID)
+
+		return
+	}
+
+	var data []byte
+	switch v := msg.Values[streamDataField].(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	}
+
+	_, err := handler(r.ctx, &bindings.ReadResponse{
+		Data:     data,
+		Metadata: map[string]string{keyMetadata: stream, idMetadata: msg.ID},
+	})
+	if err != nil {
+		r.logger.Errorf("redis binding: error handling entry %s of stream %s: %s", msg.ID, stream, err)
+
+		return
+	}
+
+	r.ack(stream, msg.ID)
+}
+
+func (r *Redis) ack(stream string, id string) {
+	if err := r.client.XAck(r.ctx, stream, r.metadata.consumerGroup, id).Err(); err != nil {
+		r.logger.Errorf("redis binding: error acknowledging entry %s of stream %s: %s", id, stream, err)
+	}
+}
+
+// readFailed logs a read error, unless the binding is closing, and waits before the next read.
+func (r *Redis) readFailed(err error) {
+	if r.ctx.Err() != nil {
+		return
+	}
+	r.logger.Errorf("redis binding: error reading: %s", err)
+
+	select {
+	case <-time.After(readErrorWait):
+	case <-r.ctx.Done():
+	}
+}
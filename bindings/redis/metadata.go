@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	inputListKey     = "inputList"
+	inputStreamKey   = "inputStream"
+	consumerGroupKey = "consumerGroup"
+	consumerNameKey  = "consumerName"
+	// redeliverIntervalKey and processingTimeoutKey configure the redelivery of pending stream entries.
+	redeliverIntervalKey = "redeliverInterval"
+	processingTimeoutKey = "processingTimeout"
+
+	defaultConsumerGroup     = "dapr"
+	defaultRedeliverInterval = 15 * time.Second
+	defaultProcessingTimeout = 60 * time.Second
+)
+
+// metadata holds the settings of the input binding, the client settings are parsed by
+// the redis component.
+type metadata struct {
+	// inputList is the list consumed with BLPOP.
+	inputList string
+	// inputStream is the stream consumed with XREADGROUP.
+	inputStream string
+	// consumerGroup and consumerName identify the reader of inputStream. The name should be stable
+	// across restarts, for the reader to get back the entries left pending.
+	consumerGroup string
+	consumerName  string
+	// redeliverInterval is the interval at which the pending entries of the consumer are delivered
+	// again, 0 disables redelivery.
+	redeliverInterval time.Duration
+	// processingTimeout is the time after which the pending entries of other consumers are claimed,
+	// 0 disables claiming.
+	processingTimeout time.Duration
+}
+
+func parseMetadata(properties map[string]string) (metadata, error) {
+	m := metadata{
+		inputList:     properties[inputListKey],
+		inputStream:   properties[inputStreamKey],
+		consumerGroup: properties[consumerGroupKey],
+		consumerName:  properties[consumerNameKey],
+	}
+	if m.inputList != "" && m.inputStream != "" {
+		return m, errors.New("redis binding: inputList and inputStream are mutually exclusive")
+	}
+	if m.consumerGroup == "" {
+		m.consumerGroup = defaultConsumerGroup
+	}
+	if m.consumerName == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = uuid.New().String()
+		}
+		m.consumerName = hostname
+	}
+
+	var err error
+	if m.redeliverInterval, err = parseDuration(properties, redeliverIntervalKey, defaultRedeliverInterval); err != nil {
+		return m, err
+	}
+	if m.processingTimeout, err = parseDuration(properties, processingTimeoutKey, defaultProcessingTimeout); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+func parseDuration(properties map[string]string, key string, defaultValue time.Duration) (time.Duration, error) {
+	val, ok := properties[key]
+	if !ok || val == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("redis binding: invalid %s %s", key, val)
+	}
+
+	return d, nil
+}
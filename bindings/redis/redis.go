@@ -15,20 +15,51 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/go-redis/redis/v8"
 
 	"github.com/dapr/components-contrib/bindings"
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
-// Redis is a redis output binding.
+const (
+	// IncrementOperation increments the integer value of a key.
+	IncrementOperation bindings.OperationKind = "incr"
+	// LPushOperation and RPushOperation push the data to the head or tail of a list.
+	LPushOperation bindings.OperationKind = "lpush"
+	RPushOperation bindings.OperationKind = "rpush"
+	// LPopOperation and RPopOperation pop a value from the head or tail of a list.
+	LPopOperation bindings.OperationKind = "lpop"
+	RPopOperation bindings.OperationKind = "rpop"
+	// HSetOperation, HGetOperation, HDeleteOperation and HGetAllOperation work on the fields of a hash.
+	HSetOperation    bindings.OperationKind = "hset"
+	HGetOperation    bindings.OperationKind = "hget"
+	HDeleteOperation bindings.OperationKind = "hdel"
+	HGetAllOperation bindings.OperationKind = "hgetall"
+	// XAddOperation appends the data to a stream.
+	XAddOperation bindings.OperationKind = "xadd"
+
+	keyMetadata          = "key"
+	fieldMetadata        = "field"
+	incrementMetadata    = "increment"
+	streamMaxLenMetadata = "maxLen"
+	idMetadata           = "id"
+
+	// streamDataField is the stream entry field holding the data, as in the redis streams pubsub.
+	streamDataField = "data"
+)
+
+// Redis is a redis input and output binding.
 type Redis struct {
 	client         redis.UniversalClient
 	clientSettings *rediscomponent.Settings
+	metadata       metadata
 	logger         logger.Logger
 
 	ctx    context.Context
@@ -42,6 +73,11 @@ func NewRedis(logger logger.Logger) *Redis {
 
 // Init performs metadata parsing and connection creation.
 func (r *Redis) Init(meta bindings.Metadata) (err error) {
+	r.metadata, err = parseMetadata(meta.Properties)
+	if err != nil {
+		return err
+	}
+
 	r.client, r.clientSettings, err = rediscomponent.ParseClientFromProperties(meta.Properties, nil)
 	if err != nil {
 		return err
@@ -58,21 +94,147 @@ func (r *Redis) Init(meta bindings.Metadata) (err error) {
 }
 
 func (r *Redis) Operations() []bindings.OperationKind {
-	return []bindings.OperationKind{bindings.CreateOperation}
+	return []bindings.OperationKind{
+		bindings.CreateOperation,
+		bindings.GetOperation,
+		bindings.DeleteOperation,
+		IncrementOperation,
+		LPushOperation,
+		RPushOperation,
+		LPopOperation,
+		RPopOperation,
+		HSetOperation,
+		HGetOperation,
+		HDeleteOperation,
+		HGetAllOperation,
+		XAddOperation,
+	}
 }
 
 func (r *Redis) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	if val, ok := req.Metadata["key"]; ok && val != "" {
-		key := val
-		_, err := r.client.Do(ctx, "SET", key, req.Data).Result()
+	key := req.Metadata[keyMetadata]
+	if key == "" {
+		return nil, errors.New("redis binding: missing key on request metadata")
+	}
+
+	switch req.Operation {
+	case bindings.CreateOperation, "":
+		return r.set(ctx, key, req)
+	case bindings.GetOperation:
+		return toResponse(r.client.Get(ctx, key).Bytes())
+	case bindings.DeleteOperation:
+		return nil, r.client.Del(ctx, key).Err()
+	case IncrementOperation:
+		return r.increment(ctx, key, req)
+	case LPushOperation:
+		return nil, r.client.LPush(ctx, key, req.Data).Err()
+	case RPushOperation:
+		return nil, r.client.RPush(ctx, key, req.Data).Err()
+	case LPopOperation:
+		return toResponse(r.client.LPop(ctx, key).Bytes())
+	case RPopOperation:
+		return toResponse(r.client.RPop(ctx, key).Bytes())
+	case HSetOperation, HGetOperation, HDeleteOperation:
+		return r.hashField(ctx, key, req)
+	case HGetAllOperation:
+		values, err := r.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(values)
 		if err != nil {
 			return nil, err
 		}
 
-		return nil, nil
+		return &bindings.InvokeResponse{Data: data}, nil
+	case XAddOperation:
+		return r.xadd(ctx, key, req)
+	default:
+		return nil, fmt.Errorf("redis binding: unsupported operation %s", req.Operation)
+	}
+}
+
+// set stores the data at key, expiring it after the optional ttlInSeconds.
+func (r *Redis) set(ctx context.Context, key string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ttl, ok, err := contrib_metadata.TryGetTTL(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("redis binding: %s", err)
+	}
+	if !ok {
+		ttl = 0
+	}
+
+	return nil, r.client.Set(ctx, key, req.Data, ttl).Err()
+}
+
+// increment adds the optional increment, 1 by default, to the integer at key and
+// returns the new value.
+func (r *Redis) increment(ctx context.Context, key string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	increment := int64(1)
+	if val := req.Metadata[incrementMetadata]; val != "" {
+		var err error
+		if increment, err = strconv.ParseInt(val, 10, 64); err != nil {
+			return nil, fmt.Errorf("redis binding: %s value must be a valid integer: actual is '%s'", incrementMetadata, val)
+		}
+	}
+
+	value, err := r.client.IncrBy(ctx, key, increment).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{Data: []byte(strconv.FormatInt(value, 10))}, nil
+}
+
+func (r *Redis) hashField(ctx context.Context, key string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	field := req.Metadata[fieldMetadata]
+	if field == "" {
+		return nil, errors.New("redis binding: missing field on hash request metadata")
+	}
+
+	switch req.Operation {
+	case HSetOperation:
+		return nil, r.client.HSet(ctx, key, field, req.Data).Err()
+	case HGetOperation:
+		return toResponse(r.client.HGet(ctx, key, field).Bytes())
+	default:
+		return nil, r.client.HDel(ctx, key, field).Err()
+	}
+}
+
+// xadd appends the data to the stream at key, capping its length at the optional maxLen,
+// and returns the ID of the entry.
+func (r *Redis) xadd(ctx context.Context, key string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	args := &redis.XAddArgs{
+		Stream: key,
+		Values: map[string]interface{}{streamDataField: req.Data},
+	}
+	if val := req.Metadata[streamMaxLenMetadata]; val != "" {
+		maxLen, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || maxLen <= 0 {
+			return nil, fmt.Errorf("redis binding: %s value must be a positive integer: actual is '%s'", streamMaxLenMetadata, val)
+		}
+		args.MaxLenApprox = maxLen
+	}
+
+	id, err := r.client.XAdd(ctx, args).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{Metadata: map[string]string{idMetadata: id}}, nil
+}
+
+// toResponse turns the result of a read into a response, with no data when the key is missing.
+func toResponse(data []byte, err error) (*bindings.InvokeResponse, error) {
+	if errors.Is(err, redis.Nil) {
+		return &bindings.InvokeResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("redis binding: missing key on write request metadata")
+	return &bindings.InvokeResponse{Data: data}, nil
 }
 
 func (r *Redis) Close() error {
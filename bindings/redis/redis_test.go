@@ -15,11 +15,15 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"os"
 	"testing"
+	"time"
 
 	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
@@ -67,3 +71,196 @@ func setupMiniredis() (*miniredis.Miniredis, *redis.Client) {
 
 	return s, redis.NewClient(opts)
 }
+
+func TestInvokeOperations(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	bind := &Redis{
+		client: c,
+		logger: logger.NewLogger("test"),
+	}
+	bind.ctx, bind.cancel = context.WithCancel(context.Background())
+
+	invoke := func(operation bindings.OperationKind, data string, metadata map[string]string) *bindings.InvokeResponse {
+		t.Helper()
+		res, err := bind.Invoke(context.TODO(), &bindings.InvokeRequest{
+			Operation: operation,
+			Data:      []byte(data),
+			Metadata:  metadata,
+		})
+		require.NoError(t, err)
+
+		return res
+	}
+
+	t.Run("create with ttl, get and delete", func(t *testing.T) {
+		invoke(bindings.CreateOperation, testData, map[string]string{"key": testKey, "ttlInSeconds": "10"})
+		assert.Equal(t, 10*time.Second, s.TTL(testKey))
+		assert.Equal(t, testData, string(invoke(bindings.GetOperation, "", map[string]string{"key": testKey}).Data))
+
+		invoke(bindings.DeleteOperation, "", map[string]string{"key": testKey})
+		assert.Nil(t, invoke(bindings.GetOperation, "", map[string]string{"key": testKey}).Data)
+
+		_, err := bind.Invoke(context.TODO(), &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Metadata:  map[string]string{"key": testKey, "ttlInSeconds": "soon"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("incr", func(t *testing.T) {
+		assert.Equal(t, "1", string(invoke(IncrementOperation, "", map[string]string{"key": "counter"}).Data))
+		assert.Equal(t, "6", string(invoke(IncrementOperation, "", map[string]string{"key": "counter", "increment": "5"}).Data))
+	})
+
+	t.Run("list push and pop", func(t *testing.T) {
+		invoke(RPushOperation, "b", map[string]string{"key": "list"})
+		invoke(LPushOperation, "a", map[string]string{"key": "list"})
+		invoke(RPushOperation, "c", map[string]string{"key": "list"})
+		assert.Equal(t, "a", string(invoke(LPopOperation, "", map[string]string{"key": "list"}).Data))
+		assert.Equal(t, "c", string(invoke(RPopOperation, "", map[string]string{"key": "list"}).Data))
+		assert.Equal(t, "b", string(invoke(LPopOperation, "", map[string]string{"key": "list"}).Data))
+		assert.Nil(t, invoke(LPopOperation, "", map[string]string{"key": "list"}).Data)
+	})
+
+	t.Run("hash fields", func(t *testing.T) {
+		invoke(HSetOperation, "1", map[string]string{"key": "hash", "field": "a"})
+		invoke(HSetOperation, "2", map[string]string{"key": "hash", "field": "b"})
+		assert.Equal(t, "1", string(invoke(HGetOperation, "", map[string]string{"key": "hash", "field": "a"}).Data))
+		invoke(HDeleteOperation, "", map[string]string{"key": "hash", "field": "a"})
+		assert.JSONEq(t, `{"b":"2"}`, string(invoke(HGetAllOperation, "", map[string]string{"key": "hash"}).Data))
+
+		_, err := bind.Invoke(context.TODO(), &bindings.InvokeRequest{
+			Operation: HGetOperation,
+			Metadata:  map[string]string{"key": "hash"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("xadd", func(t *testing.T) {
+		res := invoke(XAddOperation, testData, map[string]string{"key": "stream", "maxLen": "100"})
+		assert.NotEmpty(t, res.Metadata["id"])
+
+		entries, err := c.XRange(context.Background(), "stream", "-", "+").Result()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, testData, entries[0].Values["data"])
+	})
+
+	t.Run("unsupported operation", func(t *testing.T) {
+		_, err := bind.Invoke(context.TODO(), &bindings.InvokeRequest{
+			Operation: "flushall",
+			Metadata:  map[string]string{"key": testKey},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestReadList(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	bind := &Redis{
+		client:   c,
+		metadata: metadata{inputList: "queue"},
+		logger:   logger.NewLogger("test"),
+	}
+	bind.ctx, bind.cancel = context.WithCancel(context.Background())
+	defer bind.Close()
+
+	received := make(chan *bindings.ReadResponse, 10)
+	require.NoError(t, bind.Read(func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+		received <- r
+
+		return nil, nil
+	}))
+
+	require.NoError(t, c.RPush(context.Background(), "queue", "first", "second").Err())
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case r := <-received:
+			assert.Equal(t, expected, string(r.Data))
+			assert.Equal(t, "queue", r.Metadata["key"])
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no value read")
+		}
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	// Claiming the entries of other consumers is disabled, miniredis does not support XPENDING.
+	m, err := parseMetadata(map[string]string{"inputStream": "events", "consumerGroup": "workers", "processingTimeout": "0s"})
+	require.NoError(t, err)
+	bind := &Redis{
+		client:   c,
+		metadata: m,
+		logger:   logger.NewLogger("test"),
+	}
+	bind.ctx, bind.cancel = context.WithCancel(context.Background())
+	defer bind.cancel()
+
+	var ids []string
+	for _, data := range []string{"first", "second", "fail"} {
+		res, err := bind.Invoke(context.TODO(), &bindings.InvokeRequest{
+			Operation: XAddOperation,
+			Data:      []byte(data),
+			Metadata:  map[string]string{"key": "events"},
+		})
+		require.NoError(t, err)
+		ids = append(ids, res.Metadata["id"])
+	}
+	require.NoError(t, c.XGroupCreate(context.Background(), "events", "workers", "0").Err())
+
+	// The read loop is not started, as miniredis does not support blocking XREADGROUP.
+	var received []string
+	failures := 2
+	handler := func(ctx context.Context, r *bindings.ReadResponse) ([]byte, error) {
+		assert.Equal(t, "events", r.Metadata["key"])
+		received = append(received, r.Metadata["id"])
+		if string(r.Data) == "fail" && failures > 0 {
+			failures--
+
+			return nil, errors.New("handler failure")
+		}
+
+		return nil, nil
+	}
+	last, err := bind.readStreamMessages(handler, ">")
+	require.NoError(t, err)
+	assert.Equal(t, ids[2], last)
+	assert.Equal(t, ids, received)
+
+	// Only the failed entry is left pending, and delivered again until it succeeds.
+	received = nil
+	require.NoError(t, bind.redeliverPending(handler))
+	assert.Equal(t, []string{ids[2]}, received)
+	require.NoError(t, bind.redeliverPending(handler))
+	assert.Equal(t, []string{ids[2], ids[2]}, received)
+
+	// Nothing is left to deliver again.
+	require.NoError(t, bind.redeliverPending(handler))
+	assert.Len(t, received, 2)
+}
+
+func TestParseMetadata(t *testing.T) {
+	m, err := parseMetadata(map[string]string{"inputStream": "events"})
+	require.NoError(t, err)
+	assert.Equal(t, "dapr", m.consumerGroup)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, m.consumerName)
+	assert.Equal(t, 15*time.Second, m.redeliverInterval)
+	assert.Equal(t, time.Minute, m.processingTimeout)
+
+	_, err = parseMetadata(map[string]string{"inputStream": "events", "redeliverInterval": "soon"})
+	assert.Error(t, err)
+
+	_, err = parseMetadata(map[string]string{"inputStream": "events", "inputList": "queue"})
+	assert.Error(t, err)
+
+	bind := &Redis{logger: logger.NewLogger("test")}
+	assert.Error(t, bind.Read(nil))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"path/filepath"
+	texttemplate "text/template"
+
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	// envelopeMetadataKey set to true in the request metadata sends the JSON envelope in the data.
+	envelopeMetadataKey = "envelope"
+
+	htmlTemplateExt = ".html"
+	textTemplateExt = ".txt"
+)
+
+// envelope is the JSON data of the requests sending HTML and text bodies, templates and attachments.
+type envelope struct {
+	// Subject overrides the subject of the metadata.
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+	// Template renders the text and HTML bodies with the <template>.txt and <template>.html
+	// templates of templatesPath, executed with Data.
+	Template    string       `json:"template"`
+	Data        interface{}  `json:"data"`
+	Attachments []attachment `json:"attachments"`
+	// Inline are the images referenced by the HTML body with cid:<contentID>.
+	Inline []attachment `json:"inline"`
+}
+
+type attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	// ContentID is the identifier of inline attachments, their file name by default.
+	ContentID string `json:"contentID"`
+	// Content is the base64 encoded content.
+	Content string `json:"content"`
+}
+
+// templates are the templates loaded from the templates directory.
+type templates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// loadTemplates parses the *.html files of dir as HTML templates and the *.txt files as text templates.
+func loadTemplates(dir string) (*templates, error) {
+	t := &templates{}
+
+	htmlFiles, err := filepath.Glob(filepath.Join(dir, "*"+htmlTemplateExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(htmlFiles) > 0 {
+		if t.html, err = htmltemplate.ParseFiles(htmlFiles...); err != nil {
+			return nil, err
+		}
+	}
+
+	textFiles, err := filepath.Glob(filepath.Join(dir, "*"+textTemplateExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(textFiles) > 0 {
+		if t.text, err = texttemplate.ParseFiles(textFiles...); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.html == nil && t.text == nil {
+		return nil, fmt.Errorf("no %s or %s templates in %s", htmlTemplateExt, textTemplateExt, dir)
+	}
+
+	return t, nil
+}
+
+// render executes the text and HTML templates of name with data. Either can be missing, not both.
+func (t *templates) render(name string, data interface{}) (text, html string, err error) {
+	found := false
+
+	if t.text != nil {
+		if tmpl := t.text.Lookup(name + textTemplateExt); tmpl != nil {
+			found = true
+			var buf bytes.Buffer
+			if err = tmpl.Execute(&buf, data); err != nil {
+				return "", "", err
+			}
+			text = buf.String()
+		}
+	}
+
+	if t.html != nil {
+		if tmpl := t.html.Lookup(name + htmlTemplateExt); tmpl != nil {
+			found = true
+			var buf bytes.Buffer
+			if err = tmpl.Execute(&buf, data); err != nil {
+				return "", "", err
+			}
+			html = buf.String()
+		}
+	}
+
+	if !found {
+		return "", "", fmt.Errorf("template %s not found", name)
+	}
+
+	return text, html, nil
+}
+
+// compose sets the subject, bodies and attachments of the envelope to msg.
+func (s *Mailer) compose(msg *gomail.Message, data []byte) error {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("smtp binding error: invalid envelope: %w", err)
+	}
+
+	if env.Subject != "" {
+		msg.SetHeader("Subject", env.Subject)
+	}
+
+	if env.Template != "" {
+		if s.templates == nil {
+			return fmt.Errorf("smtp binding error: no templatesPath to render template %s", env.Template)
+		}
+		var err error
+		if env.Text, env.HTML, err = s.templates.render(env.Template, env.Data); err != nil {
+			return fmt.Errorf("smtp binding error: error rendering template: %w", err)
+		}
+	}
+
+	// Clients display the last alternative they support, so HTML comes after text.
+	switch {
+	case env.Text != "" && env.HTML != "":
+		msg.SetBody("text/plain", env.Text)
+		msg.AddAlternative("text/html", env.HTML)
+	case env.HTML != "":
+		msg.SetBody("text/html", env.HTML)
+	default:
+		msg.SetBody("text/plain", env.Text)
+	}
+
+	for _, a := range env.Attachments {
+		settings, err := a.settings(false)
+		if err != nil {
+			return err
+		}
+		msg.Attach(a.Filename, settings...)
+	}
+	for _, a := range env.Inline {
+		settings, err := a.settings(true)
+		if err != nil {
+			return err
+		}
+		msg.Embed(a.Filename, settings...)
+	}
+
+	return nil
+}
+
+// settings returns the settings writing the content of the attachment.
+func (a attachment) settings(inline bool) ([]gomail.FileSetting, error) {
+	if a.Filename == "" {
+		return nil, fmt.Errorf("smtp binding error: attachment without filename")
+	}
+	content, err := base64.StdEncoding.DecodeString(a.Content)
+	if err != nil {
+		return nil, fmt.Errorf("smtp binding error: invalid base64 content of attachment %s: %w", a.Filename, err)
+	}
+
+	header := map[string][]string{}
+	if a.ContentType != "" {
+		header["Content-Type"] = []string{fmt.Sprintf("%s; name=%q", a.ContentType, filepath.Base(a.Filename))}
+	}
+	if inline && a.ContentID != "" {
+		header["Content-ID"] = []string{"<" + a.ContentID + ">"}
+	}
+
+	return []gomail.FileSetting{
+		gomail.SetHeader(header),
+		gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(content)
+
+			return err
+		}),
+	}, nil
+}
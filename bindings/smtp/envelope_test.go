@@ -0,0 +1,274 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smtp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+// smtpServer is a local SMTP server keeping the messages it receives.
+type smtpServer struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpServer{listener: l, messages: make(chan string, 10)}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.Fields(line + " x")[0]); cmd {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				line, err = r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.messages <- data.String()
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpServer) port() string {
+	return strconv.Itoa(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// part is a decoded MIME part.
+type part struct {
+	header map[string][]string
+	body   string
+}
+
+// parts returns the leaf parts of the message, depth first.
+func parts(t *testing.T, header map[string][]string, body io.Reader) []part {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(strings.Join(header["Content-Type"], ""))
+	require.NoError(t, err)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := ioutil.ReadAll(body)
+		require.NoError(t, err)
+		switch strings.Join(header["Content-Transfer-Encoding"], "") {
+		case "base64":
+			data, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(data), "\r\n", ""))
+			require.NoError(t, err)
+		case "quoted-printable":
+			data, err = ioutil.ReadAll(quotedprintable.NewReader(strings.NewReader(string(data))))
+			require.NoError(t, err)
+		}
+
+		return []part{{header: header, body: string(data)}}
+	}
+
+	var result []part
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return result
+		}
+		require.NoError(t, err)
+		// The multipart reader removes the encoding header of quoted-printable parts it decodes.
+		result = append(result, parts(t, p.Header, p)...)
+	}
+}
+
+func newMailer(t *testing.T, server *smtpServer, properties map[string]string) *Mailer {
+	t.Helper()
+
+	properties["host"] = "127.0.0.1"
+	properties["port"] = server.port()
+	properties["emailFrom"] = "from@dapr.io"
+	properties["emailTo"] = "to@dapr.io"
+	m := NewSMTP(logger.NewLogger("test"))
+	require.NoError(t, m.Init(bindings.Metadata{Properties: properties}))
+
+	return m
+}
+
+func send(t *testing.T, m *Mailer, server *smtpServer, env envelope) (*mail.Message, []part) {
+	t.Helper()
+
+	data, err := json.Marshal(env)
+	require.NoError(t, err)
+	_, err = m.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      data,
+		Metadata:  map[string]string{envelopeMetadataKey: "true"},
+	})
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-server.messages))
+	require.NoError(t, err)
+
+	return msg, parts(t, msg.Header, msg.Body)
+}
+
+func TestSendEnvelope(t *testing.T) {
+	server := newSMTPServer(t)
+	m := newMailer(t, server, map[string]string{})
+
+	msg, ps := send(t, m, server, envelope{
+		Subject: "Invoice",
+		Text:    "Your invoice",
+		HTML:    `<p>Your invoice</p><img src="cid:logo">`,
+		Attachments: []attachment{{
+			Filename:    "invoice.pdf",
+			ContentType: "application/pdf",
+			Content:     base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")),
+		}},
+		Inline: []attachment{{
+			Filename:    "logo.png",
+			ContentType: "image/png",
+			ContentID:   "logo",
+			Content:     base64.StdEncoding.EncodeToString([]byte("PNG")),
+		}},
+	})
+
+	assert.Equal(t, "Invoice", msg.Header.Get("Subject"))
+	assert.True(t, strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/mixed"))
+	require.Len(t, ps, 4)
+	assert.True(t, strings.HasPrefix(ps[0].header["Content-Type"][0], "text/plain"))
+	assert.Equal(t, "Your invoice", ps[0].body)
+	assert.True(t, strings.HasPrefix(ps[1].header["Content-Type"][0], "text/html"))
+	assert.Equal(t, `<p>Your invoice</p><img src="cid:logo">`, ps[1].body)
+	assert.Equal(t, []string{"<logo>"}, ps[2].header["Content-Id"])
+	assert.Equal(t, "PNG", ps[2].body)
+	assert.True(t, strings.HasPrefix(ps[3].header["Content-Type"][0], "application/pdf"))
+	assert.Contains(t, ps[3].header["Content-Disposition"][0], "invoice.pdf")
+	assert.Equal(t, "%PDF-1.4", ps[3].body)
+}
+
+func TestSendTemplate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "welcome.txt"), []byte("Hello {{.Name}}"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "welcome.html"), []byte("<p>Hello {{.Name}}</p>"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "reset.html"), []byte("<a href=\"{{.Link}}\">Reset</a>"), 0o600))
+
+	server := newSMTPServer(t)
+	m := newMailer(t, server, map[string]string{"templatesPath": dir, "subject": "Welcome"})
+
+	msg, ps := send(t, m, server, envelope{Template: "welcome", Data: map[string]string{"Name": "<Ada>"}})
+	assert.Equal(t, "Welcome", msg.Header.Get("Subject"))
+	require.Len(t, ps, 2)
+	assert.Equal(t, "Hello <Ada>", ps[0].body)
+	// Escaped by the HTML template.
+	assert.Equal(t, "<p>Hello &lt;Ada&gt;</p>", ps[1].body)
+
+	_, ps = send(t, m, server, envelope{Template: "reset", Data: map[string]string{"Link": "https://dapr.io/reset"}})
+	require.Len(t, ps, 1)
+	assert.True(t, strings.HasPrefix(ps[0].header["Content-Type"][0], "text/html"))
+
+	data, _ := json.Marshal(envelope{Template: "missing"})
+	_, err := m.Invoke(context.TODO(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      data,
+		Metadata:  map[string]string{envelopeMetadataKey: "true"},
+	})
+	assert.Error(t, err)
+}
+
+func TestSendEnvelopeErrors(t *testing.T) {
+	server := newSMTPServer(t)
+	m := newMailer(t, server, map[string]string{})
+
+	for name, data := range map[string]string{
+		"invalid json":          "not json",
+		"no subject":            `{"text":"body"}`,
+		"invalid base64":        `{"subject":"s","attachments":[{"filename":"a.txt","content":"!!"}]}`,
+		"no filename":           `{"subject":"s","attachments":[{"content":"YQ=="}]}`,
+		"template without path": `{"subject":"s","template":"welcome"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := m.Invoke(context.TODO(), &bindings.InvokeRequest{
+				Operation: bindings.CreateOperation,
+				Data:      []byte(data),
+				Metadata:  map[string]string{envelopeMetadataKey: "true"},
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadTemplatesEmptyDirectory(t *testing.T) {
+	_, err := loadTemplates(t.TempDir())
+	assert.Error(t, err)
+
+	_, err = loadTemplates(filepath.Join(os.TempDir(), "does-not-exist"))
+	assert.Error(t, err)
+}
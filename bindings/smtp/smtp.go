@@ -36,8 +36,9 @@ const (
 
 // Mailer allows sending of emails using the Simple Mail Transfer Protocol.
 type Mailer struct {
-	metadata Metadata
-	logger   logger.Logger
+	metadata  Metadata
+	templates *templates
+	logger    logger.Logger
 }
 
 // Metadata holds standard email properties.
@@ -53,6 +54,8 @@ type Metadata struct {
 	EmailBCC      string `json:"emailBCC"`
 	Subject       string `json:"subject"`
 	Priority      int    `json:"priority"`
+	// TemplatesPath is the directory of the templates of the envelopes.
+	TemplatesPath string `json:"templatesPath"`
 }
 
 // NewSMTP returns a new smtp binding instance.
@@ -69,6 +72,12 @@ func (s *Mailer) Init(metadata bindings.Metadata) error {
 	}
 	s.metadata = meta
 
+	if meta.TemplatesPath != "" {
+		if s.templates, err = loadTemplates(meta.TemplatesPath); err != nil {
+			return fmt.Errorf("smtp binding error: error loading templates: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -90,7 +99,9 @@ func (s *Mailer) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bind
 	if metadata.EmailTo == "" {
 		return nil, fmt.Errorf("smtp binding error: emailTo property not supplied in configuration- or request-metadata")
 	}
-	if metadata.Subject == "" {
+	// The subject can be set by the envelope.
+	envelope, _ := strconv.ParseBool(req.Metadata[envelopeMetadataKey])
+	if metadata.Subject == "" && !envelope {
 		return nil, fmt.Errorf("smtp binding error: subject property not supplied in configuration- or request-metadata")
 	}
 
@@ -108,9 +119,14 @@ func (s *Mailer) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bind
 	msg.SetHeader("Subject", metadata.Subject)
 	msg.SetHeader("X-priority", strconv.Itoa(metadata.Priority))
 
-	body, err := strconv.Unquote(string(req.Data))
-
-	if err != nil {
+	if envelope {
+		if err = s.compose(msg, req.Data); err != nil {
+			return nil, err
+		}
+		if len(msg.GetHeader("Subject")) == 0 || msg.GetHeader("Subject")[0] == "" {
+			return nil, fmt.Errorf("smtp binding error: subject property not supplied in configuration- or request-metadata, or envelope")
+		}
+	} else if body, err := strconv.Unquote(string(req.Data)); err != nil {
 		// When data arrives over gRPC it's not quoted. Unquoting the original data will result in an error.
 		// Instead of unquoting it we'll just use the raw string as that one's already in the right format.
 
@@ -178,6 +194,7 @@ func (s *Mailer) parseMetadata(meta bindings.Metadata) (Metadata, error) {
 	smtpMeta.EmailBCC = meta.Properties["emailBCC"]
 	smtpMeta.EmailFrom = meta.Properties["emailFrom"]
 	smtpMeta.Subject = meta.Properties["subject"]
+	smtpMeta.TemplatesPath = meta.Properties["templatesPath"]
 	err = smtpMeta.parsePriority(meta.Properties["priority"])
 
 	if err != nil {
@@ -0,0 +1,264 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compression contains the payload compression shared by the pub/sub and binding decorators.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// ContentEncodingKey is the metadata key recording the encoding of a compressed payload.
+	ContentEncodingKey = "contentEncoding"
+	// EncodingKey is the component metadata key enabling compression, set to an encoding.
+	EncodingKey = "compression"
+	// MinSizeKey is the component metadata key of the size in bytes from which payloads are compressed.
+	MinSizeKey = "compressionMinSize"
+	// MaxSizeKey is the component metadata key of the maximum size in bytes of decompressed payloads.
+	MaxSizeKey = "compressionMaxSize"
+	// FramedKey is the component metadata key recording the encoding in a header of the payload too,
+	// for components that do not deliver the metadata messages are published with.
+	FramedKey = "compressionFramed"
+
+	// DefaultMaxSize is the default maximum size of decompressed payloads.
+	DefaultMaxSize = 64 << 20
+
+	// framePrefix starts framed payloads, followed by the encoding and a NUL byte.
+	framePrefix = "\x00dapr-compression:"
+	// maxEncodingLen bounds the search for the end of the encoding in the frame header.
+	maxEncodingLen = 16
+)
+
+// Encoding is a compression algorithm.
+type Encoding string
+
+const (
+	None   Encoding = ""
+	Gzip   Encoding = "gzip"
+	Zstd   Encoding = "zstd"
+	Snappy Encoding = "snappy"
+)
+
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// Settings configures the compression of payloads.
+type Settings struct {
+	Encoding Encoding
+	// MinSize is the size in bytes from which payloads are compressed.
+	MinSize int
+	// MaxSize is the maximum size in bytes of decompressed payloads, protecting against
+	// decompression bombs.
+	MaxSize int
+	// Framed records the encoding in a header of compressed payloads, and decompresses the received
+	// payloads starting with it without the encoding metadata. Only Dapr can read framed payloads.
+	Framed bool
+}
+
+// ParseSettings reads the compression settings of a component. Compression is disabled by default.
+func ParseSettings(props map[string]string) (Settings, error) {
+	s := Settings{MaxSize: DefaultMaxSize}
+
+	switch val := Encoding(props[EncodingKey]); val {
+	case None, "none":
+	case Gzip, Zstd, Snappy:
+		s.Encoding = val
+	default:
+		return s, fmt.Errorf("invalid %s %s, expected none, %s, %s or %s", EncodingKey, val, Gzip, Zstd, Snappy)
+	}
+
+	if val, ok := props[MinSizeKey]; ok && val != "" {
+		minSize, err := strconv.Atoi(val)
+		if err != nil || minSize < 0 {
+			return s, fmt.Errorf("invalid %s %s, expected a positive number of bytes", MinSizeKey, val)
+		}
+		s.MinSize = minSize
+	}
+
+	if val, ok := props[MaxSizeKey]; ok && val != "" {
+		maxSize, err := strconv.Atoi(val)
+		if err != nil || maxSize <= 0 {
+			return s, fmt.Errorf("invalid %s %s, expected a positive number of bytes", MaxSizeKey, val)
+		}
+		s.MaxSize = maxSize
+	}
+
+	if val, ok := props[FramedKey]; ok && val != "" {
+		framed, err := strconv.ParseBool(val)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s %s, expected true or false", FramedKey, val)
+		}
+		s.Framed = framed
+	}
+
+	return s, nil
+}
+
+// Compress compresses data when it is enabled and data is large enough. It returns a copy of metadata
+// recording the encoding, or metadata unchanged if data was not compressed.
+func (s Settings) Compress(data []byte, metadata map[string]string) ([]byte, map[string]string, error) {
+	if s.Encoding == None || len(data) < s.MinSize {
+		return data, metadata, nil
+	}
+	// The payload is already compressed, by another decorator for instance.
+	if metadata[ContentEncodingKey] != "" {
+		return data, metadata, nil
+	}
+	if _, _, ok := parseFrame(data); ok && s.Framed {
+		return data, metadata, nil
+	}
+
+	compressed, err := Encode(s.Encoding, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Framed {
+		framed := make([]byte, 0, len(framePrefix)+len(s.Encoding)+1+len(compressed))
+		framed = append(framed, framePrefix...)
+		framed = append(framed, s.Encoding...)
+		framed = append(framed, 0)
+		compressed = append(framed, compressed...)
+	}
+
+	md := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		md[k] = v
+	}
+	md[ContentEncodingKey] = string(s.Encoding)
+
+	return compressed, md, nil
+}
+
+// Decompress decompresses data if metadata records its encoding, whatever the settings are, or, when
+// framed, if data starts with the header of framed payloads. It returns a copy of metadata without
+// the encoding, or data and metadata unchanged if data was not compressed.
+func (s Settings) Decompress(data []byte, metadata map[string]string) ([]byte, map[string]string, error) {
+	encoding, hasEncoding := metadata[ContentEncodingKey]
+	// The header of framed payloads is removed whenever the encoding metadata is delivered too.
+	if frameEncoding, compressed, ok := parseFrame(data); ok && (s.Framed || (hasEncoding && Encoding(encoding) == frameEncoding)) {
+		encoding, hasEncoding, data = string(frameEncoding), true, compressed
+	}
+	if !hasEncoding {
+		return data, metadata, nil
+	}
+
+	maxSize := s.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	decompressed, err := Decode(Encoding(encoding), data, maxSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	md := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if k != ContentEncodingKey {
+			md[k] = v
+		}
+	}
+
+	return decompressed, md, nil
+}
+
+// parseFrame returns the encoding and the compressed data of a compressed payload.
+func parseFrame(data []byte) (Encoding, []byte, bool) {
+	if !bytes.HasPrefix(data, []byte(framePrefix)) {
+		return None, nil, false
+	}
+	rest := data[len(framePrefix):]
+
+	end := bytes.IndexByte(rest, 0)
+	if end <= 0 || end > maxEncodingLen {
+		return None, nil, false
+	}
+
+	return Encoding(rest[:end]), rest[end+1:], true
+}
+
+// Encode compresses data with encoding.
+func Encode(encoding Encoding, data []byte) ([]byte, error) {
+	switch encoding {
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case Zstd:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	case Snappy:
+		return snappy.Encode(nil, data), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", encoding)
+	}
+}
+
+// Decode decompresses data compressed with encoding, failing if it is larger than maxSize bytes.
+func Decode(encoding Encoding, data []byte, maxSize int) ([]byte, error) {
+	switch encoding {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return readAll(r, maxSize)
+	case Zstd:
+		r, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return readAll(r, maxSize)
+	case Snappy:
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxSize {
+			return nil, fmt.Errorf("decompressed payload larger than %d bytes", maxSize)
+		}
+
+		return snappy.Decode(nil, data)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", encoding)
+	}
+}
+
+// readAll reads r, failing if it is larger than maxSize bytes.
+func readAll(r io.Reader, maxSize int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("decompressed payload larger than %d bytes", maxSize)
+	}
+
+	return data, nil
+}
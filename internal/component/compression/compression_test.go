@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings(map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, Settings{MaxSize: DefaultMaxSize}, s)
+
+	s, err = ParseSettings(map[string]string{EncodingKey: "zstd", MinSizeKey: "1024", MaxSizeKey: "4096", FramedKey: "true"})
+	require.NoError(t, err)
+	assert.Equal(t, Settings{Encoding: Zstd, MinSize: 1024, MaxSize: 4096, Framed: true}, s)
+
+	_, err = ParseSettings(map[string]string{EncodingKey: "lz4"})
+	assert.Error(t, err)
+
+	_, err = ParseSettings(map[string]string{EncodingKey: "gzip", MinSizeKey: "-1"})
+	assert.Error(t, err)
+
+	_, err = ParseSettings(map[string]string{EncodingKey: "gzip", MaxSizeKey: "0"})
+	assert.Error(t, err)
+
+	_, err = ParseSettings(map[string]string{EncodingKey: "gzip", FramedKey: "maybe"})
+	assert.Error(t, err)
+}
+
+func TestCompressDecompress(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"orderId":1,"status":"created"}`), 100)
+
+	for _, encoding := range []Encoding{Gzip, Zstd, Snappy} {
+		t.Run(string(encoding), func(t *testing.T) {
+			s := Settings{Encoding: encoding}
+
+			compressed, md, err := s.Compress(data, map[string]string{"key": "value"})
+			require.NoError(t, err)
+			assert.Less(t, len(compressed), len(data))
+			assert.Equal(t, map[string]string{"key": "value", ContentEncodingKey: string(encoding)}, md)
+
+			// Compressed payloads are not compressed again.
+			again, _, err := s.Compress(compressed, md)
+			require.NoError(t, err)
+			assert.Equal(t, compressed, again)
+
+			// Payloads are decompressed whatever the settings are.
+			decompressed, md, err := Settings{}.Decompress(compressed, md)
+			require.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+			assert.Equal(t, map[string]string{"key": "value"}, md)
+
+			_, _, err = Settings{MaxSize: len(data) - 1}.Decompress(compressed, map[string]string{ContentEncodingKey: string(encoding)})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCompressFramed(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"orderId":1,"status":"created"}`), 100)
+	s := Settings{Encoding: Zstd, Framed: true}
+
+	compressed, md, err := s.Compress(data, nil)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(compressed, []byte(framePrefix+"zstd\x00")))
+	assert.Equal(t, "zstd", md[ContentEncodingKey])
+
+	// Without the metadata, only framed settings read the header.
+	decompressed, _, err := s.Decompress(compressed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+	unchanged, _, err := Settings{}.Decompress(compressed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, compressed, unchanged)
+
+	// With the metadata, the header is removed whatever the settings are.
+	decompressed, _, err = Settings{}.Decompress(compressed, md)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+}
+
+func TestCompressMinSize(t *testing.T) {
+	s := Settings{Encoding: Gzip, MinSize: 10}
+
+	data, md, err := s.Compress([]byte("small"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("small"), data)
+	assert.Empty(t, md)
+}
+
+func TestDecompressUnknownEncoding(t *testing.T) {
+	_, _, err := Settings{}.Decompress([]byte("data"), map[string]string{ContentEncodingKey: "br"})
+	assert.Error(t, err)
+	_, _, err = Settings{Framed: true}.Decompress([]byte(framePrefix+"br\x00data"), nil)
+	assert.Error(t, err)
+
+	for _, data := range []string{"data", framePrefix + "gzip", framePrefix + "\x00data"} {
+		decompressed, _, err := Settings{Framed: true}.Decompress([]byte(data), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(data), decompressed)
+	}
+}
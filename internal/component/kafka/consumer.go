@@ -23,6 +23,7 @@ import (
 	"github.com/Shopify/sarama"
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/retry"
 )
 
@@ -38,45 +39,168 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 		return fmt.Errorf("nil consumer callback")
 	}
 
+	if consumer.k.concurrencyMode == pubsub.Partitioned {
+		return consumer.consumePartitioned(session, claim)
+	}
+
 	b := consumer.k.backOffConfig.NewBackOffWithContext(session.Context())
 	for message := range claim.Messages() {
-		if consumer.k.consumeRetryEnabled {
-			if err := retry.NotifyRecover(func() error {
-				return consumer.doCallback(session, message)
-			}, b, func(err error, d time.Duration) {
-				consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Retrying...", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
-			}, func() {
-				consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
-			}); err != nil {
-				return err
+		consumer.k.recordLag(claim, message)
+		if err := consumer.processMessage(session, message, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// consumePartitioned processes the messages of a claim in parallel, keeping messages
+// that share the same key in order. As messages with different keys complete out of order,
+// an offset is only marked once all the messages before it are processed. A failed message
+// stops the claim so that it is delivered again from the failed offset.
+func (consumer *consumer) consumePartitioned(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	executor := pubsub.NewPartitionedExecutor(consumer.k.partitionedWorkers, 1)
+	// Wait for in-flight messages before the claim is released.
+	defer executor.Close()
+
+	tracker := newOffsetTracker()
+	failed := make(chan error, 1)
+	for {
+		var message *sarama.ConsumerMessage
+		select {
+		case m, ok := <-claim.Messages():
+			if !ok {
+				return nil
 			}
-		} else {
-			_ = consumer.doCallback(session, message)
+			message = m
+		case err := <-failed:
+			return err
+		case <-session.Context().Done():
+			return nil
+		}
+
+		consumer.k.recordLag(claim, message)
+		tracker.add(message.Offset)
+		err := executor.Submit(session.Context(), string(message.Key), func() {
+			b := consumer.k.backOffConfig.NewBackOffWithContext(session.Context())
+			if err := consumer.handleMessage(session, message, b); err != nil {
+				consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]: %v", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+				select {
+				case failed <- err:
+				default:
+				}
+
+				return
+			}
+			if offset, ok := tracker.complete(message.Offset); ok {
+				session.MarkOffset(message.Topic, message.Partition, offset+1, "")
+			}
+		})
+		if err != nil {
+			return err
 		}
 	}
+}
+
+func (consumer *consumer) processMessage(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, b backoff.BackOff) error {
+	err := consumer.handleMessage(session, message, b)
+	if err == nil {
+		session.MarkMessage(message, "")
+	}
+	if consumer.k.consumeRetryEnabled {
+		return err
+	}
 
 	return nil
 }
 
+// handleMessage delivers message to the callback, retrying it when enabled, without marking it.
+func (consumer *consumer) handleMessage(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, b backoff.BackOff) error {
+	if !consumer.k.consumeRetryEnabled {
+		return consumer.doCallback(session, message)
+	}
+
+	return retry.NotifyRecover(func() error {
+		return consumer.doCallback(session, message)
+	}, b, func(err error, d time.Duration) {
+		consumer.k.stats.Redelivered(message.Topic, 1)
+		consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Retrying...", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
+	}, func() {
+		consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
+	})
+}
+
 func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage) error {
 	consumer.k.logger.Debugf("Processing Kafka message: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
 	event := NewEvent{
 		Topic: message.Topic,
 		Data:  message.Value,
 	}
-	err := consumer.callback(session.Context(), &event)
-	if err == nil {
-		session.MarkMessage(message, "")
+	if len(message.Headers) > 0 {
+		event.Metadata = make(map[string]string, len(message.Headers))
+		for _, header := range message.Headers {
+			event.Metadata[string(header.Key)] = string(header.Value)
+		}
 	}
+	done := consumer.k.stats.Processing(message.Topic)
+	err := consumer.callback(session.Context(), &event)
+	done(err)
 
 	return err
 }
 
-func (consumer *consumer) Cleanup(sarama.ConsumerGroupSession) error {
+// offsetTracker tracks the in-flight offsets of a claim, in the order they were received.
+type offsetTracker struct {
+	lock    sync.Mutex
+	offsets []int64
+	done    map[int64]struct{}
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{done: make(map[int64]struct{})}
+}
+
+func (t *offsetTracker) add(offset int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.offsets = append(t.offsets, offset)
+}
+
+// complete records offset as processed and returns the highest offset whose message and all
+// the messages before it are processed, if it moved.
+func (t *offsetTracker) complete(offset int64) (int64, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.done[offset] = struct{}{}
+
+	var (
+		highest int64
+		moved   bool
+	)
+	for len(t.offsets) > 0 {
+		if _, ok := t.done[t.offsets[0]]; !ok {
+			break
+		}
+		highest, moved = t.offsets[0], true
+		delete(t.done, highest)
+		t.offsets = t.offsets[1:]
+	}
+
+	return highest, moved
+}
+
+func (consumer *consumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	// The partitions may be claimed by other consumers after a rebalance.
+	consumer.k.resetLags(session.Claims())
+
 	return nil
 }
 
-func (consumer *consumer) Setup(sarama.ConsumerGroupSession) error {
+func (consumer *consumer) Setup(session sarama.ConsumerGroupSession) error {
+	consumer.k.seekStartPositions(session)
+
 	consumer.once.Do(func() {
 		close(consumer.ready)
 	})
@@ -84,6 +208,55 @@ func (consumer *consumer) Setup(sarama.ConsumerGroupSession) error {
 	return nil
 }
 
+// recordLag records the lag of the partition of message, the messages of the claim after it.
+func (k *Kafka) recordLag(claim sarama.ConsumerGroupClaim, message *sarama.ConsumerMessage) {
+	lag := claim.HighWaterMarkOffset() - message.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+
+	k.partitionLagsLock.Lock()
+	defer k.partitionLagsLock.Unlock()
+
+	if k.partitionLags == nil {
+		k.partitionLags = make(map[string]map[int32]int64)
+	}
+	if k.partitionLags[message.Topic] == nil {
+		k.partitionLags[message.Topic] = make(map[int32]int64)
+	}
+	k.partitionLags[message.Topic][message.Partition] = lag
+
+	var total int64
+	for _, l := range k.partitionLags[message.Topic] {
+		total += l
+	}
+	k.stats.SetLag(message.Topic, total)
+}
+
+// resetLags forgets the lag of the released partitions.
+func (k *Kafka) resetLags(claims map[string][]int32) {
+	k.partitionLagsLock.Lock()
+	defer k.partitionLagsLock.Unlock()
+
+	for topic, partitions := range claims {
+		for _, partition := range partitions {
+			delete(k.partitionLags[topic], partition)
+		}
+
+		var total int64
+		for _, l := range k.partitionLags[topic] {
+			total += l
+		}
+		k.stats.SetLag(topic, total)
+	}
+}
+
+// Stats returns the statistics of the subscribed topics. The lag of a topic is the number of messages
+// after the last message received from each of its partitions claimed by this consumer.
+func (k *Kafka) Stats() map[string]pubsub.TopicStats {
+	return k.stats.Stats()
+}
+
 // Subscribe to topic in the Kafka cluster
 // This call cannot block like its sibling in bindings/kafka because of where this is invoked in runtime.go.
 func (k *Kafka) Subscribe(topics []string, _ map[string]string, handler EventHandler) error {
@@ -104,6 +277,10 @@ func (k *Kafka) Subscribe(topics []string, _ map[string]string, handler EventHan
 	ctx, cancel := context.WithCancel(context.Background())
 	k.cancel = cancel
 
+	for _, topic := range topics {
+		k.stats.Subscribed(topic)
+	}
+
 	ready := make(chan bool)
 	k.consumer = consumer{
 		k:        k,
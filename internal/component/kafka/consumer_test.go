@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	highWaterMark int64
+	messages      chan *sarama.ConsumerMessage
+}
+
+func (c fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+func (c fakeClaim) HighWaterMarkOffset() int64 {
+	return c.highWaterMark
+}
+
+func TestRecordLag(t *testing.T) {
+	k := NewKafka(nil)
+
+	k.recordLag(fakeClaim{highWaterMark: 10}, &sarama.ConsumerMessage{Topic: "orders", Partition: 0, Offset: 4})
+	k.recordLag(fakeClaim{highWaterMark: 3}, &sarama.ConsumerMessage{Topic: "orders", Partition: 1, Offset: 2})
+	assert.Equal(t, int64(5), k.Stats()["orders"].Lag)
+
+	k.recordLag(fakeClaim{highWaterMark: 10}, &sarama.ConsumerMessage{Topic: "orders", Partition: 0, Offset: 9})
+	assert.Equal(t, int64(0), k.Stats()["orders"].Lag)
+
+	k.recordLag(fakeClaim{highWaterMark: 20}, &sarama.ConsumerMessage{Topic: "orders", Partition: 1, Offset: 9})
+	k.resetLags(map[string][]int32{"orders": {0}})
+	assert.Equal(t, int64(10), k.Stats()["orders"].Lag)
+	k.resetLags(map[string][]int32{"orders": {1}})
+	assert.Equal(t, int64(0), k.Stats()["orders"].Lag)
+}
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+	for _, offset := range []int64{4, 5, 7, 8} {
+		tracker.add(offset)
+	}
+
+	_, ok := tracker.complete(7)
+	assert.False(t, ok)
+	_, ok = tracker.complete(5)
+	assert.False(t, ok)
+
+	offset, ok := tracker.complete(4)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), offset)
+
+	offset, ok = tracker.complete(8)
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), offset)
+}
+
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	lock   sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.marked = append(s.marked, offset)
+}
+
+func TestConsumePartitioned(t *testing.T) {
+	k := NewKafka(logger.NewLogger("test"))
+	k.concurrencyMode = pubsub.Partitioned
+	k.partitionedWorkers = 4
+
+	// The message with key "a" at offset 1 waits for the message with key "b" at offset 2,
+	// which fails: offset 2 and the ones after it are not marked.
+	release := make(chan struct{})
+	consumer := &consumer{k: k, callback: func(ctx context.Context, event *NewEvent) error {
+		switch string(event.Data) {
+		case "1":
+			<-release
+		case "2":
+			close(release)
+
+			return errors.New("failed")
+		}
+
+		return nil
+	}}
+
+	claim := fakeClaim{highWaterMark: 4, messages: make(chan *sarama.ConsumerMessage, 4)}
+	for i, key := range []string{"a", "b", "c"} {
+		claim.messages <- &sarama.ConsumerMessage{Topic: "orders", Offset: int64(i + 1), Key: []byte(key), Value: []byte{byte('1' + i)}}
+	}
+
+	session := &fakeSession{}
+	assert.EqualError(t, consumer.ConsumeClaim(session, claim), "failed")
+	assert.Equal(t, []int64{2}, session.marked)
+}
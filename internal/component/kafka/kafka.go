@@ -15,10 +15,12 @@ package kafka
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
 
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
 )
@@ -45,6 +47,16 @@ type Kafka struct {
 	DefaultConsumeRetryEnabled bool
 	consumeRetryEnabled        bool
 	consumeRetryInterval       time.Duration
+	concurrencyMode            pubsub.ConcurrencyMode
+	partitionedWorkers         int
+
+	startPositions     map[string]*pubsub.StartPosition
+	startPositionsLock sync.Mutex
+
+	stats pubsub.StatsRecorder
+	// partitionLags holds the lag of the claimed partitions of each topic.
+	partitionLags     map[string]map[int32]int64
+	partitionLagsLock sync.Mutex
 }
 
 func NewKafka(logger logger.Logger) *Kafka {
@@ -121,6 +133,8 @@ func (k *Kafka) Init(metadata map[string]string) error {
 	}
 	k.consumeRetryEnabled = meta.ConsumeRetryEnabled
 	k.consumeRetryInterval = meta.ConsumeRetryInterval
+	k.concurrencyMode = meta.ConcurrencyMode
+	k.partitionedWorkers = meta.PartitionedWorkers
 
 	k.logger.Debug("Kafka message bus initialization complete")
 
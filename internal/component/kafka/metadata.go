@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+
+	"github.com/dapr/components-contrib/pubsub"
 )
 
 const (
@@ -59,6 +61,8 @@ type kafkaMetadata struct {
 	ConsumeRetryEnabled  bool
 	ConsumeRetryInterval time.Duration
 	Version              sarama.KafkaVersion
+	ConcurrencyMode      pubsub.ConcurrencyMode
+	PartitionedWorkers   int
 }
 
 // upgradeMetadata updates metadata properties based on deprecated usage.
@@ -275,5 +279,17 @@ func (k *Kafka) getKafkaMetadata(metadata map[string]string) (*kafkaMetadata, er
 		meta.Version = sarama.V2_0_0_0
 	}
 
+	concurrencyMode, err := pubsub.Concurrency(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: %w", err)
+	}
+	meta.ConcurrencyMode = concurrencyMode
+
+	partitionedWorkers, err := pubsub.PartitionedWorkers(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: %w", err)
+	}
+	meta.PartitionedWorkers = partitionedWorkers
+
 	return &meta, nil
 }
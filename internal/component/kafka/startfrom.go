@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// offsetGetter is the part of sarama.Client used to resolve start positions.
+type offsetGetter interface {
+	GetOffset(topic string, partitionID int32, time int64) (int64, error)
+}
+
+// SetStartPosition makes the next consumer group session seek the partitions
+// of topic to position before consuming.
+func (k *Kafka) SetStartPosition(topic string, position *pubsub.StartPosition) {
+	k.startPositionsLock.Lock()
+	defer k.startPositionsLock.Unlock()
+
+	if k.startPositions == nil {
+		k.startPositions = make(map[string]*pubsub.StartPosition)
+	}
+	k.startPositions[topic] = position
+}
+
+// seekStartPositions moves the claimed partitions of the topics with a pending start position,
+// whether the consumer group committed offsets for them or not. Each start position is applied once
+// per subscribe.
+func (k *Kafka) seekStartPositions(session sarama.ConsumerGroupSession) {
+	k.startPositionsLock.Lock()
+	defer k.startPositionsLock.Unlock()
+
+	if len(k.startPositions) == 0 {
+		return
+	}
+
+	client, err := sarama.NewClient(k.brokers, k.config)
+	if err != nil {
+		k.logger.Errorf("kafka: error creating client to seek start positions: %v", err)
+
+		return
+	}
+	defer client.Close()
+
+	for topic, partitions := range session.Claims() {
+		position, ok := k.startPositions[topic]
+		if !ok {
+			continue
+		}
+
+		for _, partition := range partitions {
+			offset, err := startOffset(client, topic, partition, position)
+			if err != nil {
+				k.logger.Errorf("kafka: error resolving start position of %s/%d: %v", topic, partition, err)
+
+				continue
+			}
+
+			k.logger.Infof("kafka: seeking %s/%d to offset %d", topic, partition, offset)
+			// ResetOffset only moves backwards and MarkOffset only moves forward.
+			session.ResetOffset(topic, partition, offset, "")
+			session.MarkOffset(topic, partition, offset, "")
+		}
+
+		delete(k.startPositions, topic)
+	}
+}
+
+// startOffset resolves the offset of a partition matching position.
+func startOffset(client offsetGetter, topic string, partition int32, position *pubsub.StartPosition) (int64, error) {
+	switch position.Kind {
+	case pubsub.StartFromEarliest:
+		return client.GetOffset(topic, partition, sarama.OffsetOldest)
+	case pubsub.StartFromLatest:
+		return client.GetOffset(topic, partition, sarama.OffsetNewest)
+	case pubsub.StartFromTime:
+		offset, err := client.GetOffset(topic, partition, position.Time.UnixMilli())
+		if err != nil {
+			return 0, err
+		}
+		if offset < 0 {
+			// No message was produced after that time.
+			return client.GetOffset(topic, partition, sarama.OffsetNewest)
+		}
+
+		return offset, nil
+	case pubsub.StartFromSequence:
+		return int64(position.Sequence), nil
+	default:
+		return 0, fmt.Errorf("unknown start position %s", position.Kind)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+type fakeOffsetGetter map[int64]int64
+
+func (f fakeOffsetGetter) GetOffset(topic string, partitionID int32, time int64) (int64, error) {
+	return f[time], nil
+}
+
+func TestStartOffset(t *testing.T) {
+	at := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	client := fakeOffsetGetter{
+		sarama.OffsetOldest: 3,
+		sarama.OffsetNewest: 100,
+		at.UnixMilli():      42,
+	}
+
+	t.Run("earliest", func(t *testing.T) {
+		offset, err := startOffset(client, "topic", 0, &pubsub.StartPosition{Kind: pubsub.StartFromEarliest})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), offset)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		offset, err := startOffset(client, "topic", 0, &pubsub.StartPosition{Kind: pubsub.StartFromLatest})
+		require.NoError(t, err)
+		require.Equal(t, int64(100), offset)
+	})
+
+	t.Run("time", func(t *testing.T) {
+		offset, err := startOffset(client, "topic", 0, &pubsub.StartPosition{Kind: pubsub.StartFromTime, Time: at})
+		require.NoError(t, err)
+		require.Equal(t, int64(42), offset)
+	})
+
+	t.Run("time after the last message", func(t *testing.T) {
+		client := fakeOffsetGetter{sarama.OffsetNewest: 100, at.UnixMilli(): -1}
+		offset, err := startOffset(client, "topic", 0, &pubsub.StartPosition{Kind: pubsub.StartFromTime, Time: at})
+		require.NoError(t, err)
+		require.Equal(t, int64(100), offset)
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		offset, err := startOffset(client, "topic", 0, &pubsub.StartPosition{Kind: pubsub.StartFromSequence, Sequence: 7})
+		require.NoError(t, err)
+		require.Equal(t, int64(7), offset)
+	})
+}
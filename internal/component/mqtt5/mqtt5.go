@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mqtt5 contains the MQTT 5 support shared by the MQTT pub/sub and binding.
+package mqtt5
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// ProtocolVersionKey is the metadata key selecting the MQTT protocol version, 3 (3.1.1) or 5.
+	ProtocolVersionKey = "protocolVersion"
+
+	// Metadata keys mapped to the properties of MQTT 5 messages instead of user properties.
+	ContentTypeMetadata     = "contentType"
+	ResponseTopicMetadata   = "responseTopic"
+	CorrelationDataMetadata = "correlationData"
+
+	// SharedSubscriptionPrefix starts the topic of shared subscriptions, `$share/<group>/<filter>`.
+	SharedSubscriptionPrefix = "$share/"
+
+	// reasonCodeFailure is the smallest reason code reporting a failure.
+	reasonCodeFailure = 0x80
+	// sessionExpiryNever keeps the session of a client that does not start clean.
+	sessionExpiryNever = math.MaxUint32
+)
+
+// ParseProtocolVersion returns the MQTT protocol version set in metadata, 3 by default.
+func ParseProtocolVersion(props map[string]string) (int, error) {
+	switch val := props[ProtocolVersionKey]; val {
+	case "", "3", "3.1.1", "4":
+		return 3, nil
+	case "5", "5.0":
+		return 5, nil
+	default:
+		return 0, fmt.Errorf("invalid %s %s, expected 3 or 5", ProtocolVersionKey, val)
+	}
+}
+
+// PublishProperties maps the metadata of a message to MQTT 5 properties. The content type,
+// response topic, correlation data and time to live become the matching properties, every
+// other key becomes a user property.
+func PublishProperties(metadata map[string]string, contentType *string) (*paho.PublishProperties, error) {
+	props := &paho.PublishProperties{}
+
+	ttl, ok, err := contrib_metadata.TryGetTTL(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		expiry := uint32(ttl.Seconds())
+		props.MessageExpiry = &expiry
+	}
+
+	for k, v := range metadata {
+		switch k {
+		case ContentTypeMetadata:
+			props.ContentType = v
+		case ResponseTopicMetadata:
+			props.ResponseTopic = v
+		case CorrelationDataMetadata:
+			props.CorrelationData = []byte(v)
+		case contrib_metadata.TTLMetadataKey:
+		default:
+			props.User.Add(k, v)
+		}
+	}
+
+	if contentType != nil && *contentType != "" {
+		props.ContentType = *contentType
+	}
+
+	return props, nil
+}
+
+// MessageMetadata maps the MQTT 5 properties of a received message to metadata,
+// the reverse of PublishProperties.
+func MessageMetadata(p *paho.Publish) map[string]string {
+	metadata := map[string]string{}
+	if p.Properties == nil {
+		return metadata
+	}
+
+	for _, prop := range p.Properties.User {
+		metadata[prop.Key] = prop.Value
+	}
+	if p.Properties.ContentType != "" {
+		metadata[ContentTypeMetadata] = p.Properties.ContentType
+	}
+	if p.Properties.ResponseTopic != "" {
+		metadata[ResponseTopicMetadata] = p.Properties.ResponseTopic
+	}
+	if len(p.Properties.CorrelationData) > 0 {
+		metadata[CorrelationDataMetadata] = string(p.Properties.CorrelationData)
+	}
+	if p.Properties.MessageExpiry != nil {
+		metadata[contrib_metadata.TTLMetadataKey] = strconv.FormatUint(uint64(*p.Properties.MessageExpiry), 10)
+	}
+
+	return metadata
+}
+
+// PublishError returns err, or an error carrying the reason code of resp when the broker refused the message.
+func PublishError(resp *paho.PublishResponse, err error) error {
+	if resp == nil || resp.ReasonCode < reasonCodeFailure {
+		return err
+	}
+
+	reason := ""
+	if resp.Properties != nil {
+		reason = resp.Properties.ReasonString
+	}
+
+	return fmt.Errorf("message refused with reason code 0x%02x %s", resp.ReasonCode, reason)
+}
+
+// RouteFilter returns the filter matching the topics of messages received for a subscription.
+// It removes the prefix of shared subscriptions which the router does not understand.
+func RouteFilter(topic string) string {
+	if !strings.HasPrefix(topic, SharedSubscriptionPrefix) {
+		return topic
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(topic, SharedSubscriptionPrefix), "/", 2)
+	if len(parts) != 2 {
+		return topic
+	}
+
+	return parts[1]
+}
+
+// ConnectionConfig configures an MQTT 5 connection.
+type ConnectionConfig struct {
+	URL          string
+	ClientID     string
+	CleanSession bool
+	TLSConfig    *tls.Config
+	// Router dispatches the received messages, it is kept across reconnections.
+	Router paho.Router
+	// OnConnectionUp is called every time the connection is established, to subscribe again.
+	OnConnectionUp func(*autopaho.ConnectionManager)
+	Logger         logger.Logger
+}
+
+// Connect opens a connection that reconnects automatically, and waits until it is up or ctx is done.
+func Connect(ctx context.Context, cfg ConnectionConfig) (*autopaho.ConnectionManager, error) {
+	uri, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCfg := autopaho.ClientConfig{
+		BrokerUrls: []*url.URL{uri},
+		TlsCfg:     cfg.TLSConfig,
+		KeepAlive:  30,
+		OnConnectionUp: func(cm *autopaho.ConnectionManager, _ *paho.Connack) {
+			cfg.Logger.Debugf("mqtt connection of %s is up", cfg.ClientID)
+			if cfg.OnConnectionUp != nil {
+				cfg.OnConnectionUp(cm)
+			}
+		},
+		OnConnectError: func(err error) {
+			cfg.Logger.Warnf("mqtt connection of %s failed: %s", cfg.ClientID, err)
+		},
+		ClientConfig: paho.ClientConfig{
+			ClientID: cfg.ClientID,
+			Router:   cfg.Router,
+			OnClientError: func(err error) {
+				cfg.Logger.Errorf("mqtt client %s error: %s", cfg.ClientID, err)
+			},
+			OnServerDisconnect: func(d *paho.Disconnect) {
+				cfg.Logger.Warnf("mqtt client %s disconnected by the broker with reason code 0x%02x", cfg.ClientID, d.ReasonCode)
+			},
+		},
+	}
+	if cfg.Router == nil {
+		clientCfg.ClientConfig.Router = paho.NewSingleHandlerRouter(func(*paho.Publish) {})
+	}
+
+	password, _ := uri.User.Password()
+	clientCfg.SetUsernamePassword(uri.User.Username(), []byte(password))
+	clientCfg.SetConnectPacketConfigurator(func(c *paho.Connect) *paho.Connect {
+		c.CleanStart = cfg.CleanSession
+		if !cfg.CleanSession {
+			expiry := uint32(sessionExpiryNever)
+			c.Properties = &paho.ConnectProperties{SessionExpiryInterval: &expiry}
+		}
+
+		return c
+	})
+
+	// The connection manager lives until it is disconnected, not until ctx is done.
+	cm, err := autopaho.NewConnection(context.Background(), clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = cm.AwaitConnection(ctx); err != nil {
+		_ = cm.Disconnect(context.Background())
+
+		return nil, err
+	}
+
+	return cm, nil
+}
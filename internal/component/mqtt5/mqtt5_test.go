@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt5
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProtocolVersion(t *testing.T) {
+	for val, expected := range map[string]int{"": 3, "3": 3, "3.1.1": 3, "5": 5} {
+		version, err := ParseProtocolVersion(map[string]string{ProtocolVersionKey: val})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, version, val)
+	}
+
+	_, err := ParseProtocolVersion(map[string]string{ProtocolVersionKey: "2"})
+	assert.Error(t, err)
+}
+
+func TestPublishProperties(t *testing.T) {
+	contentType := "application/json"
+	props, err := PublishProperties(map[string]string{
+		"traceid":           "123",
+		"ttlInSeconds":      "60",
+		"responseTopic":     "replies/1",
+		"correlationData":   "abc",
+		ContentTypeMetadata: "text/plain",
+	}, &contentType)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "application/json", props.ContentType)
+	assert.Equal(t, "replies/1", props.ResponseTopic)
+	assert.Equal(t, []byte("abc"), props.CorrelationData)
+	assert.Equal(t, uint32(60), *props.MessageExpiry)
+	assert.Equal(t, paho.UserProperties{{Key: "traceid", Value: "123"}}, props.User)
+
+	_, err = PublishProperties(map[string]string{"ttlInSeconds": "forever"}, nil)
+	assert.Error(t, err)
+}
+
+func TestMessageMetadata(t *testing.T) {
+	expiry := uint32(30)
+	metadata := MessageMetadata(&paho.Publish{
+		Properties: &paho.PublishProperties{
+			ContentType:     "text/plain",
+			ResponseTopic:   "replies/1",
+			CorrelationData: []byte("abc"),
+			MessageExpiry:   &expiry,
+			User:            paho.UserProperties{{Key: "traceid", Value: "123"}},
+		},
+	})
+
+	assert.Equal(t, map[string]string{
+		"contentType":     "text/plain",
+		"responseTopic":   "replies/1",
+		"correlationData": "abc",
+		"ttlInSeconds":    "30",
+		"traceid":         "123",
+	}, metadata)
+	assert.Empty(t, MessageMetadata(&paho.Publish{}))
+}
+
+func TestPublishError(t *testing.T) {
+	assert.NoError(t, PublishError(nil, nil))
+	assert.NoError(t, PublishError(&paho.PublishResponse{ReasonCode: 0x10}, nil))
+
+	err := errors.New("timeout")
+	assert.Equal(t, err, PublishError(nil, err))
+
+	err = PublishError(&paho.PublishResponse{
+		ReasonCode: 0x87,
+		Properties: &paho.PublishResponseProperties{ReasonString: "not authorized"},
+	}, nil)
+	assert.EqualError(t, err, "message refused with reason code 0x87 not authorized")
+}
+
+func TestRouteFilter(t *testing.T) {
+	assert.Equal(t, "fleet/#", RouteFilter("fleet/#"))
+	assert.Equal(t, "fleet/#", RouteFilter("$share/group/fleet/#"))
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nats contains the NATS connection handling shared by the NATS components.
+package nats
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+)
+
+// Auth holds the credentials of a NATS connection, a user JWT and the seed key signing the server nonce.
+type Auth struct {
+	JWT     string
+	SeedKey string
+}
+
+// ParseAuth reads the `jwt` and `seedKey` metadata, which are set together or not at all.
+func ParseAuth(props map[string]string) (Auth, error) {
+	a := Auth{
+		JWT:     props["jwt"],
+		SeedKey: props["seedKey"],
+	}
+
+	if a.JWT != "" && a.SeedKey == "" {
+		return Auth{}, fmt.Errorf("missing seed key")
+	}
+
+	if a.JWT == "" && a.SeedKey != "" {
+		return Auth{}, fmt.Errorf("missing jwt")
+	}
+
+	return a, nil
+}
+
+// Options returns the connection options authenticating with the credentials, if any.
+func (a Auth) Options() []nats.Option {
+	if a.JWT == "" || a.SeedKey == "" {
+		return nil
+	}
+
+	return []nats.Option{nats.UserJWT(func() (string, error) {
+		return a.JWT, nil
+	}, func(nonce []byte) ([]byte, error) {
+		return SigHandler(a.SeedKey, nonce)
+	})}
+}
+
+// SigHandler handles nats signature request for challenge response authentication.
+func SigHandler(seedKey string, nonce []byte) ([]byte, error) {
+	kp, err := nkeys.FromSeed([]byte(seedKey))
+	if err != nil {
+		return nil, err
+	}
+	// Wipe our key on exit.
+	defer kp.Wipe()
+
+	sig, _ := kp.Sign(nonce)
+	return sig, nil
+}
+
+// ValidatePublishSubject checks that subject contains no wildcard, which only subscriptions accept.
+func ValidatePublishSubject(subject string) error {
+	if subject == "" {
+		return fmt.Errorf("missing subject")
+	}
+
+	for _, token := range strings.Split(subject, ".") {
+		if token == "*" || token == ">" {
+			return fmt.Errorf("cannot publish to subject %s with wildcards", subject)
+		}
+	}
+
+	return nil
+}
+
+// Header converts metadata to the headers of a message.
+func Header(metadata map[string]string) nats.Header {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	h := make(nats.Header, len(metadata))
+	for k, v := range metadata {
+		h.Set(k, v)
+	}
+
+	return h
+}
+
+// Metadata converts the headers of a message to metadata, keeping the first value of each header.
+func Metadata(h nats.Header) map[string]string {
+	metadata := make(map[string]string, len(h))
+	for k, v := range h {
+		if len(v) > 0 {
+			metadata[k] = v[0]
+		}
+	}
+
+	return metadata
+}
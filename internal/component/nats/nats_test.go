@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuth(t *testing.T) {
+	a, err := ParseAuth(map[string]string{})
+	require.NoError(t, err)
+	assert.Empty(t, a.Options())
+
+	a, err = ParseAuth(map[string]string{"jwt": "token", "seedKey": "seed"})
+	require.NoError(t, err)
+	assert.Len(t, a.Options(), 1)
+
+	_, err = ParseAuth(map[string]string{"jwt": "token"})
+	assert.EqualError(t, err, "missing seed key")
+
+	_, err = ParseAuth(map[string]string{"seedKey": "seed"})
+	assert.EqualError(t, err, "missing jwt")
+}
+
+func TestValidatePublishSubject(t *testing.T) {
+	assert.NoError(t, ValidatePublishSubject("orders.created"))
+	assert.NoError(t, ValidatePublishSubject("orders.*created"))
+	assert.Error(t, ValidatePublishSubject("orders.*"))
+	assert.Error(t, ValidatePublishSubject("orders.>"))
+	assert.Error(t, ValidatePublishSubject(""))
+}
+
+func TestHeaderMetadata(t *testing.T) {
+	assert.Nil(t, Header(nil))
+
+	metadata := map[string]string{"traceparent": "00-1", "Content-Type": "text/plain"}
+	assert.Equal(t, metadata, Metadata(Header(metadata)))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sql contains the parameter handling shared by the SQL database bindings.
+package sql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ParamsKey is the request metadata key of the positional parameters of a statement, a JSON array.
+const ParamsKey = "params"
+
+// Statement is a SQL statement with its positional parameters.
+type Statement struct {
+	SQL    string        `json:"sql"`
+	Params []interface{} `json:"params,omitempty"`
+}
+
+// ParseParams returns the positional parameters encoded in val as a JSON array, none if val is empty.
+// Integers are returned as int64 and other numbers as float64.
+func ParseParams(val string) ([]interface{}, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	var params []interface{}
+	if err := decode([]byte(val), &params); err != nil {
+		return nil, fmt.Errorf("invalid %s, expected a JSON array: %w", ParamsKey, err)
+	}
+
+	return convertNumbers(params), nil
+}
+
+// ParseStatements returns the statements encoded in data as a JSON array of objects
+// with the `sql` and `params` fields.
+func ParseStatements(data []byte) ([]Statement, error) {
+	var statements []Statement
+	if err := decode(data, &statements); err != nil {
+		return nil, fmt.Errorf("invalid statements, expected a JSON array of objects with sql and params: %w", err)
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("no statements")
+	}
+
+	for i := range statements {
+		if statements[i].SQL == "" {
+			return nil, fmt.Errorf("statement %d has no sql", i)
+		}
+		statements[i].Params = convertNumbers(statements[i].Params)
+	}
+
+	return statements, nil
+}
+
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
+
+// convertNumbers replaces the JSON numbers of params with the Go numbers drivers accept.
+func convertNumbers(params []interface{}) []interface{} {
+	for i, p := range params {
+		n, ok := p.(json.Number)
+		if !ok {
+			continue
+		}
+		if v, err := n.Int64(); err == nil {
+			params[i] = v
+		} else if v, err := n.Float64(); err == nil {
+			params[i] = v
+		} else {
+			params[i] = n.String()
+		}
+	}
+
+	return params
+}
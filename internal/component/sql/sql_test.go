@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams(`[1, 9007199254740993, 1.5, "one", true, null]`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), int64(9007199254740993), 1.5, "one", true, nil}, params)
+
+	params, err = ParseParams("")
+	require.NoError(t, err)
+	assert.Nil(t, params)
+
+	_, err = ParseParams(`{"id": 1}`)
+	assert.Error(t, err)
+}
+
+func TestParseStatements(t *testing.T) {
+	statements, err := ParseStatements([]byte(`[
+		{"sql": "INSERT INTO foo (id) VALUES ($1)", "params": [1]},
+		{"sql": "DELETE FROM bar"}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Statement{
+		{SQL: "INSERT INTO foo (id) VALUES ($1)", Params: []interface{}{int64(1)}},
+		{SQL: "DELETE FROM bar"},
+	}, statements)
+
+	_, err = ParseStatements([]byte(`[]`))
+	assert.Error(t, err)
+	_, err = ParseStatements([]byte(`[{"params": [1]}]`))
+	assert.Error(t, err)
+	_, err = ParseStatements([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -37,6 +37,12 @@ const (
 
 	// QueryIndexName defines the metadata key for the name of query indexing schema (for redis).
 	QueryIndexName = "queryIndexName"
+
+	// DeliverAtMetadataKey defines the metadata key for the time (RFC3339) at which a message should be delivered.
+	DeliverAtMetadataKey = "deliverAt"
+
+	// DelaySecondsMetadataKey defines the metadata key for delaying the delivery of a message (in seconds).
+	DelaySecondsMetadataKey = "delaySeconds"
 )
 
 // TryGetTTL tries to get the ttl as a time.Duration value for pubsub, binding and any other building block.
@@ -63,6 +69,34 @@ func TryGetTTL(props map[string]string) (time.Duration, bool, error) {
 	return 0, false, nil
 }
 
+// TryGetDeliverAt tries to get the time at which a message should be delivered, from either
+// deliverAt or delaySeconds. deliverAt takes precedence when both are set.
+func TryGetDeliverAt(props map[string]string, now time.Time) (time.Time, bool, error) {
+	if val, ok := props[DeliverAtMetadataKey]; ok && val != "" {
+		deliverAt, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(err, "%s value must be a valid RFC3339 time: actual is '%s'", DeliverAtMetadataKey, val)
+		}
+
+		return deliverAt, true, nil
+	}
+
+	if val, ok := props[DelaySecondsMetadataKey]; ok && val != "" {
+		delay, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(err, "%s value must be a valid integer: actual is '%s'", DelaySecondsMetadataKey, val)
+		}
+
+		if delay < 0 {
+			return time.Time{}, false, fmt.Errorf("%s value must not be negative: actual is %d", DelaySecondsMetadataKey, delay)
+		}
+
+		return now.Add(time.Duration(delay) * time.Second), true, nil
+	}
+
+	return time.Time{}, false, nil
+}
+
 // TryGetPriority tries to get the priority for binding and any other building block.
 func TryGetPriority(props map[string]string) (uint8, bool, error) {
 	if val, ok := props[PriorityMetadataKey]; ok && val != "" {
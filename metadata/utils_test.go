@@ -15,6 +15,7 @@ package metadata
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -91,3 +92,52 @@ func TestTryGetContentType(t *testing.T) {
 		assert.Equal(t, true, ok)
 	})
 }
+
+func TestTryGetDeliverAt(t *testing.T) {
+	now := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Metadata without delivery time", func(t *testing.T) {
+		_, ok, err := TryGetDeliverAt(map[string]string{}, now)
+
+		assert.Equal(t, false, ok)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Metadata with deliverAt", func(t *testing.T) {
+		val, ok, err := TryGetDeliverAt(map[string]string{
+			"deliverAt": "2022-05-01T11:00:00Z",
+		}, now)
+
+		assert.Equal(t, true, ok)
+		assert.Nil(t, err)
+		assert.Equal(t, now.Add(time.Hour), val)
+	})
+
+	t.Run("Metadata with delaySeconds", func(t *testing.T) {
+		val, ok, err := TryGetDeliverAt(map[string]string{
+			"delaySeconds": "30",
+		}, now)
+
+		assert.Equal(t, true, ok)
+		assert.Nil(t, err)
+		assert.Equal(t, now.Add(30*time.Second), val)
+	})
+
+	t.Run("Metadata with bad deliverAt", func(t *testing.T) {
+		_, ok, err := TryGetDeliverAt(map[string]string{
+			"deliverAt": "tomorrow",
+		}, now)
+
+		assert.Equal(t, false, ok)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Metadata with negative delaySeconds", func(t *testing.T) {
+		_, ok, err := TryGetDeliverAt(map[string]string{
+			"delaySeconds": "-1",
+		}, now)
+
+		assert.Equal(t, false, ok)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,469 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package amqp implements a pub/sub over AMQP 1.0 brokers, like ActiveMQ Artemis,
+// Qpid or Azure Service Bus.
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
+)
+
+const (
+	errorMessagePrefix = "amqp pub/sub error:"
+	logMessagePrefix   = "amqp pub/sub:"
+
+	defaultPublishTimeout = 10 * time.Second
+	defaultSettleTimeout  = 10 * time.Second
+)
+
+// receiver is the part of amqp.Receiver used to settle messages.
+type receiver interface {
+	AcceptMessage(ctx context.Context, msg *amqp.Message) error
+	RejectMessage(ctx context.Context, msg *amqp.Message, e *amqp.Error) error
+	ModifyMessage(ctx context.Context, msg *amqp.Message, deliveryFailed, undeliverableHere bool, messageAnnotations amqp.Annotations) error
+}
+
+type amqpPubSub struct {
+	metadata      *metadata
+	backOffConfig retry.Config
+	logger        logger.Logger
+
+	// connectionLock guards the connection and the senders created from it.
+	connectionLock sync.Mutex
+	client         *amqp.Client
+	session        *amqp.Session
+	senders        map[string]*amqp.Sender
+	// connectionCount identifies the connection, it is incremented on every reconnection.
+	connectionCount int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAMQP returns a new AMQP 1.0 pub/sub.
+func NewAMQP(logger logger.Logger) pubsub.PubSub {
+	return &amqpPubSub{
+		logger:  logger,
+		senders: make(map[string]*amqp.Sender),
+	}
+}
+
+// Init parses the metadata and connects to the broker.
+func (a *amqpPubSub) Init(metadata pubsub.Metadata) error {
+	meta, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	// Default retry configuration is used if no backOff properties are set.
+	a.backOffConfig = retry.DefaultConfigWithNoRetry()
+	if err = retry.DecodeConfigWithPrefix(&a.backOffConfig, metadata.Properties, "backOff"); err != nil {
+		return err
+	}
+
+	a.metadata = meta
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+
+	a.connectionLock.Lock()
+	defer a.connectionLock.Unlock()
+
+	return a.connect()
+}
+
+// connect opens the connection and its session. The caller holds connectionLock.
+func (a *amqpPubSub) connect() error {
+	opts, err := a.connectionOptions()
+	if err != nil {
+		return fmt.Errorf("%s %s", errorMessagePrefix, err)
+	}
+
+	// Credentials are passed as options, go-amqp would otherwise always use those of the url.
+	uri, _ := url.Parse(a.metadata.url)
+	uri.User = nil
+
+	client, err := amqp.Dial(uri.String(), opts...)
+	if err != nil {
+		return fmt.Errorf("%s error connecting to %s: %s", errorMessagePrefix, uri.Redacted(), err)
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+
+		return fmt.Errorf("%s error creating session: %s", errorMessagePrefix, err)
+	}
+
+	a.client = client
+	a.session = session
+	a.senders = make(map[string]*amqp.Sender)
+	a.connectionCount++
+	a.logger.Infof("%s connected to %s", logMessagePrefix, uri.Redacted())
+
+	return nil
+}
+
+func (a *amqpPubSub) connectionOptions() ([]amqp.ConnOption, error) {
+	var opts []amqp.ConnOption
+
+	switch a.metadata.saslMechanism {
+	case saslPlain:
+		opts = append(opts, amqp.ConnSASLPlain(a.metadata.username, a.metadata.password))
+	case saslExternal:
+		opts = append(opts, amqp.ConnSASLExternal(""))
+	default:
+		opts = append(opts, amqp.ConnSASLAnonymous())
+	}
+
+	// Brokers name durable subscriptions after the container id and the link name.
+	if a.metadata.consumerID != "" {
+		opts = append(opts, amqp.ConnContainerID(a.metadata.consumerID))
+	}
+
+	tlsConfig, err := a.metadata.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, amqp.ConnTLSConfig(tlsConfig))
+	}
+
+	return opts, nil
+}
+
+// reconnect replaces the connection identified by connectionCount, unless it was already replaced.
+func (a *amqpPubSub) reconnect(connectionCount int) error {
+	a.connectionLock.Lock()
+	defer a.connectionLock.Unlock()
+
+	if a.ctx.Err() != nil {
+		return a.ctx.Err()
+	}
+	if connectionCount != a.connectionCount && a.client != nil {
+		return nil
+	}
+
+	if a.client != nil {
+		a.client.Close()
+		a.client = nil
+	}
+
+	return a.connect()
+}
+
+// Publish sends the data of req to the address named by its topic. The metadata of req is sent as
+// application properties. Publishing is retried once on a new connection if the connection was lost.
+func (a *amqpPubSub) Publish(req *pubsub.PublishRequest) error {
+	msg, err := newMessage(req, a.metadata.settlementMode)
+	if err != nil {
+		return fmt.Errorf("%s %s", errorMessagePrefix, err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		sender, connectionCount, err := a.sender(req.Topic)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(a.ctx, defaultPublishTimeout)
+			err = sender.Send(ctx, msg)
+			cancel()
+			if err == nil {
+				return nil
+			}
+		}
+
+		if attempt > 0 || !isConnectionError(err) {
+			return fmt.Errorf("%s error publishing to %s: %s", errorMessagePrefix, req.Topic, err)
+		}
+
+		a.logger.Warnf("%s connection lost while publishing to %s, reconnecting: %s", logMessagePrefix, req.Topic, err)
+		if err = a.reconnect(connectionCount); err != nil {
+			return err
+		}
+	}
+}
+
+// sender returns the sender of the address, creating it on first use.
+func (a *amqpPubSub) sender(address string) (*amqp.Sender, int, error) {
+	a.connectionLock.Lock()
+	defer a.connectionLock.Unlock()
+
+	if a.session == nil {
+		return nil, a.connectionCount, amqp.ErrConnClosed
+	}
+	if sender, ok := a.senders[address]; ok {
+		return sender, a.connectionCount, nil
+	}
+
+	settleMode := amqp.ModeUnsettled
+	if a.metadata.settlementMode == atMostOnce {
+		settleMode = amqp.ModeSettled
+	}
+	sender, err := a.session.NewSender(amqp.LinkTargetAddress(address), amqp.LinkSenderSettle(settleMode))
+	if err != nil {
+		return nil, a.connectionCount, err
+	}
+	a.senders[address] = sender
+
+	return sender, a.connectionCount, nil
+}
+
+// Subscribe attaches a receiver to the address named by the topic of req and delivers its messages
+// to handler until the component is closed. The receiver attaches again after connection losses.
+func (a *amqpPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	r, connectionCount, err := a.receiver(req.Topic)
+	if err != nil {
+		return fmt.Errorf("%s error subscribing to %s: %s", errorMessagePrefix, req.Topic, err)
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		for {
+			err := a.receiveMessages(r, req.Topic, handler)
+			closeCtx, cancel := context.WithTimeout(context.Background(), defaultSettleTimeout)
+			r.Close(closeCtx)
+			cancel()
+			if a.ctx.Err() != nil {
+				return
+			}
+
+			a.logger.Warnf("%s subscription to %s interrupted, attaching again in %s: %s", logMessagePrefix, req.Topic, a.metadata.reconnectWait, err)
+			for {
+				select {
+				case <-time.After(a.metadata.reconnectWait):
+				case <-a.ctx.Done():
+					return
+				}
+
+				if isConnectionError(err) {
+					if err = a.reconnect(connectionCount); err != nil {
+						a.logger.Errorf("%s error reconnecting: %s", logMessagePrefix, err)
+
+						continue
+					}
+				}
+				if r, connectionCount, err = a.receiver(req.Topic); err == nil {
+					break
+				}
+				a.logger.Errorf("%s error subscribing to %s: %s", logMessagePrefix, req.Topic, err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// receiver attaches a receiver to the address.
+func (a *amqpPubSub) receiver(address string) (*amqp.Receiver, int, error) {
+	a.connectionLock.Lock()
+	defer a.connectionLock.Unlock()
+
+	if a.session == nil {
+		return nil, a.connectionCount, amqp.ErrConnClosed
+	}
+
+	opts := []amqp.LinkOption{
+		amqp.LinkSourceAddress(address),
+		amqp.LinkCredit(a.metadata.prefetchCount),
+	}
+	if len(a.metadata.sourceCapabilities) > 0 {
+		opts = append(opts, amqp.LinkSourceCapabilities(a.metadata.sourceCapabilities...))
+	}
+	if a.metadata.durable {
+		opts = append(opts,
+			amqp.LinkName(fmt.Sprintf("%s-%s", a.metadata.consumerID, address)),
+			amqp.LinkSourceDurability(amqp.DurabilityUnsettledState),
+			amqp.LinkSourceExpiryPolicy(amqp.ExpiryNever),
+		)
+	}
+	if a.metadata.settlementMode == atMostOnce {
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeSettled))
+	} else {
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeUnsettled), amqp.LinkReceiverSettle(amqp.ModeFirst))
+	}
+
+	r, err := a.session.NewReceiver(opts...)
+
+	return r, a.connectionCount, err
+}
+
+// receiveMessages delivers the messages of r to handler until receiving fails.
+func (a *amqpPubSub) receiveMessages(r *amqp.Receiver, topic string, handler pubsub.Handler) error {
+	for {
+		msg, err := r.Receive(a.ctx)
+		if err != nil {
+			return err
+		}
+
+		a.handleMessage(r, msg, topic, handler)
+	}
+}
+
+// handleMessage delivers msg to handler and settles it with the result: accepted on success,
+// else modified to be delivered again if requeueInFailure is set and it was not redelivered yet,
+// else rejected. Messages settled by the sender are not settled again.
+func (a *amqpPubSub) handleMessage(r receiver, msg *amqp.Message, topic string, handler pubsub.Handler) {
+	newMsg := &pubsub.NewMessage{
+		Topic:    topic,
+		Data:     messageData(msg),
+		Metadata: messageMetadata(msg),
+	}
+	if msg.Properties != nil && msg.Properties.ContentType != nil {
+		contentType := *msg.Properties.ContentType
+		newMsg.ContentType = &contentType
+	}
+
+	b := a.backOffConfig.NewBackOffWithContext(a.ctx)
+	err := retry.NotifyRecover(func() error {
+		a.logger.Debugf("%s processing message from %s", logMessagePrefix, topic)
+
+		return handler(a.ctx, newMsg)
+	}, b, func(err error, d time.Duration) {
+		a.logger.Errorf("%s error handling message from %s, retrying in %s: %s", logMessagePrefix, topic, d, err)
+	}, func() {
+		a.logger.Infof("%s successfully processed message after it previously failed from %s", logMessagePrefix, topic)
+	})
+
+	if a.metadata.settlementMode == atMostOnce {
+		if err != nil {
+			a.logger.Errorf("%s error handling message from %s: %s", logMessagePrefix, topic, err)
+		}
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultSettleTimeout)
+	defer cancel()
+
+	var settleErr error
+	switch {
+	case err == nil:
+		settleErr = r.AcceptMessage(ctx, msg)
+	case a.metadata.requeueInFailure && (msg.Header == nil || msg.Header.DeliveryCount == 0):
+		a.logger.Errorf("%s error handling message from %s, requeueing it: %s", logMessagePrefix, topic, err)
+		settleErr = r.ModifyMessage(ctx, msg, true, false, nil)
+	default:
+		a.logger.Errorf("%s error handling message from %s, rejecting it: %s", logMessagePrefix, topic, err)
+		settleErr = r.RejectMessage(ctx, msg, &amqp.Error{Condition: amqp.ErrorInternalError, Description: err.Error()})
+	}
+	if settleErr != nil {
+		a.logger.Errorf("%s error settling message from %s: %s", logMessagePrefix, topic, settleErr)
+	}
+}
+
+func (a *amqpPubSub) Close() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+
+	a.connectionLock.Lock()
+	defer a.connectionLock.Unlock()
+
+	if a.client == nil {
+		return nil
+	}
+	err := a.client.Close()
+	a.client, a.session = nil, nil
+
+	return err
+}
+
+func (a *amqpPubSub) Features() []pubsub.Feature {
+	return []pubsub.Feature{pubsub.FeatureMessageTTL}
+}
+
+// newMessage returns the AMQP message of req, with its metadata as application properties
+// and its time to live in the header.
+func newMessage(req *pubsub.PublishRequest, mode settlementMode) (*amqp.Message, error) {
+	msg := amqp.NewMessage(req.Data)
+	msg.Header = &amqp.MessageHeader{Durable: mode == atLeastOnce}
+
+	ttl, ok, err := contrib_metadata.TryGetTTL(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		msg.Header.TTL = ttl
+	}
+
+	for k, v := range req.Metadata {
+		if k == contrib_metadata.TTLMetadataKey {
+			continue
+		}
+		if msg.ApplicationProperties == nil {
+			msg.ApplicationProperties = make(map[string]interface{}, len(req.Metadata))
+		}
+		msg.ApplicationProperties[k] = v
+	}
+
+	if req.ContentType != nil && *req.ContentType != "" {
+		contentType := *req.ContentType
+		msg.Properties = &amqp.MessageProperties{ContentType: &contentType}
+	}
+
+	return msg, nil
+}
+
+// messageData returns the body of msg. Bodies sent by other clients as a single
+// string or binary value are supported too.
+func messageData(msg *amqp.Message) []byte {
+	if len(msg.Data) > 0 {
+		return msg.GetData()
+	}
+
+	switch v := msg.Value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return nil
+	}
+}
+
+// messageMetadata returns the application properties of msg as metadata.
+func messageMetadata(msg *amqp.Message) map[string]string {
+	metadata := make(map[string]string, len(msg.ApplicationProperties))
+	for k, v := range msg.ApplicationProperties {
+		if s, ok := v.(string); ok {
+			metadata[k] = s
+		} else {
+			metadata[k] = fmt.Sprint(v)
+		}
+	}
+
+	return metadata
+}
+
+// isConnectionError reports whether err is caused by a lost connection, session or link.
+func isConnectionError(err error) bool {
+	var detachErr *amqp.DetachError
+
+	return errors.Is(err, amqp.ErrConnClosed) ||
+		errors.Is(err, amqp.ErrSessionClosed) ||
+		errors.Is(err, amqp.ErrLinkClosed) ||
+		errors.As(err, &detachErr)
+}
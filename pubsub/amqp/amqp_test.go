@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
+)
+
+// fakeReceiver records how messages are settled.
+type fakeReceiver struct {
+	settlements []string
+}
+
+func (r *fakeReceiver) AcceptMessage(ctx context.Context, msg *amqp.Message) error {
+	r.settlements = append(r.settlements, "accepted")
+
+	return nil
+}
+
+func (r *fakeReceiver) RejectMessage(ctx context.Context, msg *amqp.Message, e *amqp.Error) error {
+	r.settlements = append(r.settlements, "rejected")
+
+	return nil
+}
+
+func (r *fakeReceiver) ModifyMessage(ctx context.Context, msg *amqp.Message, deliveryFailed, undeliverableHere bool, messageAnnotations amqp.Annotations) error {
+	r.settlements = append(r.settlements, fmt.Sprintf("modified failed=%t", deliveryFailed))
+
+	return nil
+}
+
+func newTestPubSub(m *metadata) *amqpPubSub {
+	a := NewAMQP(logger.NewLogger("test")).(*amqpPubSub)
+	a.metadata = m
+	a.backOffConfig = retry.DefaultConfigWithNoRetry()
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+
+	return a
+}
+
+func TestHandleMessage(t *testing.T) {
+	failing := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return errors.New("handler failed")
+	}
+	succeeding := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	}
+
+	tests := []struct {
+		name          string
+		metadata      *metadata
+		handler       pubsub.Handler
+		deliveryCount uint32
+		expected      []string
+	}{
+		{"accepted on success", &metadata{settlementMode: atLeastOnce}, succeeding, 0, []string{"accepted"}},
+		{"rejected on failure", &metadata{settlementMode: atLeastOnce}, failing, 0, []string{"rejected"}},
+		{"requeued on failure", &metadata{settlementMode: atLeastOnce, requeueInFailure: true}, failing, 0, []string{"modified failed=true"}},
+		{"rejected on failure of a redelivery", &metadata{settlementMode: atLeastOnce, requeueInFailure: true}, failing, 1, []string{"rejected"}},
+		{"not settled at most once", &metadata{settlementMode: atMostOnce}, failing, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestPubSub(tt.metadata)
+			defer a.Close()
+
+			r := &fakeReceiver{}
+			msg := amqp.NewMessage([]byte("hello"))
+			msg.Header = &amqp.MessageHeader{DeliveryCount: tt.deliveryCount}
+			a.handleMessage(r, msg, "orders", tt.handler)
+			assert.Equal(t, tt.expected, r.settlements)
+		})
+	}
+}
+
+func TestHandleMessageMetadata(t *testing.T) {
+	a := newTestPubSub(&metadata{settlementMode: atLeastOnce})
+	defer a.Close()
+
+	contentType := "application/json"
+	msg := amqp.NewMessage([]byte(`{"id":1}`))
+	msg.Properties = &amqp.MessageProperties{ContentType: &contentType}
+	msg.ApplicationProperties = map[string]interface{}{"tenant": "contoso", "priority": int32(5)}
+
+	var received *pubsub.NewMessage
+	a.handleMessage(&fakeReceiver{}, msg, "orders", func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = msg
+
+		return nil
+	})
+
+	require.NotNil(t, received)
+	assert.Equal(t, "orders", received.Topic)
+	assert.Equal(t, []byte(`{"id":1}`), received.Data)
+	assert.Equal(t, map[string]string{"tenant": "contoso", "priority": "5"}, received.Metadata)
+	require.NotNil(t, received.ContentType)
+	assert.Equal(t, contentType, *received.ContentType)
+}
+
+func TestNewMessage(t *testing.T) {
+	contentType := "text/plain"
+	msg, err := newMessage(&pubsub.PublishRequest{
+		Topic:       "orders",
+		Data:        []byte("hello"),
+		Metadata:    map[string]string{"tenant": "contoso", "ttlInSeconds": "30"},
+		ContentType: &contentType,
+	}, atLeastOnce)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("hello"), msg.GetData())
+	assert.True(t, msg.Header.Durable)
+	assert.Equal(t, 30*time.Second, msg.Header.TTL)
+	assert.Equal(t, map[string]interface{}{"tenant": "contoso"}, msg.ApplicationProperties)
+	assert.Equal(t, contentType, *msg.Properties.ContentType)
+
+	_, err = newMessage(&pubsub.PublishRequest{Metadata: map[string]string{"ttlInSeconds": "soon"}}, atLeastOnce)
+	assert.Error(t, err)
+}
+
+func TestMessageData(t *testing.T) {
+	assert.Equal(t, []byte("data"), messageData(amqp.NewMessage([]byte("data"))))
+	assert.Equal(t, []byte("value"), messageData(&amqp.Message{Value: "value"}))
+	assert.Nil(t, messageData(&amqp.Message{Value: 42}))
+}
+
+func TestIsConnectionError(t *testing.T) {
+	assert.True(t, isConnectionError(amqp.ErrConnClosed))
+	assert.True(t, isConnectionError(fmt.Errorf("send: %w", amqp.ErrLinkClosed)))
+	assert.True(t, isConnectionError(&amqp.DetachError{}))
+	assert.False(t, isConnectionError(errors.New("unauthorized")))
+}
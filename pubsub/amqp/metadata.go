@@ -0,0 +1,223 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amqp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	metadataURLKey              = "url"
+	metadataUsernameKey         = "username"
+	metadataPasswordKey         = "password"
+	metadataSASLMechanismKey    = "saslMechanism"
+	metadataConsumerIDKey       = "consumerID"
+	metadataDurableKey          = "durable"
+	metadataSettlementModeKey   = "settlementMode"
+	metadataRequeueInFailureKey = "requeueInFailure"
+	metadataPrefetchCountKey    = "prefetchCount"
+	metadataReconnectWaitKey    = "reconnectWaitSeconds"
+	metadataCapabilitiesKey     = "sourceCapabilities"
+	metadataCACertKey           = "caCert"
+	metadataClientCertKey       = "clientCert"
+	metadataClientKeyKey        = "clientKey"
+	metadataSkipVerifyKey       = "skipVerify"
+
+	defaultPrefetchCount = 10
+	defaultReconnectWait = 3 * time.Second
+)
+
+// SASL mechanisms. Plain is used when a username is set, else anonymous.
+const (
+	saslPlain     = "plain"
+	saslAnonymous = "anonymous"
+	saslExternal  = "external"
+)
+
+// settlementMode selects the delivery guarantee of messages.
+type settlementMode string
+
+const (
+	// atLeastOnce settles messages once they are processed: accepted when the handler succeeds,
+	// rejected or released when it fails.
+	atLeastOnce settlementMode = "atLeastOnce"
+	// atMostOnce has messages settled by the sender when they are sent, handler errors are only logged.
+	atMostOnce settlementMode = "atMostOnce"
+)
+
+type metadata struct {
+	url           string
+	username      string
+	password      string
+	saslMechanism string
+	consumerID    string
+	// durable keeps the subscriptions of consumerID on the broker while it is disconnected.
+	durable          bool
+	settlementMode   settlementMode
+	requeueInFailure bool
+	prefetchCount    uint32
+	reconnectWait    time.Duration
+	// sourceCapabilities are sent when subscribing, like "topic" for the multicast addresses of Artemis.
+	sourceCapabilities []string
+
+	caCert     string
+	clientCert string
+	clientKey  string
+	skipVerify bool
+}
+
+func parseMetadata(psm pubsub.Metadata) (*metadata, error) {
+	m := metadata{
+		settlementMode: atLeastOnce,
+		prefetchCount:  defaultPrefetchCount,
+		reconnectWait:  defaultReconnectWait,
+	}
+
+	if m.url = psm.Properties[metadataURLKey]; m.url == "" {
+		return nil, fmt.Errorf("%s missing url", errorMessagePrefix)
+	}
+	uri, err := url.Parse(m.url)
+	if err != nil {
+		return nil, fmt.Errorf("%s invalid url: %s", errorMessagePrefix, err)
+	}
+
+	// Credentials can be set in the url or apart.
+	m.username = uri.User.Username()
+	m.password, _ = uri.User.Password()
+	if val := psm.Properties[metadataUsernameKey]; val != "" {
+		m.username = val
+		m.password = psm.Properties[metadataPasswordKey]
+	}
+
+	switch val := psm.Properties[metadataSASLMechanismKey]; val {
+	case "":
+		m.saslMechanism = saslAnonymous
+		if m.username != "" {
+			m.saslMechanism = saslPlain
+		}
+	case saslPlain, saslAnonymous, saslExternal:
+		m.saslMechanism = val
+	default:
+		return nil, fmt.Errorf("%s invalid %s %s, expected %s, %s or %s", errorMessagePrefix, metadataSASLMechanismKey, val, saslPlain, saslAnonymous, saslExternal)
+	}
+	if m.saslMechanism == saslPlain && m.username == "" {
+		return nil, fmt.Errorf("%s sasl mechanism %s requires a username", errorMessagePrefix, saslPlain)
+	}
+
+	m.consumerID = psm.Properties[metadataConsumerIDKey]
+
+	if val := psm.Properties[metadataDurableKey]; val != "" {
+		if m.durable, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("%s invalid %s %s", errorMessagePrefix, metadataDurableKey, val)
+		}
+	}
+	if m.durable && m.consumerID == "" {
+		return nil, fmt.Errorf("%s durable subscriptions require a %s", errorMessagePrefix, metadataConsumerIDKey)
+	}
+
+	switch val := settlementMode(psm.Properties[metadataSettlementModeKey]); val {
+	case "":
+	case atLeastOnce, atMostOnce:
+		m.settlementMode = val
+	default:
+		return nil, fmt.Errorf("%s invalid %s %s, expected %s or %s", errorMessagePrefix, metadataSettlementModeKey, val, atLeastOnce, atMostOnce)
+	}
+
+	if val := psm.Properties[metadataRequeueInFailureKey]; val != "" {
+		if m.requeueInFailure, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("%s invalid %s %s", errorMessagePrefix, metadataRequeueInFailureKey, val)
+		}
+	}
+
+	if val := psm.Properties[metadataPrefetchCountKey]; val != "" {
+		count, err := strconv.ParseUint(val, 10, 32)
+		if err != nil || count == 0 {
+			return nil, fmt.Errorf("%s invalid %s %s, expected a positive number", errorMessagePrefix, metadataPrefetchCountKey, val)
+		}
+		m.prefetchCount = uint32(count)
+	}
+
+	if val := psm.Properties[metadataReconnectWaitKey]; val != "" {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("%s invalid %s %s", errorMessagePrefix, metadataReconnectWaitKey, val)
+		}
+		m.reconnectWait = time.Duration(seconds) * time.Second
+	}
+
+	if val := psm.Properties[metadataCapabilitiesKey]; val != "" {
+		m.sourceCapabilities = splitList(val)
+	}
+
+	m.caCert = psm.Properties[metadataCACertKey]
+	m.clientCert = psm.Properties[metadataClientCertKey]
+	m.clientKey = psm.Properties[metadataClientKeyKey]
+	if (m.clientCert == "") != (m.clientKey == "") {
+		return nil, fmt.Errorf("%s %s and %s must be set together", errorMessagePrefix, metadataClientCertKey, metadataClientKeyKey)
+	}
+	if val := psm.Properties[metadataSkipVerifyKey]; val != "" {
+		if m.skipVerify, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("%s invalid %s %s", errorMessagePrefix, metadataSkipVerifyKey, val)
+		}
+	}
+
+	return &m, nil
+}
+
+// tlsConfig returns the TLS configuration of the connection, or nil if none is set.
+// Connections to `amqps` URLs use TLS even without configuration.
+func (m *metadata) tlsConfig() (*tls.Config, error) {
+	if m.caCert == "" && m.clientCert == "" && !m.skipVerify {
+		return nil, nil
+	}
+
+	//nolint:gosec
+	cfg := &tls.Config{InsecureSkipVerify: m.skipVerify}
+	if m.caCert != "" {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM([]byte(m.caCert)) {
+			return nil, errors.New("invalid ca certificate")
+		}
+	}
+	if m.clientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(m.clientCert), []byte(m.clientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// splitList returns the non-empty items of a comma separated list.
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
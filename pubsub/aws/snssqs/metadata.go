@@ -155,6 +155,9 @@ func (md *snsSqsMetadata) setConcurrencyMode(props map[string]string) error {
 	if err != nil {
 		return err
 	}
+	if c == pubsub.Partitioned {
+		return fmt.Errorf("%s %s is not supported", pubsub.ConcurrencyKey, c)
+	}
 	md.concurrencyMode = c
 
 	return nil
@@ -204,6 +204,12 @@ func NewASBMessageFromPubsubRequest(req *pubsub.PublishRequest) (*azservicebus.M
 	scheduledEnqueueTime, ok, _ := tryGetScheduledEnqueueTime(req.Metadata)
 	if ok {
 		asbMsg.ScheduledEnqueueTime = scheduledEnqueueTime
+	} else if deliverAt, ok, err := contrib_metadata.TryGetDeliverAt(req.Metadata, time.Now()); err != nil {
+		return nil, err
+	} else if ok {
+		// Common delayed delivery properties map to the scheduled enqueue time.
+		deliverAt = deliverAt.UTC()
+		asbMsg.ScheduledEnqueueTime = &deliverAt
 	}
 
 	return asbMsg, nil
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 )
 
@@ -75,6 +76,20 @@ func TestNewASBMessageFromPubsubRequest(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Maps common delayed delivery metadata to the scheduled enqueue time.",
+			pubsubRequest: pubsub.PublishRequest{
+				Data: testMessageData,
+				Metadata: map[string]string{
+					contrib_metadata.DeliverAtMetadataKey: nowUtc.Format(time.RFC3339),
+				},
+			},
+			expectedAzServiceBusMessage: azservicebus.Message{
+				Body:                 testMessageData,
+				ScheduledEnqueueTime: &nowUtc,
+			},
+			expectError: false,
+		},
 		{
 			name: "Errors when partition key and session id set but not equal.",
 			pubsubRequest: pubsub.PublishRequest{
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bridge forwards the messages of a pub/sub to another, like the messages of an
+// MQTT broker at the edge to Kafka in the cloud.
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dapr/components-contrib/contenttype"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+const defaultMaxInFlight = 100
+
+// ErrClosed is returned by the handlers of a closed bridge, leaving the messages unacknowledged.
+var ErrClosed = errors.New("pub/sub bridge is closed")
+
+// Rule forwards the messages of a source topic to a target topic.
+type Rule struct {
+	// Source is the topic subscribed to in the source pub/sub.
+	Source string
+	// Target is the topic published to in the target pub/sub, Source if empty.
+	Target string
+	// Metadata is the metadata of the subscription to Source, like its consumer group.
+	Metadata map[string]string
+}
+
+// Options configures a bridge.
+type Options struct {
+	Rules []Rule
+	// RewriteCloudEvents rewrites the topic and pub/sub attributes of cloud events for the target
+	// with pubsub.FromCloudEvent. Other messages are forwarded as they are.
+	RewriteCloudEvents bool
+	// TargetPubsubName is the pub/sub name of rewritten cloud events and publish requests.
+	TargetPubsubName string
+	// MaxInFlight is the number of messages being forwarded at once, 100 by default.
+	// Source handlers block while it is reached.
+	MaxInFlight int
+}
+
+// Bridge subscribes to the topics of a source pub/sub and publishes their messages to a target pub/sub.
+// A message is acknowledged to the source only once the target accepted it, so messages the target
+// refused are delivered again by the source: forwarding is at least once.
+type Bridge struct {
+	source pubsub.PubSub
+	target pubsub.PubSub
+	opts   Options
+	logger logger.Logger
+
+	inFlight chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	started  bool
+	startErr error
+	lock     sync.Mutex
+}
+
+// New returns a bridge between two initialized pub/subs. It forwards nothing until it is started.
+func New(source, target pubsub.PubSub, opts Options, logger logger.Logger) *Bridge {
+	if opts.MaxInFlight <= 0 {
+		opts.MaxInFlight = defaultMaxInFlight
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Bridge{
+		source:   source,
+		target:   target,
+		opts:     opts,
+		logger:   logger,
+		inFlight: make(chan struct{}, opts.MaxInFlight),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start subscribes to the source topics of the rules. When a subscription fails, the rules
+// subscribed before keep forwarding and Start can't be called again: the bridge must be
+// closed and replaced by a new one, as pub/subs can't unsubscribe.
+func (b *Bridge) Start() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.ctx.Err() != nil {
+		return ErrClosed
+	}
+	if b.startErr != nil {
+		return fmt.Errorf("pub/sub bridge: partially started, close it and create a new one: %w", b.startErr)
+	}
+	if b.started {
+		return errors.New("pub/sub bridge is already started")
+	}
+	if len(b.opts.Rules) == 0 {
+		return errors.New("pub/sub bridge has no rules")
+	}
+	for _, rule := range b.opts.Rules {
+		if rule.Source == "" {
+			return errors.New("pub/sub bridge rule without a source topic")
+		}
+	}
+
+	for _, rule := range b.opts.Rules {
+		req := pubsub.SubscribeRequest{Topic: rule.Source, Metadata: rule.Metadata}
+		if err := b.source.Subscribe(req, b.forward(rule)); err != nil {
+			b.startErr = fmt.Errorf("pub/sub bridge: error subscribing to %s: %w", rule.Source, err)
+
+			return b.startErr
+		}
+		b.logger.Infof("pub/sub bridge: forwarding %s to %s", rule.Source, targetTopic(rule))
+	}
+	b.started = true
+
+	return nil
+}
+
+// Close stops forwarding. Messages waiting to be forwarded are left unacknowledged.
+// The source and target pub/subs are not closed.
+func (b *Bridge) Close() error {
+	b.cancel()
+
+	return nil
+}
+
+// forward returns the handler publishing the messages of rule to the target.
+func (b *Bridge) forward(rule Rule) pubsub.Handler {
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		// Holding the source handler while the target is busy slows the source down.
+		select {
+		case b.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.ctx.Done():
+			return ErrClosed
+		}
+		defer func() { <-b.inFlight }()
+
+		if b.ctx.Err() != nil {
+			return ErrClosed
+		}
+
+		req, err := b.publishRequest(rule, msg)
+		if err != nil {
+			return err
+		}
+		if err = b.target.Publish(req); err != nil {
+			b.logger.Warnf("pub/sub bridge: error forwarding message from %s to %s: %s", msg.Topic, req.Topic, err)
+
+			return fmt.Errorf("pub/sub bridge: error publishing to %s: %w", req.Topic, err)
+		}
+
+		return nil
+	}
+}
+
+// publishRequest returns the request publishing msg to the target topic of rule.
+func (b *Bridge) publishRequest(rule Rule, msg *pubsub.NewMessage) (*pubsub.PublishRequest, error) {
+	req := &pubsub.PublishRequest{
+		Data:        msg.Data,
+		PubsubName:  b.opts.TargetPubsubName,
+		Topic:       targetTopic(rule),
+		ContentType: msg.ContentType,
+	}
+	if len(msg.Metadata) > 0 {
+		req.Metadata = make(map[string]string, len(msg.Metadata))
+		for k, v := range msg.Metadata {
+			req.Metadata[k] = v
+		}
+	}
+
+	if !b.opts.RewriteCloudEvents {
+		return req, nil
+	}
+
+	var original map[string]interface{}
+	if err := json.Unmarshal(msg.Data, &original); err != nil || original[pubsub.SpecVersionField] == nil {
+		// Not a cloud event, forwarded as it is.
+		return req, nil
+	}
+
+	// The tracing attributes stay those of the original event.
+	traceParent, _ := original[pubsub.TraceParentField].(string)
+	traceState, _ := original[pubsub.TraceStateField].(string)
+	cloudEvent, err := pubsub.FromCloudEvent(msg.Data, req.Topic, b.opts.TargetPubsubName, traceParent, traceState)
+	if err != nil {
+		return nil, fmt.Errorf("pub/sub bridge: error rewriting cloud event: %w", err)
+	}
+
+	data, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return nil, fmt.Errorf("pub/sub bridge: error rewriting cloud event: %w", err)
+	}
+	contentType := contenttype.CloudEventContentType
+	req.Data = data
+	req.ContentType = &contentType
+
+	return req, nil
+}
+
+func targetTopic(rule Rule) string {
+	if rule.Target == "" {
+		return rule.Source
+	}
+
+	return rule.Target
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	inmemory "github.com/dapr/components-contrib/pubsub/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func newBus(t *testing.T) pubsub.PubSub {
+	t.Helper()
+
+	bus := inmemory.New(logger.NewLogger("test"))
+	require.NoError(t, bus.Init(pubsub.Metadata{Properties: map[string]string{"recordMessages": "true"}}))
+	t.Cleanup(func() { bus.Close() })
+
+	return bus
+}
+
+func startBridge(t *testing.T, source, target pubsub.PubSub, opts Options) {
+	t.Helper()
+
+	b := New(source, target, opts, logger.NewLogger("test"))
+	require.NoError(t, b.Start())
+	t.Cleanup(func() { b.Close() })
+}
+
+func TestForward(t *testing.T) {
+	source, target := newBus(t), newBus(t)
+	startBridge(t, source, target, Options{Rules: []Rule{
+		{Source: "sensors", Target: "telemetry"},
+		{Source: "alerts"},
+	}})
+
+	contentType := "text/plain"
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{
+		Topic:       "sensors",
+		Data:        []byte("21.5"),
+		Metadata:    map[string]string{"device": "d1"},
+		ContentType: &contentType,
+	}))
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "alerts", Data: []byte("fire")}))
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "unmapped", Data: []byte("ignored")}))
+
+	telemetry := target.(inmemory.Inspector).Published("telemetry")
+	require.Len(t, telemetry, 1)
+	assert.Equal(t, []byte("21.5"), telemetry[0].Data)
+	assert.Equal(t, map[string]string{"device": "d1"}, telemetry[0].Metadata)
+	assert.Equal(t, contentType, *telemetry[0].ContentType)
+
+	assert.Len(t, target.(inmemory.Inspector).Published("alerts"), 1)
+	assert.Empty(t, target.(inmemory.Inspector).Published("unmapped"))
+	assert.Len(t, source.(inmemory.Inspector).Acked("sensors"), 1)
+}
+
+func TestRewriteCloudEvents(t *testing.T) {
+	source, target := newBus(t), newBus(t)
+	startBridge(t, source, target, Options{
+		Rules:              []Rule{{Source: "orders", Target: "cloud-orders"}},
+		RewriteCloudEvents: true,
+		TargetPubsubName:   "kafka",
+	})
+
+	ce := pubsub.NewCloudEventsEnvelope("1", "edge", "order", "", "orders", "mqtt", "application/json", []byte(`{"id":1}`), "00-trace", "")
+	data, err := json.Marshal(ce)
+	require.NoError(t, err)
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: data}))
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("raw")}))
+
+	published := target.(inmemory.Inspector).Published("cloud-orders")
+	require.Len(t, published, 2)
+
+	var rewritten map[string]interface{}
+	require.NoError(t, json.Unmarshal(published[0].Data, &rewritten))
+	assert.Equal(t, "cloud-orders", rewritten[pubsub.TopicField])
+	assert.Equal(t, "kafka", rewritten[pubsub.PubsubField])
+	assert.Equal(t, "00-trace", rewritten[pubsub.TraceParentField])
+	assert.Equal(t, "1", rewritten[pubsub.IDField])
+	assert.Equal(t, []byte("raw"), published[1].Data)
+}
+
+// flakyPubSub fails the first publications.
+type flakyPubSub struct {
+	pubsub.PubSub
+	failures int32
+}
+
+func (f *flakyPubSub) Publish(req *pubsub.PublishRequest) error {
+	if atomic.AddInt32(&f.failures, -1) >= 0 {
+		return errors.New("target unavailable")
+	}
+
+	return f.PubSub.Publish(req)
+}
+
+func TestAtLeastOnce(t *testing.T) {
+	source, target := newBus(t), newBus(t)
+	startBridge(t, source, &flakyPubSub{PubSub: target, failures: 2}, Options{Rules: []Rule{{Source: "orders"}}})
+
+	// The in-memory source delivers the message again until the target accepts it.
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("order")}))
+
+	assert.Len(t, target.(inmemory.Inspector).Published("orders"), 1)
+	assert.Len(t, source.(inmemory.Inspector).Acked("orders"), 1)
+}
+
+func TestNotAcknowledgedWhenTargetFails(t *testing.T) {
+	source := inmemory.New(logger.NewLogger("test"))
+	require.NoError(t, source.Init(pubsub.Metadata{Properties: map[string]string{"backOffMaxRetries": "1", "recordMessages": "true"}}))
+	defer source.Close()
+
+	target := &flakyPubSub{PubSub: newBus(t), failures: 10}
+	startBridge(t, source, target, Options{Rules: []Rule{{Source: "orders"}}})
+
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("order")}))
+
+	assert.Empty(t, source.(inmemory.Inspector).Acked("orders"))
+}
+
+// blockingPubSub blocks publications until released and records how many run at once.
+type blockingPubSub struct {
+	pubsub.PubSub
+	release     chan struct{}
+	running     int32
+	maxRunning  int32
+	publishedCh chan struct{}
+}
+
+func (b *blockingPubSub) Publish(req *pubsub.PublishRequest) error {
+	running := atomic.AddInt32(&b.running, 1)
+	for {
+		max := atomic.LoadInt32(&b.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt32(&b.maxRunning, max, running) {
+			break
+		}
+	}
+	<-b.release
+	atomic.AddInt32(&b.running, -1)
+	b.publishedCh <- struct{}{}
+
+	return nil
+}
+
+func TestBackpressure(t *testing.T) {
+	source := newBus(t)
+	target := &blockingPubSub{
+		PubSub:      newBus(t),
+		release:     make(chan struct{}),
+		publishedCh: make(chan struct{}, 10),
+	}
+	startBridge(t, source, target, Options{Rules: []Rule{{Source: "orders"}}, MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			source.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("order")})
+		}()
+	}
+
+	for i := 0; i < 5; i++ {
+		target.release <- struct{}{}
+		<-target.publishedCh
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&target.maxRunning), int32(2))
+	assert.Len(t, source.(inmemory.Inspector).Acked("orders"), 5)
+}
+
+func TestStart(t *testing.T) {
+	source, target := newBus(t), newBus(t)
+
+	assert.Error(t, New(source, target, Options{}, logger.NewLogger("test")).Start())
+	assert.Error(t, New(source, target, Options{Rules: []Rule{{Target: "orders"}}}, logger.NewLogger("test")).Start())
+
+	b := New(source, target, Options{Rules: []Rule{{Source: "orders"}}}, logger.NewLogger("test"))
+	require.NoError(t, b.Start())
+	assert.Error(t, b.Start())
+
+	b.Close()
+	assert.ErrorIs(t, b.Start(), ErrClosed)
+}
+
+// failingPubSub fails the subscriptions to a topic.
+type failingPubSub struct {
+	pubsub.PubSub
+	topic string
+}
+
+func (f *failingPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if req.Topic == f.topic {
+		return errors.New("subscription refused")
+	}
+
+	return f.PubSub.Subscribe(req, handler)
+}
+
+func TestPartialStart(t *testing.T) {
+	source, target := newBus(t), newBus(t)
+	b := New(&failingPubSub{PubSub: source, topic: "alerts"}, target, Options{Rules: []Rule{
+		{Source: "sensors"},
+		{Source: "alerts"},
+	}}, logger.NewLogger("test"))
+	defer b.Close()
+	require.Error(t, b.Start())
+
+	// The rules subscribed before the failure forward, but are not subscribed twice.
+	err := b.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subscription refused")
+
+	require.NoError(t, source.Publish(&pubsub.PublishRequest{Topic: "sensors", Data: []byte("21.5")}))
+	assert.Len(t, target.(inmemory.Inspector).Published("sensors"), 1)
+}
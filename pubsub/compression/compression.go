@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compression wraps a pub/sub to compress the payload of messages.
+package compression
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dapr/components-contrib/internal/component/compression"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+type compressingPubSub struct {
+	pubsub.PubSub
+	settings compression.Settings
+}
+
+// New returns a pub/sub compressing the messages it publishes according to the `compression` and
+// `compressionMinSize` metadata. The encoding is recorded in the `contentEncoding` metadata of
+// messages, and received messages carrying it are decompressed whatever the settings are, up to
+// `compressionMaxSize` bytes. Messages are only decompressed by components that deliver the metadata
+// they were published with, unless `compressionFramed` records the encoding in the payload too.
+func New(inner pubsub.PubSub) pubsub.PubSub {
+	return &compressingPubSub{PubSub: inner}
+}
+
+func (c *compressingPubSub) Init(metadata pubsub.Metadata) error {
+	settings, err := compression.ParseSettings(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	c.settings = settings
+
+	return c.PubSub.Init(metadata)
+}
+
+func (c *compressingPubSub) Publish(req *pubsub.PublishRequest) error {
+	data, metadata, err := c.settings.Compress(req.Data, req.Metadata)
+	if err != nil {
+		return fmt.Errorf("error compressing message of topic %s: %w", req.Topic, err)
+	}
+
+	compressed := *req
+	compressed.Data = data
+	compressed.Metadata = metadata
+
+	return c.PubSub.Publish(&compressed)
+}
+
+func (c *compressingPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	return c.PubSub.Subscribe(req, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		data, metadata, err := c.settings.Decompress(msg.Data, msg.Metadata)
+		if err != nil {
+			return fmt.Errorf("error decompressing message of topic %s: %w", msg.Topic, err)
+		}
+
+		decompressed := *msg
+		decompressed.Data = data
+		decompressed.Metadata = metadata
+
+		return handler(ctx, &decompressed)
+	})
+}
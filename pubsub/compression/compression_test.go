@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/internal/component/compression"
+	"github.com/dapr/components-contrib/pubsub"
+	inmemory "github.com/dapr/components-contrib/pubsub/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+func TestCompressingPubSub(t *testing.T) {
+	inner := inmemory.New(logger.NewLogger("test"))
+	c := New(inner)
+	err := c.Init(pubsub.Metadata{Properties: map[string]string{
+		"compression":        "zstd",
+		"compressionMinSize": "16",
+		"recordMessages":     "true",
+	}})
+	require.NoError(t, err)
+	defer c.Close()
+
+	var received []*pubsub.NewMessage
+	err = c.Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = append(received, msg)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	large := bytes.Repeat([]byte("order "), 100)
+	require.NoError(t, c.Publish(&pubsub.PublishRequest{Topic: "orders", Data: large, Metadata: map[string]string{"k": "v"}}))
+	require.NoError(t, c.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("small")}))
+
+	published := inner.(inmemory.Inspector).Published("orders")
+	require.Len(t, published, 2)
+	assert.Less(t, len(published[0].Data), len(large))
+	assert.Equal(t, map[string]string{"k": "v", compression.ContentEncodingKey: "zstd"}, published[0].Metadata)
+	assert.Equal(t, []byte("small"), published[1].Data)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, large, received[0].Data)
+	assert.Equal(t, map[string]string{"k": "v"}, received[0].Metadata)
+	assert.Equal(t, []byte("small"), received[1].Data)
+}
+
+func TestDecompressWithoutMetadata(t *testing.T) {
+	// Components like redis streams do not deliver the metadata of published messages,
+	// the encoding is recorded in the payload too when framed.
+	inner := inmemory.New(logger.NewLogger("test"))
+	c := New(inner)
+	require.NoError(t, c.Init(pubsub.Metadata{Properties: map[string]string{"compression": "gzip", "compressionFramed": "true"}}))
+	defer c.Close()
+
+	var received [][]byte
+	err := c.Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = append(received, msg.Data)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	compressed, _, err := compression.Settings{Encoding: compression.Gzip, Framed: true}.Compress([]byte("order"), nil)
+	require.NoError(t, err)
+	require.NoError(t, inner.Publish(&pubsub.PublishRequest{Topic: "orders", Data: compressed}))
+
+	assert.Equal(t, [][]byte{[]byte("order")}, received)
+}
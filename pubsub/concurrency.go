@@ -13,7 +13,10 @@ limitations under the License.
 
 package pubsub
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 // ConcurrencyMode is a pub/sub metadata setting that allows to specify whether messages are delivered in a serial or parallel execution.
 type ConcurrencyMode string
@@ -23,6 +26,14 @@ const (
 	ConcurrencyKey                 = "concurrencyMode"
 	Single         ConcurrencyMode = "single"
 	Parallel       ConcurrencyMode = "parallel"
+	// Partitioned processes messages that share an ordering key in order, on the same worker,
+	// while messages with different keys are processed in parallel.
+	Partitioned ConcurrencyMode = "partitioned"
+
+	// PartitionedWorkersKey is the metadata key name for the number of workers used in Partitioned mode.
+	PartitionedWorkersKey = "partitionedWorkers"
+	// DefaultPartitionedWorkers is the number of workers used in Partitioned mode when none is configured.
+	DefaultPartitionedWorkers = 10
 )
 
 // Concurrency takes a metadata object and returns the ConcurrencyMode configured. Default is Parallel.
@@ -33,6 +44,8 @@ func Concurrency(metadata map[string]string) (ConcurrencyMode, error) {
 			return Single, nil
 		case string(Parallel):
 			return Parallel, nil
+		case string(Partitioned):
+			return Partitioned, nil
 		default:
 			return "", fmt.Errorf("invalid %s %s", ConcurrencyKey, val)
 		}
@@ -40,3 +53,17 @@ func Concurrency(metadata map[string]string) (ConcurrencyMode, error) {
 
 	return Parallel, nil
 }
+
+// PartitionedWorkers takes a metadata object and returns the number of workers to use in Partitioned mode.
+func PartitionedWorkers(metadata map[string]string) (int, error) {
+	if val, ok := metadata[PartitionedWorkersKey]; ok && val != "" {
+		workers, err := strconv.Atoi(val)
+		if err != nil || workers <= 0 {
+			return 0, fmt.Errorf("invalid %s %s", PartitionedWorkersKey, val)
+		}
+
+		return workers, nil
+	}
+
+	return DefaultPartitionedWorkers, nil
+}
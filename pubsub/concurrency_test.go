@@ -41,6 +41,13 @@ func TestConcurrency(t *testing.T) {
 		assert.Equal(t, Single, c)
 	})
 
+	t.Run("partitioned", func(t *testing.T) {
+		m := map[string]string{ConcurrencyKey: string(Partitioned)}
+		c, _ := Concurrency(m)
+
+		assert.Equal(t, Partitioned, c)
+	})
+
 	t.Run("invalid", func(t *testing.T) {
 		m := map[string]string{ConcurrencyKey: "a"}
 		c, err := Concurrency(m)
@@ -49,3 +56,25 @@ func TestConcurrency(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestPartitionedWorkers(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		w, err := PartitionedWorkers(map[string]string{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultPartitionedWorkers, w)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		w, err := PartitionedWorkers(map[string]string{PartitionedWorkersKey: "4"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, w)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := PartitionedWorkers(map[string]string{PartitionedWorkersKey: "0"})
+
+		assert.Error(t, err)
+	})
+}
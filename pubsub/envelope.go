@@ -17,7 +17,10 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -51,8 +54,52 @@ const (
 	SourceField          = "source"
 	IDField              = "id"
 	SubjectField         = "subject"
+	TimeField            = "time"
+	DataSchemaField      = "dataschema"
+
+	// CloudEventExtensionMetadataPrefix prefixes the publish metadata keys setting
+	// extension attributes, `cloudevent.<name>`. Attributes of the specification,
+	// and those set by Dapr, cannot be set this way.
+	CloudEventExtensionMetadataPrefix = "cloudevent."
+
+	// Prefixes of the cloud event attributes carried as message headers in binary content mode.
+	KafkaCloudEventHeaderPrefix = "ce_"
+	AMQPCloudEventHeaderPrefix  = "cloudEvents:"
+	MQTTCloudEventHeaderPrefix  = ""
+
+	// CloudEventsModeKey is the component metadata key of the cloud events content mode.
+	CloudEventsModeKey = "cloudEventsMode"
 )
 
+// CloudEventsMode is the content mode of the cloud events exchanged with the broker.
+type CloudEventsMode string
+
+const (
+	// CloudEventsStructured sends cloud events as a JSON document, the default.
+	CloudEventsStructured CloudEventsMode = "structured"
+	// CloudEventsBinary sends the attributes of cloud events as message headers
+	// and their data as the message payload.
+	CloudEventsBinary CloudEventsMode = "binary"
+)
+
+// ErrNotBinaryCloudEvent is returned when decoding a message without cloud event headers.
+var ErrNotBinaryCloudEvent = errors.New("message is not a binary cloud event")
+
+// cloudEventAttributeRegexp matches the names allowed for cloud event attributes.
+var cloudEventAttributeRegexp = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// CloudEventsContentMode returns the cloud events content mode set in metadata.
+func CloudEventsContentMode(metadata map[string]string) (CloudEventsMode, error) {
+	switch mode := CloudEventsMode(metadata[CloudEventsModeKey]); mode {
+	case "", CloudEventsStructured:
+		return CloudEventsStructured, nil
+	case CloudEventsBinary:
+		return CloudEventsBinary, nil
+	default:
+		return "", fmt.Errorf("invalid %s %s, expected %s or %s", CloudEventsModeKey, mode, CloudEventsStructured, CloudEventsBinary)
+	}
+}
+
 // unmarshalPrecise is a wrapper around encoding/json's Decoder
 // with UseNumber. It prevents data loss for big numbers
 // while unmarshalling.
@@ -83,21 +130,7 @@ func NewCloudEventsEnvelope(id, source, eventType, subject string, topic string,
 		dataContentType = DefaultCloudEventDataContentType
 	}
 
-	var ceData interface{}
-	ceDataField := DataField
-	var err error
-	if contrib_contenttype.IsJSONContentType(dataContentType) {
-		err = unmarshalPrecise(data, &ceData)
-	} else if contrib_contenttype.IsBinaryContentType(dataContentType) {
-		ceData = base64.StdEncoding.EncodeToString(data)
-		ceDataField = DataBase64Field
-	} else {
-		ceData = string(data)
-	}
-
-	if err != nil {
-		ceData = string(data)
-	}
+	ceDataField, ceData := cloudEventData(dataContentType, data)
 
 	ce := map[string]interface{}{
 		IDField:              id,
@@ -121,6 +154,22 @@ func NewCloudEventsEnvelope(id, source, eventType, subject string, topic string,
 	return ce
 }
 
+// cloudEventData returns the field and the value holding data in a cloud event.
+func cloudEventData(dataContentType string, data []byte) (string, interface{}) {
+	if contrib_contenttype.IsBinaryContentType(dataContentType) {
+		return DataBase64Field, base64.StdEncoding.EncodeToString(data)
+	}
+
+	if contrib_contenttype.IsJSONContentType(dataContentType) {
+		var ceData interface{}
+		if err := unmarshalPrecise(data, &ceData); err == nil {
+			return DataField, ceData
+		}
+	}
+
+	return DataField, string(data)
+}
+
 // FromCloudEvent returns a map representation of an existing cloudevents JSON.
 func FromCloudEvent(cloudEvent []byte, topic, pubsub, traceParent string, traceState string) (map[string]interface{}, error) {
 	var m map[string]interface{}
@@ -198,4 +247,138 @@ func ApplyMetadata(cloudEvent map[string]interface{}, componentFeatures []Featur
 		expiration := now.Add(ttl)
 		cloudEvent[ExpirationField] = expiration.Format(time.RFC3339)
 	}
+
+	for k, v := range metadata {
+		if name := strings.TrimPrefix(k, CloudEventExtensionMetadataPrefix); name != k && isExtensionAttribute(name) {
+			cloudEvent[name] = v
+		}
+	}
+}
+
+// isExtensionAttribute checks that name is a valid attribute name defined neither by the
+// cloud events specification nor by Dapr.
+func isExtensionAttribute(name string) bool {
+	switch name {
+	case IDField, SourceField, SpecVersionField, TypeField, DataContentTypeField, DataSchemaField, SubjectField, TimeField, DataField,
+		TopicField, PubsubField, TraceIDField, TraceParentField, TraceStateField, ExpirationField:
+		return false
+	}
+
+	return cloudEventAttributeRegexp.MatchString(name)
+}
+
+// BinaryCloudEvent is a cloud event in binary content mode.
+type BinaryCloudEvent struct {
+	// Headers holds the attributes but datacontenttype, prefixed for the protocol.
+	Headers map[string]string
+	// ContentType is the datacontenttype attribute, carried by the content type of the message.
+	ContentType string
+	// Data is the payload of the message.
+	Data []byte
+}
+
+// ToBinaryCloudEvent converts a structured cloud event to binary content mode.
+// Attributes that are not strings are encoded as JSON.
+func ToBinaryCloudEvent(cloudEvent map[string]interface{}, headerPrefix string) (*BinaryCloudEvent, error) {
+	b := &BinaryCloudEvent{Headers: make(map[string]string, len(cloudEvent))}
+
+	for k, v := range cloudEvent {
+		switch k {
+		case DataField, DataBase64Field:
+			continue
+		case DataContentTypeField:
+			b.ContentType = fmt.Sprint(v)
+
+			continue
+		}
+
+		var value string
+		switch t := v.(type) {
+		case nil:
+		case string:
+			value = t
+		case json.Number:
+			value = t.String()
+		default:
+			encoded, err := json.Marshal(t)
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode cloud event attribute %s: %w", k, err)
+			}
+			value = string(encoded)
+		}
+		if value != "" {
+			b.Headers[headerPrefix+k] = value
+		}
+	}
+
+	if v, ok := cloudEvent[DataBase64Field]; ok {
+		encoded, _ := v.(string)
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode cloud event %s: %w", DataBase64Field, err)
+		}
+		b.Data = data
+	} else if v, ok := cloudEvent[DataField]; ok && v != nil {
+		if str, isString := v.(string); isString && !contrib_contenttype.IsJSONContentType(b.ContentType) {
+			b.Data = []byte(str)
+		} else {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode cloud event %s: %w", DataField, err)
+			}
+			b.Data = data
+		}
+	}
+
+	return b, nil
+}
+
+// BinaryCloudEventFromJSON converts a structured cloud event serialized as JSON to binary content mode.
+// It returns an error if data is not a cloud event.
+func BinaryCloudEventFromJSON(data []byte, headerPrefix string) (*BinaryCloudEvent, error) {
+	var cloudEvent map[string]interface{}
+	if err := unmarshalPrecise(data, &cloudEvent); err != nil {
+		return nil, err
+	}
+	if _, ok := cloudEvent[SpecVersionField]; !ok {
+		return nil, fmt.Errorf("missing %s attribute", SpecVersionField)
+	}
+
+	return ToBinaryCloudEvent(cloudEvent, headerPrefix)
+}
+
+// IsBinaryCloudEvent checks whether the headers of a message carry a cloud event in binary content mode.
+func IsBinaryCloudEvent(headers map[string]string, headerPrefix string) bool {
+	_, ok := headers[headerPrefix+SpecVersionField]
+
+	return ok
+}
+
+// FromBinaryCloudEvent converts a message in binary content mode to a structured cloud event.
+// Headers without the prefix, or not named like attributes, are ignored. It returns
+// ErrNotBinaryCloudEvent if the headers do not carry a cloud event.
+func FromBinaryCloudEvent(headers map[string]string, contentType string, data []byte, headerPrefix string) (map[string]interface{}, error) {
+	if !IsBinaryCloudEvent(headers, headerPrefix) {
+		return nil, ErrNotBinaryCloudEvent
+	}
+
+	cloudEvent := make(map[string]interface{}, len(headers)+2)
+	for k, v := range headers {
+		if !strings.HasPrefix(k, headerPrefix) {
+			continue
+		}
+		if name := strings.TrimPrefix(k, headerPrefix); cloudEventAttributeRegexp.MatchString(name) {
+			cloudEvent[name] = v
+		}
+	}
+
+	if contentType != "" {
+		cloudEvent[DataContentTypeField] = contentType
+	}
+	if len(data) > 0 {
+		field, value := cloudEventData(contentType, data)
+		cloudEvent[field] = value
+	}
+
+	return cloudEvent, nil
 }
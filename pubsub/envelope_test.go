@@ -327,3 +327,105 @@ func TestNewFromRawPayload(t *testing.T) {
 		assert.Equal(t, "aGVsbG8gd29ybGQ=", n[DataBase64Field])
 	})
 }
+
+func TestExtensionAttributes(t *testing.T) {
+	envelope := NewCloudEventsEnvelope("a", "source", "", "", "orders", "pubsub", "", []byte("data"), "00-trace", "state")
+	ApplyMetadata(envelope, nil, map[string]string{
+		"cloudevent.partitionkey": "p1",
+		"cloudevent.id":           "overridden",
+		"cloudevent.topic":        "overridden",
+		"cloudevent.pubsubname":   "overridden",
+		"cloudevent.traceparent":  "overridden",
+		"cloudevent.tracestate":   "overridden",
+		"cloudevent.expiration":   "overridden",
+		"cloudevent.Invalid-Name": "x",
+		"other":                   "y",
+	})
+
+	assert.Equal(t, "p1", envelope["partitionkey"])
+	assert.Equal(t, "a", envelope[IDField])
+	assert.Equal(t, "orders", envelope[TopicField])
+	assert.Equal(t, "pubsub", envelope[PubsubField])
+	assert.Equal(t, "00-trace", envelope[TraceParentField])
+	assert.Equal(t, "state", envelope[TraceStateField])
+	assert.Nil(t, envelope[ExpirationField])
+	assert.Nil(t, envelope["Invalid-Name"])
+	assert.Nil(t, envelope["other"])
+}
+
+func TestCloudEventsContentMode(t *testing.T) {
+	mode, err := CloudEventsContentMode(map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, CloudEventsStructured, mode)
+
+	mode, err = CloudEventsContentMode(map[string]string{CloudEventsModeKey: "binary"})
+	assert.NoError(t, err)
+	assert.Equal(t, CloudEventsBinary, mode)
+
+	_, err = CloudEventsContentMode(map[string]string{CloudEventsModeKey: "batched"})
+	assert.Error(t, err)
+}
+
+func TestBinaryCloudEvent(t *testing.T) {
+	t.Run("json data", func(t *testing.T) {
+		ce, err := BinaryCloudEventFromJSON([]byte(`{"specversion":"1.0","id":"a1","type":"t","datacontenttype":"application/json","data":{"n":1.50},"count":2,"flag":true}`), KafkaCloudEventHeaderPrefix)
+		assert.NoError(t, err)
+		assert.Equal(t, "application/json", ce.ContentType)
+		assert.Equal(t, `{"n":1.50}`, string(ce.Data))
+		assert.Equal(t, map[string]string{
+			"ce_specversion": "1.0",
+			"ce_id":          "a1",
+			"ce_type":        "t",
+			"ce_count":       "2",
+			"ce_flag":        "true",
+		}, ce.Headers)
+	})
+
+	t.Run("string data", func(t *testing.T) {
+		ce, err := BinaryCloudEventFromJSON([]byte(`{"specversion":"1.0","id":"a1","datacontenttype":"text/plain","data":"hello"}`), AMQPCloudEventHeaderPrefix)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(ce.Data))
+		assert.Equal(t, "a1", ce.Headers["cloudEvents:id"])
+	})
+
+	t.Run("base64 data", func(t *testing.T) {
+		ce, err := BinaryCloudEventFromJSON([]byte(`{"specversion":"1.0","id":"a1","datacontenttype":"application/octet-stream","data_base64":"AQI="}`), "")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x1, 0x2}, ce.Data)
+	})
+
+	t.Run("not a cloud event", func(t *testing.T) {
+		_, err := BinaryCloudEventFromJSON([]byte(`{"id":"a1"}`), "")
+		assert.Error(t, err)
+		_, err = BinaryCloudEventFromJSON([]byte("hello"), "")
+		assert.Error(t, err)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		headers := map[string]string{
+			"ce_specversion": "1.0",
+			"ce_id":          "a1",
+			"ce_source":      "app",
+			"partitionKey":   "k",
+		}
+		assert.True(t, IsBinaryCloudEvent(headers, KafkaCloudEventHeaderPrefix))
+		assert.False(t, IsBinaryCloudEvent(headers, AMQPCloudEventHeaderPrefix))
+
+		ce, err := FromBinaryCloudEvent(headers, "application/json", []byte(`{"n":1}`), KafkaCloudEventHeaderPrefix)
+		assert.NoError(t, err)
+		assert.Equal(t, "1.0", ce[SpecVersionField])
+		assert.Equal(t, "a1", ce[IDField])
+		assert.Equal(t, "application/json", ce[DataContentTypeField])
+		assert.Nil(t, ce["partitionKey"])
+
+		b, err := ToBinaryCloudEvent(ce, KafkaCloudEventHeaderPrefix)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"n":1}`, string(b.Data))
+		assert.Equal(t, "app", b.Headers["ce_source"])
+	})
+
+	t.Run("missing specversion", func(t *testing.T) {
+		_, err := FromBinaryCloudEvent(map[string]string{"ce_id": "a1"}, "", nil, KafkaCloudEventHeaderPrefix)
+		assert.ErrorIs(t, err, ErrNotBinaryCloudEvent)
+	})
+}
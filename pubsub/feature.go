@@ -16,6 +16,8 @@ package pubsub
 const (
 	// FeatureMessageTTL is the feature to handle message TTL.
 	FeatureMessageTTL Feature = "MESSAGE_TTL"
+	// FeatureDelayedDelivery is the feature to deliver messages at a future time.
+	FeatureDelayedDelivery Feature = "DELAYED_DELIVERY"
 )
 
 // Feature names a feature that can be implemented by PubSub components.
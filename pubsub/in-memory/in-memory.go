@@ -15,19 +15,73 @@ package inmemory
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
 
-	"github.com/asaskevich/EventBus"
+	"github.com/google/uuid"
 
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
+const (
+	// consumerGroupKey is the subscription metadata key of the consumer group.
+	// Subscriptions of a topic sharing a consumer group compete for its messages,
+	// subscriptions without one receive every message.
+	consumerGroupKey = "consumerGroup"
+	// deadLetterTopicKey is the subscription metadata key of the topic receiving
+	// the messages the subscription failed to process after all retries.
+	deadLetterTopicKey = "deadLetterTopic"
+	// recordMessagesKey enables keeping the published and acked messages for Inspector.
+	// They are kept until Reset, so it is meant for tests.
+	recordMessagesKey = "recordMessages"
+
+	defaultMaxRetries = 9
+)
+
+var errClosed = errors.New("in-memory pubsub is closed")
+
+type subscriber struct {
+	handler         pubsub.Handler
+	deadLetterTopic string
+}
+
+// consumerGroup delivers each message of a topic to one of its subscribers.
+type consumerGroup struct {
+	name        string
+	subscribers []*subscriber
+	next        uint32
+	executor    *pubsub.PartitionedExecutor
+}
+
 type bus struct {
-	bus EventBus.Bus
-	ctx context.Context
-	log logger.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	log    logger.Logger
+
+	concurrencyMode    pubsub.ConcurrencyMode
+	partitionedWorkers int
+	backOffConfig      retry.Config
+	recordMessages     bool
+
+	groups    map[string][]*consumerGroup
+	timers    map[*time.Timer]struct{}
+	published []Message
+	acked     []AckedMessage
+	stats     pubsub.StatsRecorder
+	closed    bool
+	lock      sync.Mutex
 }
 
+// New returns the in-memory pub/sub. Messages are delivered from the publishing goroutine,
+// unless concurrencyMode is partitioned. The returned pub/sub implements Inspector, which
+// sees messages once recordMessages is enabled.
 func New(logger logger.Logger) pubsub.PubSub {
 	return &bus{
 		log: logger,
@@ -35,36 +89,310 @@ func New(logger logger.Logger) pubsub.PubSub {
 }
 
 func (a *bus) Close() error {
+	a.lock.Lock()
+	a.closed = true
+	if a.cancel != nil {
+		a.cancel()
+	}
+	for timer := range a.timers {
+		timer.Stop()
+	}
+	a.timers = nil
+
+	var executors []*pubsub.PartitionedExecutor
+	for _, groups := range a.groups {
+		for _, group := range groups {
+			if group.executor != nil {
+				executors = append(executors, group.executor)
+			}
+		}
+	}
+	a.groups = nil
+	a.lock.Unlock()
+
+	// Queued deliveries need the lock to complete.
+	for _, executor := range executors {
+		executor.Close()
+	}
+
 	return nil
 }
 
 func (a *bus) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureDelayedDelivery, pubsub.FeatureMessageTTL}
 }
 
 func (a *bus) Init(metadata pubsub.Metadata) error {
-	a.bus = EventBus.New()
-	a.ctx = context.Background()
+	concurrencyMode, err := pubsub.Concurrency(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	partitionedWorkers, err := pubsub.PartitionedWorkers(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
+	// Retry immediately by default, the backOff properties override it.
+	backOffConfig := retry.DefaultConfig()
+	backOffConfig.Duration = 0
+	backOffConfig.MaxRetries = defaultMaxRetries
+	if err = retry.DecodeConfigWithPrefix(&backOffConfig, metadata.Properties, "backOff"); err != nil {
+		return err
+	}
+
+	recordMessages := false
+	if val := metadata.Properties[recordMessagesKey]; val != "" {
+		if recordMessages, err = strconv.ParseBool(val); err != nil {
+			return fmt.Errorf("in-memory pubsub: invalid %s %s", recordMessagesKey, val)
+		}
+	}
+
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	a.recordMessages = recordMessages
+	a.concurrencyMode = concurrencyMode
+	a.partitionedWorkers = partitionedWorkers
+	a.backOffConfig = backOffConfig
+	a.groups = make(map[string][]*consumerGroup)
+	a.timers = make(map[*time.Timer]struct{})
 
 	return nil
 }
 
 func (a *bus) Publish(req *pubsub.PublishRequest) error {
-	a.bus.Publish(req.Topic, a.ctx, req.Data)
+	now := time.Now()
+	deliverAt, delayed, err := contrib_metadata.TryGetDeliverAt(req.Metadata, now)
+	if err != nil {
+		return err
+	}
+	ttl, hasTTL, err := contrib_metadata.TryGetTTL(req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	msg := Message{
+		ID:          uuid.New().String(),
+		Topic:       req.Topic,
+		Data:        req.Data,
+		Metadata:    copyMetadata(req.Metadata),
+		ContentType: req.ContentType,
+		PublishedAt: now,
+	}
+	if hasTTL {
+		// The time to live of a delayed message starts when it is due.
+		start := now
+		if delayed && deliverAt.After(now) {
+			start = deliverAt
+		}
+		msg.ExpiresAt = start.Add(ttl)
+	}
+
+	a.lock.Lock()
+	if a.groups == nil {
+		a.lock.Unlock()
+
+		return errClosed
+	}
+	if a.recordMessages {
+		a.published = append(a.published, msg)
+	}
+	a.lock.Unlock()
+
+	if delayed {
+		if delay := time.Until(deliverAt); delay > 0 {
+			return a.publishAfter(delay, msg)
+		}
+	}
+
+	a.route(msg)
 
 	return nil
 }
 
-func (a *bus) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
-	return a.bus.Subscribe(req.Topic, func(ctx context.Context, data []byte) {
-		for i := 0; i < 10; i++ {
-			if err := handler(ctx, &pubsub.NewMessage{Data: data, Topic: req.Topic, Metadata: req.Metadata}); err != nil {
-				a.log.Error(err)
+// publishAfter holds a delayed message in memory until it is due.
+func (a *bus) publishAfter(delay time.Duration, msg Message) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
 
-				continue
-			}
+	// The pub/sub may have been closed since the message was published.
+	if a.closed {
+		return errClosed
+	}
 
-			return
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		a.lock.Lock()
+		_, pending := a.timers[timer]
+		delete(a.timers, timer)
+		a.lock.Unlock()
+
+		if pending {
+			a.route(msg)
 		}
 	})
+	a.timers[timer] = struct{}{}
+
+	return nil
+}
+
+// route hands msg to every consumer group of its topic.
+func (a *bus) route(msg Message) {
+	a.lock.Lock()
+	groups := append([]*consumerGroup(nil), a.groups[msg.Topic]...)
+	a.lock.Unlock()
+
+	for _, group := range groups {
+		a.stats.AddLag(msg.Topic, 1)
+		if group.executor == nil {
+			a.deliver(group, msg)
+
+			continue
+		}
+
+		group := group
+		err := group.executor.Submit(a.ctx, msg.Metadata[pubsub.PartitionKeyMetadata], func() {
+			a.deliver(group, msg)
+		})
+		if err != nil {
+			a.stats.AddLag(msg.Topic, -1)
+			a.log.Errorf("in-memory pubsub: error delivering message %s of topic %s: %s", msg.ID, msg.Topic, err)
+		}
+	}
+}
+
+// deliver runs the handler of one subscriber of the group, retrying failures according
+// to the backOff properties. Messages still failing go to the dead letter topic, if any.
+func (a *bus) deliver(group *consumerGroup, msg Message) {
+	a.stats.AddLag(msg.Topic, -1)
+
+	if !msg.ExpiresAt.IsZero() && time.Now().After(msg.ExpiresAt) {
+		a.log.Debugf("in-memory pubsub: message %s of topic %s expired", msg.ID, msg.Topic)
+
+		return
+	}
+
+	sub := a.pick(group, msg.Metadata[pubsub.PartitionKeyMetadata])
+	newMsg := &pubsub.NewMessage{
+		Data:        msg.Data,
+		Topic:       msg.Topic,
+		Metadata:    copyMetadata(msg.Metadata),
+		ContentType: msg.ContentType,
+	}
+
+	b := a.backOffConfig.NewBackOffWithContext(a.ctx)
+	err := retry.NotifyRecover(func() error {
+		return sub.handler(a.ctx, newMsg)
+	}, b, func(err error, d time.Duration) {
+		a.stats.Redelivered(msg.Topic, 1)
+		a.log.Errorf("in-memory pubsub: error processing message %s of topic %s, retrying in %s: %s", msg.ID, msg.Topic, d, err)
+	}, func() {
+		a.log.Infof("in-memory pubsub: successfully processed message %s of topic %s after it previously failed", msg.ID, msg.Topic)
+	})
+	if err == nil {
+		a.lock.Lock()
+		if a.recordMessages {
+			a.acked = append(a.acked, AckedMessage{Message: msg, ConsumerGroup: group.name})
+		}
+		a.lock.Unlock()
+
+		return
+	}
+
+	if sub.deadLetterTopic == "" {
+		a.log.Errorf("in-memory pubsub: dropping message %s of topic %s: %s", msg.ID, msg.Topic, err)
+
+		return
+	}
+
+	// The dead letter copy is delivered right away and does not expire.
+	metadata := copyMetadata(msg.Metadata)
+	delete(metadata, contrib_metadata.DeliverAtMetadataKey)
+	delete(metadata, contrib_metadata.DelaySecondsMetadataKey)
+	delete(metadata, contrib_metadata.TTLMetadataKey)
+
+	a.log.Warnf("in-memory pubsub: moving message %s of topic %s to dead letter topic %s", msg.ID, msg.Topic, sub.deadLetterTopic)
+	if err = a.Publish(&pubsub.PublishRequest{
+		Data:        msg.Data,
+		Topic:       sub.deadLetterTopic,
+		Metadata:    metadata,
+		ContentType: msg.ContentType,
+	}); err != nil {
+		a.log.Errorf("in-memory pubsub: error moving message %s of topic %s to dead letter topic %s: %s", msg.ID, msg.Topic, sub.deadLetterTopic, err)
+	}
+}
+
+// pick selects the subscriber of the group receiving a message. In partitioned mode,
+// messages with the same key go to the same subscriber so that their order is kept.
+func (a *bus) pick(group *consumerGroup, key string) *subscriber {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	n := uint32(len(group.subscribers))
+	if key != "" && group.executor != nil {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+
+		return group.subscribers[h.Sum32()%n]
+	}
+
+	sub := group.subscribers[group.next%n]
+	group.next++
+
+	return sub
+}
+
+func (a *bus) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	sub := &subscriber{
+		handler:         a.stats.Handler(req.Topic, handler),
+		deadLetterTopic: req.Metadata[deadLetterTopicKey],
+	}
+	name := req.Metadata[consumerGroupKey]
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.groups == nil {
+		return errClosed
+	}
+
+	if name != "" {
+		for _, group := range a.groups[req.Topic] {
+			if group.name == name {
+				group.subscribers = append(group.subscribers, sub)
+
+				return nil
+			}
+		}
+	}
+
+	group := &consumerGroup{
+		name:        name,
+		subscribers: []*subscriber{sub},
+	}
+	if a.concurrencyMode == pubsub.Partitioned {
+		group.executor = pubsub.NewPartitionedExecutor(a.partitionedWorkers, 1)
+	}
+	a.groups[req.Topic] = append(a.groups[req.Topic], group)
+
+	return nil
+}
+
+var _ pubsub.StatsProvider = (*bus)(nil)
+
+// Stats reports the statistics of the subscribed topics. The lag of a topic is the number of
+// its messages waiting for a partitioned worker, delayed messages are not counted.
+func (a *bus) Stats(ctx context.Context) (map[string]pubsub.TopicStats, error) {
+	return a.stats.Stats(), nil
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		c[k] = v
+	}
+
+	return c
 }
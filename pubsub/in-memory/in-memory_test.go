@@ -16,9 +16,13 @@ package inmemory
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
@@ -78,8 +82,287 @@ func TestRetry(t *testing.T) {
 	assert.Equal(t, 5, i)
 }
 
+func TestPartitioned(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{Properties: map[string]string{
+		pubsub.ConcurrencyKey: string(pubsub.Partitioned),
+	}})
+
+	var wg sync.WaitGroup
+	wg.Add(20)
+	var lock sync.Mutex
+	received := map[string][]string{}
+	bus.Subscribe(pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		defer wg.Done()
+		lock.Lock()
+		defer lock.Unlock()
+		key := string(msg.Data[:1])
+		received[key] = append(received[key], string(msg.Data))
+
+		return nil
+	})
+
+	for i := 0; i < 10; i++ {
+		for _, key := range []string{"a", "b"} {
+			bus.Publish(&pubsub.PublishRequest{
+				Data:     []byte(fmt.Sprintf("%s%d", key, i)),
+				Topic:    "demo",
+				Metadata: map[string]string{pubsub.PartitionKeyMetadata: key},
+			})
+		}
+	}
+	wg.Wait()
+	bus.Close()
+
+	for _, key := range []string{"a", "b"} {
+		expected := make([]string, 10)
+		for i := range expected {
+			expected[i] = fmt.Sprintf("%s%d", key, i)
+		}
+		assert.Equal(t, expected, received[key])
+	}
+}
+
+func TestDelayedDelivery(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{})
+	assert.True(t, pubsub.FeatureDelayedDelivery.IsPresent(bus.Features()))
+
+	ch := make(chan []byte)
+	bus.Subscribe(pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return publish(ch, msg)
+	})
+
+	published := time.Now()
+	bus.Publish(&pubsub.PublishRequest{
+		Data:     []byte("ABCD"),
+		Topic:    "demo",
+		Metadata: map[string]string{"deliverAt": published.Add(2 * time.Second).Format(time.RFC3339)},
+	})
+
+	select {
+	case <-ch:
+		assert.Fail(t, "message delivered before it was due")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Equal(t, "ABCD", string(<-ch))
+	assert.WithinDuration(t, published.Add(2*time.Second), time.Now(), time.Second)
+}
+
+func TestPublishAfterClose(t *testing.T) {
+	a := New(logger.NewLogger("test")).(*bus)
+	a.Init(pubsub.Metadata{})
+	a.Close()
+
+	err := a.publishAfter(time.Second, Message{Topic: "demo"})
+	assert.ErrorIs(t, err, errClosed)
+}
+
+func TestConsumerGroups(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{Properties: map[string]string{recordMessagesKey: "true"}})
+
+	var lock sync.Mutex
+	received := map[string]int{}
+	subscribe := func(name, group string) {
+		bus.Subscribe(pubsub.SubscribeRequest{
+			Topic:    "demo",
+			Metadata: map[string]string{"consumerGroup": group},
+		}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			lock.Lock()
+			defer lock.Unlock()
+			received[name]++
+
+			return nil
+		})
+	}
+	subscribe("a1", "a")
+	subscribe("a2", "a")
+	subscribe("b1", "b")
+
+	for i := 0; i < 10; i++ {
+		bus.Publish(&pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "demo"})
+	}
+
+	assert.Equal(t, map[string]int{"a1": 5, "a2": 5, "b1": 10}, received)
+	assert.Len(t, bus.(Inspector).Acked("demo"), 20)
+}
+
+func TestMetadataAndContentType(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{})
+
+	var received *pubsub.NewMessage
+	bus.Subscribe(pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = msg
+
+		return nil
+	})
+
+	contentType := "application/json"
+	bus.Publish(&pubsub.PublishRequest{
+		Data:        []byte(`{"a":1}`),
+		Topic:       "demo",
+		Metadata:    map[string]string{"traceid": "123"},
+		ContentType: &contentType,
+	})
+
+	assert.Equal(t, map[string]string{"traceid": "123"}, received.Metadata)
+	assert.Equal(t, &contentType, received.ContentType)
+}
+
+func TestMessageTTL(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{Properties: map[string]string{
+		pubsub.ConcurrencyKey: string(pubsub.Partitioned),
+		recordMessagesKey:     "true",
+	}})
+	assert.True(t, pubsub.FeatureMessageTTL.IsPresent(bus.Features()))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.Subscribe(pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		if string(msg.Data) == "slow" {
+			time.Sleep(1500 * time.Millisecond)
+			wg.Done()
+		}
+
+		return nil
+	})
+
+	// The second message expires while it waits behind the first one.
+	for _, data := range []string{"slow", "expiring", "lasting"} {
+		metadata := map[string]string{pubsub.PartitionKeyMetadata: "key"}
+		if data == "expiring" {
+			metadata["ttlInSeconds"] = "1"
+		}
+		bus.Publish(&pubsub.PublishRequest{Data: []byte(data), Topic: "demo", Metadata: metadata})
+	}
+	wg.Wait()
+	bus.Close()
+
+	published := bus.(Inspector).Published("demo")
+	assert.Len(t, published, 3)
+	assert.WithinDuration(t, published[1].PublishedAt.Add(time.Second), published[1].ExpiresAt, 0)
+
+	var acked []string
+	for _, msg := range bus.(Inspector).Acked("demo") {
+		acked = append(acked, string(msg.Data))
+	}
+	assert.Equal(t, []string{"slow", "lasting"}, acked)
+}
+
+func TestDeadLetterTopic(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{Properties: map[string]string{
+		"backOffMaxRetries": "2",
+		recordMessagesKey:   "true",
+	}})
+
+	attempts := 0
+	bus.Subscribe(pubsub.SubscribeRequest{
+		Topic:    "demo",
+		Metadata: map[string]string{"deadLetterTopic": "poison"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		attempts++
+
+		return errors.New("cannot process")
+	})
+
+	var deadLetter *pubsub.NewMessage
+	bus.Subscribe(pubsub.SubscribeRequest{Topic: "poison"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		deadLetter = msg
+
+		return nil
+	})
+
+	bus.Publish(&pubsub.PublishRequest{
+		Data:     []byte("ABCD"),
+		Topic:    "demo",
+		Metadata: map[string]string{"ttlInSeconds": "60", "traceid": "123"},
+	})
+
+	assert.Equal(t, 3, attempts)
+	assert.Empty(t, bus.(Inspector).Acked("demo"))
+	assert.Equal(t, "ABCD", string(deadLetter.Data))
+	assert.Equal(t, map[string]string{"traceid": "123"}, deadLetter.Metadata)
+	assert.Len(t, bus.(Inspector).Published("poison"), 1)
+
+	bus.(Inspector).Reset()
+	assert.Empty(t, bus.(Inspector).Published("demo"))
+}
+
 func publish(ch chan []byte, msg *pubsub.NewMessage) error {
 	go func() { ch <- msg.Data }()
 
 	return nil
 }
+
+func TestStats(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	bus.Init(pubsub.Metadata{Properties: map[string]string{
+		"concurrencyMode":    "partitioned",
+		"partitionedWorkers": "1",
+		"backOffMaxRetries":  "1",
+		recordMessagesKey:    "true",
+	}})
+	defer bus.Close()
+
+	release := make(chan struct{})
+	bus.Subscribe(pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		<-release
+		if string(msg.Data) == "bad" {
+			return errors.New("cannot process")
+		}
+
+		return nil
+	})
+
+	stats := func() pubsub.TopicStats {
+		s, err := bus.(pubsub.StatsProvider).Stats(context.Background())
+		assert.NoError(t, err)
+
+		return s["demo"]
+	}
+
+	// The bad message blocks the only worker while the good one waits.
+	bus.Publish(&pubsub.PublishRequest{Topic: "demo", Data: []byte("bad")})
+	bus.Publish(&pubsub.PublishRequest{Topic: "demo", Data: []byte("good")})
+	assert.Eventually(t, func() bool {
+		s := stats()
+
+		return s.InFlight == 1 && s.Lag == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The bad message is retried once.
+	for i := 0; i < 3; i++ {
+		release <- struct{}{}
+	}
+	assert.Eventually(t, func() bool {
+		return len(bus.(Inspector).Acked("demo")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s := stats()
+	assert.Equal(t, int64(0), s.Lag)
+	assert.Equal(t, int64(1), s.Redeliveries)
+	assert.Equal(t, "cannot process", s.LastError)
+}
+
+func TestRecordMessages(t *testing.T) {
+	bus := New(logger.NewLogger("test"))
+	require.NoError(t, bus.Init(pubsub.Metadata{}))
+	defer bus.Close()
+
+	bus.Subscribe(pubsub.SubscribeRequest{Topic: "demo"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	})
+	require.NoError(t, bus.Publish(&pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "demo"}))
+
+	// Messages are not kept unless enabled.
+	assert.Empty(t, bus.(Inspector).Published("demo"))
+	assert.Empty(t, bus.(Inspector).Acked("demo"))
+
+	err := New(logger.NewLogger("test")).Init(pubsub.Metadata{Properties: map[string]string{recordMessagesKey: "maybe"}})
+	assert.Error(t, err)
+}
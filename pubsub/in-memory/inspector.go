@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inmemory
+
+import "time"
+
+// Message is a message published to the in-memory pub/sub.
+type Message struct {
+	ID          string
+	Topic       string
+	Data        []byte
+	Metadata    map[string]string
+	ContentType *string
+	PublishedAt time.Time
+	// ExpiresAt is zero unless the message was published with a time to live.
+	ExpiresAt time.Time
+}
+
+// AckedMessage is a message successfully processed by a subscriber.
+type AckedMessage struct {
+	Message
+	// ConsumerGroup is the group of the subscriber, empty if it has none.
+	ConsumerGroup string
+}
+
+// Inspector lets tests assert on the messages that went through the in-memory pub/sub.
+// Messages are only kept when the recordMessages metadata property is true, until Reset is called.
+type Inspector interface {
+	// Published returns the messages published to topic, in publishing order.
+	Published(topic string) []Message
+	// Acked returns the messages of topic processed successfully, in processing order.
+	Acked(topic string) []AckedMessage
+	// Reset forgets all the published and acked messages.
+	Reset()
+}
+
+var _ Inspector = (*bus)(nil)
+
+func (a *bus) Published(topic string) []Message {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var messages []Message
+	for _, msg := range a.published {
+		if msg.Topic == topic {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages
+}
+
+func (a *bus) Acked(topic string) []AckedMessage {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var messages []AckedMessage
+	for _, msg := range a.acked {
+		if msg.Topic == topic {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages
+}
+
+func (a *bus) Reset() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.published = nil
+	a.acked = nil
+}
@@ -16,11 +16,13 @@ package jetstream
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
-	"github.com/nats-io/nkeys"
 
+	contrib_nats "github.com/dapr/components-contrib/internal/component/nats"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -35,10 +37,17 @@ type jetstreamPubSub struct {
 	ctx           context.Context
 	ctxCancel     context.CancelFunc
 	backOffConfig retry.Config
+
+	stats     pubsub.StatsRecorder
+	subs      map[string]*nats.Subscription
+	subsMutex sync.Mutex
 }
 
 func NewJetStream(logger logger.Logger) pubsub.PubSub {
-	return &jetstreamPubSub{l: logger}
+	return &jetstreamPubSub{
+		l:    logger,
+		subs: make(map[string]*nats.Subscription),
+	}
 }
 
 func (js *jetstreamPubSub) Init(metadata pubsub.Metadata) error {
@@ -52,13 +61,8 @@ func (js *jetstreamPubSub) Init(metadata pubsub.Metadata) error {
 	opts = append(opts, nats.Name(js.meta.name))
 
 	// Set nats.UserJWT options when jwt and seed key is provided.
-	if js.meta.jwt != "" && js.meta.seedKey != "" {
-		opts = append(opts, nats.UserJWT(func() (string, error) {
-			return js.meta.jwt, nil
-		}, func(nonce []byte) ([]byte, error) {
-			return sigHandler(js.meta.seedKey, nonce)
-		}))
-	}
+	auth := contrib_nats.Auth{JWT: js.meta.jwt, SeedKey: js.meta.seedKey}
+	opts = append(opts, auth.Options()...)
 
 	js.nc, err = nats.Connect(js.meta.natsURL, opts...)
 	if err != nil {
@@ -104,20 +108,17 @@ func (js *jetstreamPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub
 		opts = append(opts, nats.Durable(v))
 	}
 
-	if v := js.meta.startTime; !v.IsZero() {
-		opts = append(opts, nats.StartTime(v))
-	} else if v := js.meta.startSequence; v > 0 {
-		opts = append(opts, nats.StartSequence(v))
-	} else if js.meta.deliverAll {
-		opts = append(opts, nats.DeliverAll())
-	} else {
-		opts = append(opts, nats.DeliverLast())
+	startFrom, err := pubsub.StartFrom(req.Metadata)
+	if err != nil {
+		return err
 	}
+	opts = append(opts, js.deliverPolicy(startFrom))
 
 	if js.meta.flowControl {
 		opts = append(opts, nats.EnableFlowControl())
 	}
 
+	handler = js.stats.Handler(req.Topic, handler)
 	natsHandler := func(m *nats.Msg) {
 		jsm, err := m.Metadata()
 		if err != nil {
@@ -127,6 +128,9 @@ func (js *jetstreamPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub
 
 			return
 		}
+		if jsm.NumDelivered > 1 {
+			js.stats.Redelivered(req.Topic, 1)
+		}
 
 		operation := func() error {
 			js.l.Debugf("Processing JetStream message %s/%d", m.Subject,
@@ -161,34 +165,78 @@ func (js *jetstreamPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub
 		}
 	}
 
-	var err error
+	var sub *nats.Subscription
 	if queue := js.meta.queueGroupName; queue != "" {
 		js.l.Debugf("nats: subscribed to subject %s with queue group %s",
 			req.Topic, js.meta.queueGroupName)
-		_, err = js.jsc.QueueSubscribe(req.Topic, queue, natsHandler, opts...)
+		sub, err = js.jsc.QueueSubscribe(req.Topic, queue, natsHandler, opts...)
 	} else {
 		js.l.Debugf("nats: subscribed to subject %s", req.Topic)
-		_, err = js.jsc.Subscribe(req.Topic, natsHandler, opts...)
+		sub, err = js.jsc.Subscribe(req.Topic, natsHandler, opts...)
+	}
+	if err != nil {
+		return err
 	}
 
-	return err
+	js.subsMutex.Lock()
+	js.subs[req.Topic] = sub
+	js.subsMutex.Unlock()
+
+	return nil
 }
 
-func (js *jetstreamPubSub) Close() error {
-	js.ctxCancel()
+var _ pubsub.StatsProvider = (*jetstreamPubSub)(nil)
 
-	return js.nc.Drain()
+// Stats reports the statistics of the subscribed subjects, with the messages pending
+// for their consumer as lag.
+func (js *jetstreamPubSub) Stats(ctx context.Context) (map[string]pubsub.TopicStats, error) {
+	js.subsMutex.Lock()
+	defer js.subsMutex.Unlock()
+
+	stats := js.stats.Stats()
+	for topic, sub := range js.subs {
+		info, err := sub.ConsumerInfo()
+		if err != nil {
+			return nil, fmt.Errorf("nats: error retrieving consumer info of subject %s: %w", topic, err)
+		}
+		s := stats[topic]
+		s.Lag = int64(info.NumPending)
+		stats[topic] = s
+	}
+
+	return stats, nil
 }
 
-// Handle nats signature request for challenge response authentication.
-func sigHandler(seedKey string, nonce []byte) ([]byte, error) {
-	kp, err := nkeys.FromSeed([]byte(seedKey))
-	if err != nil {
-		return nil, err
+// deliverPolicy returns the option selecting the first message delivered to a subscription.
+// The startFrom of the subscription takes precedence over the component settings. It does not move
+// an existing durable consumer, which keeps its position.
+func (js *jetstreamPubSub) deliverPolicy(startFrom *pubsub.StartPosition) nats.SubOpt {
+	if startFrom != nil {
+		switch startFrom.Kind {
+		case pubsub.StartFromEarliest:
+			return nats.DeliverAll()
+		case pubsub.StartFromLatest:
+			return nats.DeliverNew()
+		case pubsub.StartFromTime:
+			return nats.StartTime(startFrom.Time)
+		case pubsub.StartFromSequence:
+			return nats.StartSequence(startFrom.Sequence)
+		}
 	}
-	// Wipe our key on exit.
-	defer kp.Wipe()
 
-	sig, _ := kp.Sign(nonce)
-	return sig, nil
+	if v := js.meta.startTime; !v.IsZero() {
+		return nats.StartTime(v)
+	} else if v := js.meta.startSequence; v > 0 {
+		return nats.StartSequence(v)
+	} else if js.meta.deliverAll {
+		return nats.DeliverAll()
+	}
+
+	return nats.DeliverLast()
+}
+
+func (js *jetstreamPubSub) Close() error {
+	js.ctxCancel()
+
+	return js.nc.Drain()
 }
@@ -18,6 +18,7 @@ import (
 	"strconv"
 	"time"
 
+	contrib_nats "github.com/dapr/components-contrib/internal/component/nats"
 	"github.com/dapr/components-contrib/pubsub"
 )
 
@@ -44,16 +45,12 @@ func parseMetadata(psm pubsub.Metadata) (metadata, error) {
 		return metadata{}, fmt.Errorf("missing nats URL")
 	}
 
-	m.jwt = psm.Properties["jwt"]
-	m.seedKey = psm.Properties["seedKey"]
-
-	if m.jwt != "" && m.seedKey == "" {
-		return metadata{}, fmt.Errorf("missing seed key")
-	}
-
-	if m.jwt == "" && m.seedKey != "" {
-		return metadata{}, fmt.Errorf("missing jwt")
+	auth, err := contrib_nats.ParseAuth(psm.Properties)
+	if err != nil {
+		return metadata{}, err
 	}
+	m.jwt = auth.JWT
+	m.seedKey = auth.SeedKey
 
 	if m.name = psm.Properties["name"]; m.name == "" {
 		m.name = "dapr.io - pubsub.jetstream"
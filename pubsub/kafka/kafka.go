@@ -15,27 +15,47 @@ package kafka
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/dapr/kit/logger"
 
+	"github.com/dapr/components-contrib/contenttype"
 	"github.com/dapr/components-contrib/internal/component/kafka"
 	"github.com/dapr/components-contrib/pubsub"
 )
 
+// contentTypeHeader carries the datacontenttype of cloud events in binary content mode.
+const contentTypeHeader = "content-type"
+
 type PubSub struct {
-	kafka  *kafka.Kafka
-	topics map[string]bool
+	kafka           *kafka.Kafka
+	topics          map[string]bool
+	cloudEventsMode pubsub.CloudEventsMode
 }
 
 func (p *PubSub) Init(metadata pubsub.Metadata) error {
+	mode, err := pubsub.CloudEventsContentMode(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	p.cloudEventsMode = mode
+
 	p.topics = make(map[string]bool)
 	return p.kafka.Init(metadata.Properties)
 }
 
 func (p *PubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	startFrom, err := pubsub.StartFrom(req.Metadata)
+	if err != nil {
+		return err
+	}
+	if startFrom != nil {
+		p.kafka.SetStartPosition(req.Topic, startFrom)
+	}
+
 	topics := p.addTopic(req.Topic)
 
-	return p.kafka.Subscribe(topics, req.Metadata, newSubscribeAdapter(handler).adapter)
+	return p.kafka.Subscribe(topics, req.Metadata, newSubscribeAdapter(handler, p.cloudEventsMode).adapter)
 }
 
 func (p *PubSub) addTopic(newTopic string) []string {
@@ -64,7 +84,26 @@ func NewKafka(logger logger.Logger) pubsub.PubSub {
 }
 
 // Publish message to Kafka cluster.
+// In binary content mode, the attributes of cloud events are sent as `ce_` headers.
 func (p *PubSub) Publish(req *pubsub.PublishRequest) error {
+	if p.cloudEventsMode == pubsub.CloudEventsBinary {
+		// Raw payloads are not cloud events and are sent as they are.
+		if ce, err := pubsub.BinaryCloudEventFromJSON(req.Data, pubsub.KafkaCloudEventHeaderPrefix); err == nil {
+			metadata := make(map[string]string, len(req.Metadata)+len(ce.Headers)+1)
+			for k, v := range req.Metadata {
+				metadata[k] = v
+			}
+			for k, v := range ce.Headers {
+				metadata[k] = v
+			}
+			if ce.ContentType != "" {
+				metadata[contentTypeHeader] = ce.ContentType
+			}
+
+			return p.kafka.Publish(req.Topic, ce.Data, metadata)
+		}
+	}
+
 	return p.kafka.Publish(req.Topic, req.Data, req.Metadata)
 }
 
@@ -72,20 +111,48 @@ func (p *PubSub) Close() (err error) {
 	return p.kafka.Close()
 }
 
+var _ pubsub.StatsProvider = (*PubSub)(nil)
+
+// Stats reports the statistics of the subscribed topics.
+func (p *PubSub) Stats(ctx context.Context) (map[string]pubsub.TopicStats, error) {
+	return p.kafka.Stats(), nil
+}
+
 func (p *PubSub) Features() []pubsub.Feature {
 	return nil
 }
 
 // subscribeAdapter is used to adapter pubsub.Handler to kafka.EventHandler with the same content.
+// In binary content mode, it turns cloud events carried by headers back into structured ones.
 type subscribeAdapter struct {
-	handler pubsub.Handler
+	handler         pubsub.Handler
+	cloudEventsMode pubsub.CloudEventsMode
 }
 
-func newSubscribeAdapter(handler pubsub.Handler) *subscribeAdapter {
-	return &subscribeAdapter{handler: handler}
+func newSubscribeAdapter(handler pubsub.Handler, cloudEventsMode pubsub.CloudEventsMode) *subscribeAdapter {
+	return &subscribeAdapter{handler: handler, cloudEventsMode: cloudEventsMode}
 }
 
 func (a *subscribeAdapter) adapter(ctx context.Context, event *kafka.NewEvent) error {
+	if a.cloudEventsMode == pubsub.CloudEventsBinary && pubsub.IsBinaryCloudEvent(event.Metadata, pubsub.KafkaCloudEventHeaderPrefix) {
+		ce, err := pubsub.FromBinaryCloudEvent(event.Metadata, event.Metadata[contentTypeHeader], event.Data, pubsub.KafkaCloudEventHeaderPrefix)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(ce)
+		if err != nil {
+			return err
+		}
+		contentType := contenttype.CloudEventContentType
+
+		return a.handler(ctx, &pubsub.NewMessage{
+			Topic:       event.Topic,
+			Data:        data,
+			Metadata:    event.Metadata,
+			ContentType: &contentType,
+		})
+	}
+
 	return a.handler(ctx, &pubsub.NewMessage{
 		Topic:       event.Topic,
 		Data:        event.Data,
@@ -38,7 +38,7 @@ func TestSubscribeAdapter(t *testing.T) {
 	a := testAdapter{ctx: ctx, event: event, t: t}
 
 	// step2: call this adapter method to mock the new kafka event is triggered from kafka topic
-	err := newSubscribeAdapter(a.testHandler).adapter(ctx, event)
+	err := newSubscribeAdapter(a.testHandler, pubsub.CloudEventsStructured).adapter(ctx, event)
 	assert.NoError(t, err)
 }
 
@@ -58,3 +58,28 @@ func (a *testAdapter) testHandler(ctx context.Context, msg *pubsub.NewMessage) e
 
 	return nil
 }
+
+func TestSubscribeAdapterBinaryCloudEvent(t *testing.T) {
+	event := &kafka.NewEvent{
+		Topic: "topic1",
+		Data:  []byte("hello"),
+		Metadata: map[string]string{
+			"ce_specversion": "1.0",
+			"ce_id":          "a1",
+			"ce_source":      "app",
+			"ce_traceparent": "00-1",
+			"content-type":   "text/plain",
+		},
+	}
+
+	var received *pubsub.NewMessage
+	err := newSubscribeAdapter(func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = msg
+
+		return nil
+	}, pubsub.CloudEventsBinary).adapter(context.Background(), event)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "application/cloudevents+json", *received.ContentType)
+	assert.JSONEq(t, `{"specversion":"1.0","id":"a1","source":"app","traceparent":"00-1","datacontenttype":"text/plain","data":"hello"}`, string(received.Data))
+}
@@ -13,6 +13,8 @@ limitations under the License.
 
 package mqtt
 
+import "github.com/dapr/components-contrib/pubsub"
+
 type metadata struct {
 	tlsCfg
 	url               string
@@ -21,6 +23,8 @@ type metadata struct {
 	retain            bool
 	cleanSession      bool
 	backOffMaxRetries int
+	protocolVersion   int
+	cloudEventsMode   pubsub.CloudEventsMode
 }
 
 type tlsCfg struct {
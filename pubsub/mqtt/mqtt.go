@@ -21,12 +21,16 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 
+	"github.com/dapr/components-contrib/internal/component/mqtt5"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -44,6 +48,11 @@ const (
 	mqttClientKey         = "clientKey"
 	mqttBackOffMaxRetries = "backOffMaxRetries"
 
+	// mqttTopicMetadata is the metadata key of the topic a received message was published to.
+	mqttTopicMetadata = "mqttTopic"
+	// sharedSubscriptionPrefix starts the topic of shared subscriptions, `$share/<group>/<filter>`.
+	sharedSubscriptionPrefix = mqtt5.SharedSubscriptionPrefix
+
 	// errors.
 	errorMsgPrefix = "mqtt pub sub error:"
 
@@ -58,6 +67,9 @@ const (
 type mqttPubSub struct {
 	producer   mqtt.Client
 	consumer   mqtt.Client
+	producer5  *autopaho.ConnectionManager
+	consumer5  *autopaho.ConnectionManager
+	router5    *paho.StandardRouter
 	metadata   *metadata
 	logger     logger.Logger
 	topics     map[string]pubsub.Handler
@@ -152,9 +164,49 @@ func parseMQTTMetaData(md pubsub.Metadata) (*metadata, error) {
 		m.backOffMaxRetries = backOffMaxRetriesInt
 	}
 
+	protocolVersion, err := mqtt5.ParseProtocolVersion(md.Properties)
+	if err != nil {
+		return &m, fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+	m.protocolVersion = protocolVersion
+
+	// Binary cloud events need the properties of MQTT 5 messages.
+	m.cloudEventsMode, err = pubsub.CloudEventsContentMode(md.Properties)
+	if err != nil {
+		return &m, fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+	if m.cloudEventsMode == pubsub.CloudEventsBinary && m.protocolVersion != 5 {
+		return &m, fmt.Errorf("%s %s %s requires %s 5", errorMsgPrefix, pubsub.CloudEventsModeKey, pubsub.CloudEventsBinary, mqtt5.ProtocolVersionKey)
+	}
+
 	return &m, nil
 }
 
+// validateTopicFilter checks the wildcards of a subscription topic and the group of
+// a shared subscription.
+func validateTopicFilter(topic string) error {
+	filter := topic
+	if strings.HasPrefix(topic, sharedSubscriptionPrefix) {
+		parts := strings.SplitN(strings.TrimPrefix(topic, sharedSubscriptionPrefix), "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.ContainsAny(parts[0], "+#") {
+			return fmt.Errorf("%s invalid shared subscription %s, expected $share/<group>/<topic>", errorMsgPrefix, topic)
+		}
+		filter = parts[1]
+	}
+
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		switch {
+		case level == "#" && i != len(levels)-1:
+			return fmt.Errorf("%s invalid topic %s, # must be the last level", errorMsgPrefix, topic)
+		case level != "#" && level != "+" && strings.ContainsAny(level, "+#"):
+			return fmt.Errorf("%s invalid topic %s, wildcards must occupy an entire level", errorMsgPrefix, topic)
+		}
+	}
+
+	return nil
+}
+
 // Init parses metadata and creates a new Pub Sub client.
 func (m *mqttPubSub) Init(metadata pubsub.Metadata) error {
 	mqttMeta, err := parseMQTTMetaData(metadata)
@@ -164,6 +216,11 @@ func (m *mqttPubSub) Init(metadata pubsub.Metadata) error {
 	m.metadata = mqttMeta
 
 	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.topics = make(map[string]pubsub.Handler)
+
+	if m.metadata.protocolVersion == 5 {
+		return m.initV5()
+	}
 
 	// mqtt broker allows only one connection at a given time from a clientID.
 	producerClientID := fmt.Sprintf("%s-producer", m.metadata.clientID)
@@ -175,7 +232,6 @@ func (m *mqttPubSub) Init(metadata pubsub.Metadata) error {
 	}
 
 	m.producer = p
-	m.topics = make(map[string]pubsub.Handler)
 
 	m.logger.Debug("mqtt message bus initialization complete")
 
@@ -188,6 +244,14 @@ func (m *mqttPubSub) Publish(req *pubsub.PublishRequest) error {
 	// m.logger.Debugf("mqtt publishing topic %s with data: %v", req.Topic, req.Data)
 	m.logger.Debugf("mqtt publishing topic %s", req.Topic)
 
+	if strings.ContainsAny(req.Topic, "+#") || strings.HasPrefix(req.Topic, sharedSubscriptionPrefix) {
+		return fmt.Errorf("%s cannot publish to topic filter %s", errorMsgPrefix, req.Topic)
+	}
+
+	if m.metadata.protocolVersion == 5 {
+		return m.publishV5(req)
+	}
+
 	token := m.producer.Publish(req.Topic, m.metadata.qos, m.metadata.retain, req.Data)
 	if !token.WaitTimeout(defaultWait) || token.Error() != nil {
 		return fmt.Errorf("mqtt error from publish: %v", token.Error())
@@ -198,6 +262,14 @@ func (m *mqttPubSub) Publish(req *pubsub.PublishRequest) error {
 
 // Subscribe to the mqtt pub sub topic.
 func (m *mqttPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if err := validateTopicFilter(req.Topic); err != nil {
+		return err
+	}
+
+	if m.metadata.protocolVersion == 5 {
+		return m.subscribeV5(req, handler)
+	}
+
 	m.topicsLock.Lock()
 	defer m.topicsLock.Unlock()
 
@@ -227,48 +299,12 @@ func (m *mqttPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handl
 		subscribeTopics[k] = m.metadata.qos
 	}
 
-	token := m.consumer.SubscribeMultiple(
-		subscribeTopics,
-		func(client mqtt.Client, mqttMsg mqtt.Message) {
-			mqttMsg.AutoAckOff()
-			msg := pubsub.NewMessage{
-				Topic: mqttMsg.Topic(),
-				Data:  mqttMsg.Payload(),
-			}
-
-			m.topicsLock.RLock()
-			topicHandler, ok := m.topics[msg.Topic]
-			m.topicsLock.RUnlock()
-			if !ok || topicHandler == nil {
-				m.logger.Errorf("no handler defined for topic %s", msg.Topic)
-				return
-			}
-
-			// TODO: Make the backoff configurable for constant or exponential
-			var b backoff.BackOff = backoff.NewConstantBackOff(5 * time.Second)
-			b = backoff.WithContext(b, m.ctx)
-			if m.metadata.backOffMaxRetries >= 0 {
-				b = backoff.WithMaxRetries(b, uint64(m.metadata.backOffMaxRetries))
-			}
-			if err := retry.NotifyRecover(func() error {
-				m.logger.Debugf("Processing MQTT message %s/%d", mqttMsg.Topic(), mqttMsg.MessageID())
-
-				if err := topicHandler(m.ctx, &msg); err != nil {
-					return err
-				}
-
-				mqttMsg.Ack()
-
-				return nil
-			}, b, func(err error, d time.Duration) {
-				m.logger.Errorf("Error processing MQTT message: %s/%d. Retrying...", mqttMsg.Topic(), mqttMsg.MessageID())
-			}, func() {
-				m.logger.Infof("Successfully processed MQTT message after it previously failed: %s/%d", mqttMsg.Topic(), mqttMsg.MessageID())
-			}); err != nil {
-				m.logger.Errorf("Failed processing MQTT message: %s/%d: %v", mqttMsg.Topic(), mqttMsg.MessageID(), err)
-			}
-		},
-	)
+	// Each subscription gets its own route, the client matches the topic of incoming
+	// messages against wildcard and shared subscription filters.
+	for topic, topicHandler := range m.topics {
+		m.consumer.AddRoute(topic, m.onMessage(topic, topicHandler))
+	}
+	token := m.consumer.SubscribeMultiple(subscribeTopics, nil)
 	subscribeCtx, subscribeCancel := context.WithTimeout(m.ctx, defaultWait)
 	defer subscribeCancel()
 	select {
@@ -284,6 +320,55 @@ func (m *mqttPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handl
 	return nil
 }
 
+// onMessage returns the callback delivering the messages of a subscription to its handler.
+// The message topic is the subscription topic, as it may be a filter, and the topic the
+// message was published to is in the metadata.
+func (m *mqttPubSub) onMessage(topic string, handler pubsub.Handler) mqtt.MessageHandler {
+	return func(client mqtt.Client, mqttMsg mqtt.Message) {
+		mqttMsg.AutoAckOff()
+		msg := pubsub.NewMessage{
+			Topic:    topic,
+			Data:     mqttMsg.Payload(),
+			Metadata: map[string]string{mqttTopicMetadata: mqttMsg.Topic()},
+		}
+
+		if err := m.handleMessage(handler, &msg, mqttMsg.MessageID(), mqttMsg.Ack); err != nil {
+			m.logger.Errorf("Failed processing MQTT message: %s/%d: %v", mqttMsg.Topic(), mqttMsg.MessageID(), err)
+		}
+	}
+}
+
+// handleMessage runs the handler of a message, with retries, and acknowledges it once handled.
+func (m *mqttPubSub) handleMessage(handler pubsub.Handler, msg *pubsub.NewMessage, messageID uint16, ack func()) error {
+	topic := msg.Metadata[mqttTopicMetadata]
+
+	// TODO: Make the backoff configurable for constant or exponential
+	var b backoff.BackOff = backoff.NewConstantBackOff(5 * time.Second)
+	b = backoff.WithContext(b, m.ctx)
+	if m.metadata.backOffMaxRetries >= 0 {
+		b = backoff.WithMaxRetries(b, uint64(m.metadata.backOffMaxRetries))
+	}
+	if err := retry.NotifyRecover(func() error {
+		m.logger.Debugf("Processing MQTT message %s/%d", topic, messageID)
+
+		if err := handler(m.ctx, msg); err != nil {
+			return err
+		}
+
+		ack()
+
+		return nil
+	}, b, func(err error, d time.Duration) {
+		m.logger.Errorf("Error processing MQTT message: %s/%d. Retrying...", topic, messageID)
+	}, func() {
+		m.logger.Infof("Successfully processed MQTT message after it previously failed: %s/%d", topic, messageID)
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *mqttPubSub) connect(ctx context.Context, clientID string) (mqtt.Client, error) {
 	uri, err := url.Parse(m.metadata.url)
 	if err != nil {
@@ -353,6 +438,10 @@ func (m *mqttPubSub) createClientOptions(uri *url.URL, clientID string) *mqtt.Cl
 func (m *mqttPubSub) Close() error {
 	m.cancel()
 
+	if m.metadata.protocolVersion == 5 {
+		return m.closeV5()
+	}
+
 	if m.consumer != nil {
 		m.consumer.Disconnect(5)
 	}
@@ -362,5 +451,10 @@ func (m *mqttPubSub) Close() error {
 }
 
 func (m *mqttPubSub) Features() []pubsub.Feature {
+	// MQTT 5 brokers expire messages.
+	if m.metadata != nil && m.metadata.protocolVersion == 5 {
+		return []pubsub.Feature{pubsub.FeatureMessageTTL}
+	}
+
 	return nil
 }
@@ -0,0 +1,226 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+
+	"github.com/dapr/components-contrib/contenttype"
+	"github.com/dapr/components-contrib/internal/component/mqtt5"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// initV5 connects the producer when the component uses MQTT 5.
+func (m *mqttPubSub) initV5() error {
+	m.router5 = paho.NewStandardRouter()
+
+	// mqtt broker allows only one connection at a given time from a clientID.
+	connCtx, connCancel := context.WithTimeout(m.ctx, defaultWait)
+	defer connCancel()
+	p, err := mqtt5.Connect(connCtx, m.connectionConfigV5(fmt.Sprintf("%s-producer", m.metadata.clientID), nil, nil))
+	if err != nil {
+		return fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+	m.producer5 = p
+
+	m.logger.Debug("mqtt 5 message bus initialization complete")
+
+	return nil
+}
+
+func (m *mqttPubSub) connectionConfigV5(clientID string, router paho.Router, onConnectionUp func(*autopaho.ConnectionManager)) mqtt5.ConnectionConfig {
+	return mqtt5.ConnectionConfig{
+		URL:            m.metadata.url,
+		ClientID:       clientID,
+		CleanSession:   m.metadata.cleanSession,
+		TLSConfig:      m.newTLSConfig(),
+		Router:         router,
+		OnConnectionUp: onConnectionUp,
+		Logger:         m.logger,
+	}
+}
+
+// publishV5 sends the metadata and content type of the request as MQTT 5 properties.
+// In binary content mode, the attributes of cloud events are sent as user properties.
+func (m *mqttPubSub) publishV5(req *pubsub.PublishRequest) error {
+	payload, metadata, contentType := req.Data, req.Metadata, req.ContentType
+	if m.metadata.cloudEventsMode == pubsub.CloudEventsBinary {
+		// Raw payloads are not cloud events and are sent as they are.
+		if ce, err := pubsub.BinaryCloudEventFromJSON(req.Data, pubsub.MQTTCloudEventHeaderPrefix); err == nil {
+			metadata = make(map[string]string, len(req.Metadata)+len(ce.Headers))
+			for k, v := range req.Metadata {
+				metadata[k] = v
+			}
+			for k, v := range ce.Headers {
+				metadata[k] = v
+			}
+			payload, contentType = ce.Data, &ce.ContentType
+		}
+	}
+
+	props, err := mqtt5.PublishProperties(metadata, contentType)
+	if err != nil {
+		return fmt.Errorf("%s %s", errorMsgPrefix, err)
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, defaultWait)
+	defer cancel()
+	resp, err := m.producer5.Publish(ctx, &paho.Publish{
+		Topic:      req.Topic,
+		QoS:        m.metadata.qos,
+		Retain:     m.metadata.retain,
+		Payload:    payload,
+		Properties: props,
+	})
+	if err = mqtt5.PublishError(resp, err); err != nil {
+		return fmt.Errorf("mqtt error from publish: %v", err)
+	}
+
+	return nil
+}
+
+// subscribeV5 adds a subscription to the consumer, connecting it on the first subscription.
+// Unlike MQTT 3, the consumer stays connected and subscribes again after reconnections.
+// The MQTT 5 client acknowledges messages once their handler returns, so messages whose handler
+// fails after the retries are not delivered again: delivery is at most once.
+func (m *mqttPubSub) subscribeV5(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	m.topicsLock.Lock()
+	defer m.topicsLock.Unlock()
+
+	m.topics[req.Topic] = handler
+	m.router5.RegisterHandler(mqtt5.RouteFilter(req.Topic), m.onMessageV5(req.Topic, handler))
+
+	if m.consumer5 == nil {
+		m.logger.Infof("initializing the subscriber with topic %s", req.Topic)
+		if m.metadata.qos > 0 {
+			m.logger.Warnf("mqtt 5 subscriptions acknowledge messages even when their handler fails, messages are delivered at most once")
+		}
+
+		// mqtt broker allows only one connection at a given time from a clientID.
+		consumerClientID := fmt.Sprintf("%s-consumer", m.metadata.clientID)
+		connCtx, connCancel := context.WithTimeout(m.ctx, defaultWait)
+		c, err := mqtt5.Connect(connCtx, m.connectionConfigV5(consumerClientID, m.router5, m.resubscribeV5))
+		connCancel()
+		if err != nil {
+			return fmt.Errorf("%s %s", errorMsgPrefix, err)
+		}
+		m.consumer5 = c
+	}
+
+	subscribeCtx, subscribeCancel := context.WithTimeout(m.ctx, defaultWait)
+	defer subscribeCancel()
+	_, err := m.consumer5.Subscribe(subscribeCtx, &paho.Subscribe{
+		Subscriptions: map[string]paho.SubscribeOptions{
+			req.Topic: {QoS: m.metadata.qos},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mqtt error from subscribe: %v", err)
+	}
+
+	return nil
+}
+
+// resubscribeV5 restores the subscriptions when the consumer connection comes up.
+func (m *mqttPubSub) resubscribeV5(cm *autopaho.ConnectionManager) {
+	m.topicsLock.RLock()
+	subscriptions := make(map[string]paho.SubscribeOptions, len(m.topics))
+	for topic := range m.topics {
+		subscriptions[topic] = paho.SubscribeOptions{QoS: m.metadata.qos}
+	}
+	m.topicsLock.RUnlock()
+
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, defaultWait)
+	defer cancel()
+	if _, err := cm.Subscribe(ctx, &paho.Subscribe{Subscriptions: subscriptions}); err != nil {
+		m.logger.Errorf("mqtt error from subscribe: %v", err)
+	}
+}
+
+// onMessageV5 returns the callback delivering the MQTT 5 messages of a subscription to its handler,
+// with the message properties in the metadata.
+func (m *mqttPubSub) onMessageV5(topic string, handler pubsub.Handler) paho.MessageHandler {
+	return func(p *paho.Publish) {
+		msg := pubsub.NewMessage{
+			Topic:    topic,
+			Data:     p.Payload,
+			Metadata: mqtt5.MessageMetadata(p),
+		}
+		msg.Metadata[mqttTopicMetadata] = p.Topic
+		if p.Properties != nil && p.Properties.ContentType != "" {
+			contentType := p.Properties.ContentType
+			msg.ContentType = &contentType
+		}
+		if m.metadata.cloudEventsMode == pubsub.CloudEventsBinary {
+			if err := structuredCloudEvent(&msg); err != nil {
+				m.logger.Errorf("mqtt error decoding cloud event of message from topic %s: %s", p.Topic, err)
+			}
+		}
+
+		// The client acknowledges the message when the callback returns, whether the handler succeeded or not.
+		if err := m.handleMessage(handler, &msg, p.PacketID, func() {}); err != nil {
+			m.logger.Errorf("Failed processing MQTT message: %s/%d, the message is acknowledged and will not be delivered again: %v", p.Topic, p.PacketID, err)
+		}
+	}
+}
+
+// structuredCloudEvent replaces the data of msg with the structured cloud event carried by
+// its user properties, if it has any.
+func structuredCloudEvent(msg *pubsub.NewMessage) error {
+	if !pubsub.IsBinaryCloudEvent(msg.Metadata, pubsub.MQTTCloudEventHeaderPrefix) {
+		return nil
+	}
+
+	var contentType string
+	if msg.ContentType != nil {
+		contentType = *msg.ContentType
+	}
+	ce, err := pubsub.FromBinaryCloudEvent(msg.Metadata, contentType, msg.Data, pubsub.MQTTCloudEventHeaderPrefix)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(ce)
+	if err != nil {
+		return err
+	}
+
+	ceContentType := contenttype.CloudEventContentType
+	msg.Data = data
+	msg.ContentType = &ceContentType
+
+	return nil
+}
+
+func (m *mqttPubSub) closeV5() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultWait)
+	defer cancel()
+
+	if m.consumer5 != nil {
+		_ = m.consumer5.Disconnect(ctx)
+	}
+	if m.producer5 != nil {
+		return m.producer5.Disconnect(ctx)
+	}
+
+	return nil
+}
@@ -14,14 +14,18 @@ limitations under the License.
 package mqtt
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"testing"
 
+	"github.com/eclipse/paho.golang/paho"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
 )
 
 func getFakeProperties() map[string]string {
@@ -50,6 +54,41 @@ func TestParseMetadata(t *testing.T) {
 		assert.Equal(t, byte(1), m.qos)
 		assert.Equal(t, true, m.retain)
 		assert.Equal(t, false, m.cleanSession)
+		assert.Equal(t, 3, m.protocolVersion)
+	})
+
+	t.Run("protocol version 5", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeMetaData := pubsub.Metadata{Properties: fakeProperties}
+		fakeMetaData.Properties["protocolVersion"] = "5"
+		m, err := parseMQTTMetaData(fakeMetaData)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 5, m.protocolVersion)
+	})
+
+	t.Run("invalid protocol version", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeMetaData := pubsub.Metadata{Properties: fakeProperties}
+		fakeMetaData.Properties["protocolVersion"] = "4.5"
+		_, err := parseMQTTMetaData(fakeMetaData)
+
+		// assert
+		assert.Contains(t, err.Error(), "invalid protocolVersion")
+	})
+
+	t.Run("binary cloud events require protocol version 5", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeMetaData := pubsub.Metadata{Properties: fakeProperties}
+		fakeMetaData.Properties["cloudEventsMode"] = "binary"
+		_, err := parseMQTTMetaData(fakeMetaData)
+		assert.Error(t, err)
+
+		fakeMetaData.Properties["protocolVersion"] = "5"
+		m, err := parseMQTTMetaData(fakeMetaData)
+		assert.NoError(t, err)
+		assert.Equal(t, pubsub.CloudEventsBinary, m.cloudEventsMode)
 	})
 
 	t.Run("missing consumerID", func(t *testing.T) {
@@ -183,3 +222,150 @@ func TestParseMetadata(t *testing.T) {
 		assert.NotNil(t, m.tlsCfg.clientKey, "failed to parse valid client certificate key")
 	})
 }
+
+func TestValidateTopicFilter(t *testing.T) {
+	valid := []string{"sensors/1/temp", "sensors/+/temp", "fleet/#", "#", "+", "$share/group/fleet/#"}
+	for _, topic := range valid {
+		assert.NoError(t, validateTopicFilter(topic), topic)
+	}
+
+	invalid := []string{"fleet/#/temp", "sensors/a+/temp", "$share/group", "$share//fleet", "$share/gr+oup/fleet"}
+	for _, topic := range invalid {
+		assert.Error(t, validateTopicFilter(topic), topic)
+	}
+}
+
+func TestPublishToTopicFilter(t *testing.T) {
+	m := &mqttPubSub{logger: logger.NewLogger("test")}
+
+	for _, topic := range []string{"sensors/+/temp", "fleet/#", "$share/group/fleet"} {
+		err := m.Publish(&pubsub.PublishRequest{Topic: topic, Data: []byte("hello")})
+		assert.Error(t, err, topic)
+	}
+}
+
+type fakeMessage struct {
+	mqtt.Message
+	topic string
+	acked bool
+}
+
+func (f *fakeMessage) Topic() string     { return f.topic }
+func (f *fakeMessage) Payload() []byte   { return []byte("hello") }
+func (f *fakeMessage) MessageID() uint16 { return 1 }
+func (f *fakeMessage) AutoAckOff()       {}
+func (f *fakeMessage) Ack()              { f.acked = true }
+
+func TestOnMessage(t *testing.T) {
+	m := &mqttPubSub{
+		logger:   logger.NewLogger("test"),
+		metadata: &metadata{backOffMaxRetries: 0},
+		ctx:      context.Background(),
+	}
+
+	t.Run("wildcard subscription", func(t *testing.T) {
+		var received *pubsub.NewMessage
+		callback := m.onMessage("sensors/+/temp", func(ctx context.Context, msg *pubsub.NewMessage) error {
+			received = msg
+
+			return nil
+		})
+
+		msg := &fakeMessage{topic: "sensors/1/temp"}
+		callback(nil, msg)
+
+		assert.True(t, msg.acked)
+		assert.Equal(t, "sensors/+/temp", received.Topic)
+		assert.Equal(t, "sensors/1/temp", received.Metadata[mqttTopicMetadata])
+		assert.Equal(t, "hello", string(received.Data))
+	})
+
+	t.Run("shared subscription", func(t *testing.T) {
+		var received *pubsub.NewMessage
+		callback := m.onMessage("$share/group/fleet/#", func(ctx context.Context, msg *pubsub.NewMessage) error {
+			received = msg
+
+			return nil
+		})
+
+		msg := &fakeMessage{topic: "fleet/truck/1"}
+		callback(nil, msg)
+
+		assert.True(t, msg.acked)
+		assert.Equal(t, "$share/group/fleet/#", received.Topic)
+		assert.Equal(t, "fleet/truck/1", received.Metadata[mqttTopicMetadata])
+	})
+
+	t.Run("failed messages are not acked", func(t *testing.T) {
+		callback := m.onMessage("fleet/#", func(ctx context.Context, msg *pubsub.NewMessage) error {
+			return errors.New("failed")
+		})
+
+		msg := &fakeMessage{topic: "fleet/truck/1"}
+		callback(nil, msg)
+
+		assert.False(t, msg.acked)
+	})
+}
+
+func TestOnMessageV5(t *testing.T) {
+	m := &mqttPubSub{
+		logger:   logger.NewLogger("test"),
+		metadata: &metadata{backOffMaxRetries: 0, protocolVersion: 5},
+		ctx:      context.Background(),
+	}
+	assert.True(t, pubsub.FeatureMessageTTL.IsPresent(m.Features()))
+
+	var received *pubsub.NewMessage
+	callback := m.onMessageV5("sensors/+/temp", func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = msg
+
+		return nil
+	})
+
+	callback(&paho.Publish{
+		Topic:   "sensors/1/temp",
+		Payload: []byte("hello"),
+		Properties: &paho.PublishProperties{
+			ContentType: "text/plain",
+			User:        paho.UserProperties{{Key: "traceid", Value: "123"}},
+		},
+	})
+
+	assert.Equal(t, "sensors/+/temp", received.Topic)
+	assert.Equal(t, "hello", string(received.Data))
+	assert.Equal(t, "text/plain", *received.ContentType)
+	assert.Equal(t, "sensors/1/temp", received.Metadata[mqttTopicMetadata])
+	assert.Equal(t, "123", received.Metadata["traceid"])
+}
+
+func TestOnMessageV5BinaryCloudEvent(t *testing.T) {
+	m := &mqttPubSub{
+		logger:   logger.NewLogger("test"),
+		metadata: &metadata{backOffMaxRetries: 0, protocolVersion: 5, cloudEventsMode: pubsub.CloudEventsBinary},
+		ctx:      context.Background(),
+	}
+
+	var received *pubsub.NewMessage
+	callback := m.onMessageV5("orders", func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = msg
+
+		return nil
+	})
+
+	callback(&paho.Publish{
+		Topic:   "orders",
+		Payload: []byte(`{"orderId":1}`),
+		Properties: &paho.PublishProperties{
+			ContentType: "application/json",
+			User: paho.UserProperties{
+				{Key: "specversion", Value: "1.0"},
+				{Key: "id", Value: "a1"},
+				{Key: "type", Value: "order.created"},
+			},
+		},
+	})
+
+	assert.Equal(t, "application/cloudevents+json", *received.ContentType)
+	assert.JSONEq(t, `{"specversion":"1.0","id":"a1","type":"order.created","datacontenttype":"application/json","data":{"orderId":1}}`, string(received.Data))
+}
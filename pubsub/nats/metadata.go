@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"fmt"
+
+	contrib_nats "github.com/dapr/components-contrib/internal/component/nats"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	// queueGroupKey is the subscription metadata key of the queue group, overriding queueGroupName.
+	// Subscriptions sharing a queue group receive each message once between them.
+	queueGroupKey = "queueGroup"
+)
+
+type metadata struct {
+	natsURL string
+	auth    contrib_nats.Auth
+
+	name           string
+	queueGroupName string
+}
+
+func parseMetadata(psm pubsub.Metadata) (metadata, error) {
+	var m metadata
+
+	if v, ok := psm.Properties["natsURL"]; ok && v != "" {
+		m.natsURL = v
+	} else {
+		return metadata{}, fmt.Errorf("missing nats URL")
+	}
+
+	auth, err := contrib_nats.ParseAuth(psm.Properties)
+	if err != nil {
+		return metadata{}, err
+	}
+	m.auth = auth
+
+	if m.name = psm.Properties["name"]; m.name == "" {
+		m.name = "dapr.io - pubsub.nats"
+	}
+
+	m.queueGroupName = psm.Properties["queueGroupName"]
+
+	return m, nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nats is a pub/sub on NATS core subjects. Messages are not persisted:
+// they are delivered at most once, to the subscribers connected when they are published.
+package nats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	contrib_nats "github.com/dapr/components-contrib/internal/component/nats"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
+)
+
+// topicMetadata is the metadata key of the subject of received messages,
+// which differs from the topic of subscriptions with wildcards.
+const topicMetadata = "Topic"
+
+type natsPubSub struct {
+	nc   *nats.Conn
+	l    logger.Logger
+	meta metadata
+
+	ctx           context.Context
+	ctxCancel     context.CancelFunc
+	backOffConfig retry.Config
+}
+
+func NewNATS(logger logger.Logger) pubsub.PubSub {
+	return &natsPubSub{l: logger}
+}
+
+func (n *natsPubSub) Init(metadata pubsub.Metadata) error {
+	var err error
+	n.meta, err = parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	// Default retry configuration is used if no backOff properties are set.
+	if err = retry.DecodeConfigWithPrefix(
+		&n.backOffConfig,
+		metadata.Properties,
+		"backOff"); err != nil {
+		return err
+	}
+
+	opts := []nats.Option{nats.Name(n.meta.name)}
+	opts = append(opts, n.meta.auth.Options()...)
+
+	n.nc, err = nats.Connect(n.meta.natsURL, opts...)
+	if err != nil {
+		return err
+	}
+	n.l.Debugf("Connected to nats at %s", n.meta.natsURL)
+
+	n.ctx, n.ctxCancel = context.WithCancel(context.Background())
+
+	n.l.Debug("NATS initialization complete")
+
+	return nil
+}
+
+func (n *natsPubSub) Features() []pubsub.Feature {
+	return nil
+}
+
+// Publish sends the message with its metadata as headers. It returns once the message
+// is written to the connection, without waiting for subscribers.
+func (n *natsPubSub) Publish(req *pubsub.PublishRequest) error {
+	if err := contrib_nats.ValidatePublishSubject(req.Topic); err != nil {
+		return err
+	}
+
+	n.l.Debugf("Publishing topic %v with data: %v", req.Topic, req.Data)
+
+	return n.nc.PublishMsg(&nats.Msg{
+		Subject: req.Topic,
+		Data:    req.Data,
+		Header:  contrib_nats.Header(req.Metadata),
+	})
+}
+
+// Subscribe listens to a subject, which can contain the `*` and `>` wildcards.
+// With a queue group, each message is received by one of the subscriptions of the group.
+func (n *natsPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	natsHandler := func(m *nats.Msg) {
+		msg := &pubsub.NewMessage{
+			Topic:    req.Topic,
+			Data:     m.Data,
+			Metadata: contrib_nats.Metadata(m.Header),
+		}
+		msg.Metadata[topicMetadata] = m.Subject
+
+		operation := func() error {
+			n.l.Debugf("Processing NATS message on subject %s", m.Subject)
+
+			return handler(n.ctx, msg)
+		}
+		notify := func(nerr error, d time.Duration) {
+			n.l.Errorf("Error processing NATS message on subject %s. Retrying...", m.Subject)
+		}
+		recovered := func() {
+			n.l.Infof("Successfully processed NATS message on subject %s after it previously failed", m.Subject)
+		}
+		backOff := n.backOffConfig.NewBackOffWithContext(n.ctx)
+
+		// NATS core does not redeliver messages, they are lost once retries are exhausted.
+		err := retry.NotifyRecover(operation, backOff, notify, recovered)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			n.l.Errorf("Error processing message and retries are exhausted, dropping it: %s.", m.Subject)
+		}
+	}
+
+	queue := n.meta.queueGroupName
+	if v := req.Metadata[queueGroupKey]; v != "" {
+		queue = v
+	}
+
+	var err error
+	if queue != "" {
+		n.l.Debugf("nats: subscribed to subject %s with queue group %s", req.Topic, queue)
+		_, err = n.nc.QueueSubscribe(req.Topic, queue, natsHandler)
+	} else {
+		n.l.Debugf("nats: subscribed to subject %s", req.Topic)
+		_, err = n.nc.Subscribe(req.Topic, natsHandler)
+	}
+
+	return err
+}
+
+func (n *natsPubSub) Close() error {
+	n.ctxCancel()
+
+	return n.nc.Drain()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	m, err := parseMetadata(pubsub.Metadata{Properties: map[string]string{
+		"natsURL":        "nats://localhost:4222",
+		"queueGroupName": "workers",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "nats://localhost:4222", m.natsURL)
+	assert.Equal(t, "workers", m.queueGroupName)
+	assert.Equal(t, "dapr.io - pubsub.nats", m.name)
+
+	_, err = parseMetadata(pubsub.Metadata{Properties: map[string]string{}})
+	assert.Error(t, err)
+
+	_, err = parseMetadata(pubsub.Metadata{Properties: map[string]string{
+		"natsURL": "nats://localhost:4222",
+		"jwt":     "token",
+	}})
+	assert.Error(t, err)
+}
+
+// collector records the messages received by a subscription.
+type collector struct {
+	messages []*pubsub.NewMessage
+	lock     sync.Mutex
+}
+
+func (c *collector) handle(ctx context.Context, msg *pubsub.NewMessage) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.messages = append(c.messages, msg)
+
+	return nil
+}
+
+func (c *collector) count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.messages)
+}
+
+func newNATSPubSub(t *testing.T, url string) pubsub.PubSub {
+	t.Helper()
+
+	ps := NewNATS(logger.NewLogger("test"))
+	require.NoError(t, ps.Init(pubsub.Metadata{Properties: map[string]string{"natsURL": url}}))
+	t.Cleanup(func() { ps.Close() })
+
+	return ps
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+
+	ps := newNATSPubSub(t, s.ClientURL())
+
+	t.Run("wildcard subscription", func(t *testing.T) {
+		c := &collector{}
+		require.NoError(t, ps.Subscribe(pubsub.SubscribeRequest{Topic: "sensors.*.temp"}, c.handle))
+
+		err := ps.Publish(&pubsub.PublishRequest{
+			Topic:    "sensors.1.temp",
+			Data:     []byte("21"),
+			Metadata: map[string]string{"unit": "celsius"},
+		})
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return c.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+		msg := c.messages[0]
+		assert.Equal(t, "sensors.*.temp", msg.Topic)
+		assert.Equal(t, []byte("21"), msg.Data)
+		assert.Equal(t, "sensors.1.temp", msg.Metadata[topicMetadata])
+		assert.Equal(t, "celsius", msg.Metadata["unit"])
+	})
+
+	t.Run("queue group", func(t *testing.T) {
+		c1, c2, all := &collector{}, &collector{}, &collector{}
+		queue := map[string]string{queueGroupKey: "workers"}
+		require.NoError(t, ps.Subscribe(pubsub.SubscribeRequest{Topic: "jobs", Metadata: queue}, c1.handle))
+		require.NoError(t, ps.Subscribe(pubsub.SubscribeRequest{Topic: "jobs", Metadata: queue}, c2.handle))
+		require.NoError(t, ps.Subscribe(pubsub.SubscribeRequest{Topic: "jobs"}, all.handle))
+
+		for i := 0; i < 20; i++ {
+			require.NoError(t, ps.Publish(&pubsub.PublishRequest{Topic: "jobs", Data: []byte("job")}))
+		}
+
+		assert.Eventually(t, func() bool { return c1.count()+c2.count() == 20 && all.count() == 20 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("publish to wildcard subject", func(t *testing.T) {
+		assert.Error(t, ps.Publish(&pubsub.PublishRequest{Topic: "sensors.>", Data: []byte("21")}))
+	})
+}
@@ -184,6 +184,9 @@ func parseNATSStreamingMetadata(meta pubsub.Metadata) (metadata, error) {
 	if err != nil {
 		return m, fmt.Errorf("nats-streaming error: can't parse %s: %s", pubsub.ConcurrencyKey, err)
 	}
+	if c == pubsub.Partitioned {
+		return m, fmt.Errorf("nats-streaming error: %s %s is not supported", pubsub.ConcurrencyKey, c)
+	}
 
 	m.concurrencyMode = c
 	return m, nil
@@ -233,7 +236,12 @@ func (n *natsStreamingPubSub) Publish(req *pubsub.PublishRequest) error {
 }
 
 func (n *natsStreamingPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
-	natStreamingsubscriptionOptions, err := n.subscriptionOptions()
+	startFrom, err := pubsub.StartFrom(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("nats-streaming: error getting subscription options %s", err)
+	}
+
+	natStreamingsubscriptionOptions, err := n.subscriptionOptions(startFrom)
 	if err != nil {
 		return fmt.Errorf("nats-streaming: error getting subscription options %s", err)
 	}
@@ -279,7 +287,10 @@ func (n *natsStreamingPubSub) Subscribe(req pubsub.SubscribeRequest, handler pub
 	return nil
 }
 
-func (n *natsStreamingPubSub) subscriptionOptions() ([]stan.SubscriptionOption, error) {
+// subscriptionOptions returns the options of a subscription.
+// The startFrom of the subscription, when set, takes precedence over the start position of the component.
+// It does not move an existing durable subscription, which resumes where it stopped.
+func (n *natsStreamingPubSub) subscriptionOptions(startFrom *pubsub.StartPosition) ([]stan.SubscriptionOption, error) {
 	var options []stan.SubscriptionOption
 
 	if n.metadata.durableSubscriptionName != "" {
@@ -287,6 +298,14 @@ func (n *natsStreamingPubSub) subscriptionOptions() ([]stan.SubscriptionOption,
 	}
 
 	switch {
+	case startFrom != nil && startFrom.Kind == pubsub.StartFromEarliest:
+		options = append(options, stan.DeliverAllAvailable())
+	case startFrom != nil && startFrom.Kind == pubsub.StartFromLatest:
+		options = append(options, stan.StartAt(pb.StartPosition_NewOnly))
+	case startFrom != nil && startFrom.Kind == pubsub.StartFromTime:
+		options = append(options, stan.StartAtTime(startFrom.Time))
+	case startFrom != nil && startFrom.Kind == pubsub.StartFromSequence:
+		options = append(options, stan.StartAtSequence(startFrom.Sequence))
 	case n.metadata.deliverNew == deliverNewTrue:
 		options = append(options, stan.StartAt(pb.StartPosition_NewOnly))
 	case n.metadata.startAtSequence >= 1: // messages index start from 1, this is a valid check
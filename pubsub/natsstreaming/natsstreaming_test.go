@@ -18,6 +18,8 @@ import (
 	"testing"
 	"time"
 
+	stan "github.com/nats-io/stan.go"
+	"github.com/nats-io/stan.go/pb"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/dapr/components-contrib/pubsub"
@@ -285,6 +287,19 @@ func TestParseNATSStreamingMetadata(t *testing.T) {
 		_, err := parseNATSStreamingMetadata(fakeMetaData)
 		assert.Empty(t, err)
 	})
+	t.Run("partitioned concurrency mode is not supported", func(t *testing.T) {
+		fakeProperties := map[string]string{
+			natsURL:                "nats://foo.bar:4222",
+			natsStreamingClusterID: "testcluster",
+			consumerID:             "consumer1",
+			pubsub.ConcurrencyKey:  string(pubsub.Partitioned),
+		}
+		fakeMetaData := pubsub.Metadata{
+			Properties: fakeProperties,
+		}
+		_, err := parseNATSStreamingMetadata(fakeMetaData)
+		assert.NotEmpty(t, err)
+	})
 	t.Run("invalid value for subscription type", func(t *testing.T) {
 		fakeProperties := map[string]string{
 			natsURL:                "nats://foo.bar:4222",
@@ -353,7 +368,7 @@ func TestSubscriptionOptionsForValidOptions(t *testing.T) {
 	for _, _test := range tests {
 		t.Run(_test.name, func(t *testing.T) {
 			natsStreaming := natsStreamingPubSub{metadata: _test.m}
-			opts, err := natsStreaming.subscriptionOptions()
+			opts, err := natsStreaming.subscriptionOptions(nil)
 			assert.Empty(t, err)
 			assert.NotEmpty(t, opts)
 			assert.Equal(t, _test.expectedNumberOfOptions, len(opts))
@@ -379,7 +394,7 @@ func TestSubscriptionOptionsForInvalidOptions(t *testing.T) {
 	for _, _test := range tests {
 		t.Run(_test.name, func(t *testing.T) {
 			natsStreaming := natsStreamingPubSub{metadata: _test.m}
-			opts, err := natsStreaming.subscriptionOptions()
+			opts, err := natsStreaming.subscriptionOptions(nil)
 			assert.Empty(t, err)
 			assert.NotEmpty(t, opts)
 			assert.Equal(t, 1, len(opts))
@@ -391,7 +406,7 @@ func TestSubscriptionOptions(t *testing.T) {
 	// general
 	t.Run("manual ACK option is present by default", func(t *testing.T) {
 		natsStreaming := natsStreamingPubSub{metadata: metadata{}}
-		opts, err := natsStreaming.subscriptionOptions()
+		opts, err := natsStreaming.subscriptionOptions(nil)
 		assert.Empty(t, err)
 		assert.NotEmpty(t, opts)
 		assert.Equal(t, 1, len(opts))
@@ -400,18 +415,33 @@ func TestSubscriptionOptions(t *testing.T) {
 	t.Run("only one subscription option will be honored", func(t *testing.T) {
 		m := metadata{deliverNew: deliverNewTrue, deliverAll: deliverAllTrue, startAtTimeDelta: 1 * time.Hour}
 		natsStreaming := natsStreamingPubSub{metadata: m}
-		opts, err := natsStreaming.subscriptionOptions()
+		opts, err := natsStreaming.subscriptionOptions(nil)
 		assert.Empty(t, err)
 		assert.NotEmpty(t, opts)
 		assert.Equal(t, 2, len(opts))
 	})
 
+	t.Run("startFrom overrides the start position of the component", func(t *testing.T) {
+		m := metadata{deliverAll: deliverAllTrue}
+		natsStreaming := natsStreamingPubSub{metadata: m}
+		opts, err := natsStreaming.subscriptionOptions(&pubsub.StartPosition{Kind: pubsub.StartFromSequence, Sequence: 42})
+		assert.Empty(t, err)
+		assert.Equal(t, 2, len(opts))
+
+		var options stan.SubscriptionOptions
+		for _, opt := range opts {
+			assert.NoError(t, opt(&options))
+		}
+		assert.Equal(t, uint64(42), options.StartSequence)
+		assert.Equal(t, pb.StartPosition_SequenceStart, options.StartAt)
+	})
+
 	// invalid subscription options
 
 	t.Run("startAtTime is invalid", func(t *testing.T) {
 		m := metadata{startAtTime: "foobar", startAtTimeFormat: "Jan 2, 2006 at 3:04pm (MST)"}
 		natsStreaming := natsStreamingPubSub{metadata: m}
-		opts, err := natsStreaming.subscriptionOptions()
+		opts, err := natsStreaming.subscriptionOptions(nil)
 		assert.NotEmpty(t, err)
 		assert.Nil(t, opts)
 	})
@@ -420,7 +450,7 @@ func TestSubscriptionOptions(t *testing.T) {
 		m := metadata{startAtTime: "Feb 3, 2013 at 7:54pm (PST)", startAtTimeFormat: "foo"}
 
 		natsStreaming := natsStreamingPubSub{metadata: m}
-		opts, err := natsStreaming.subscriptionOptions()
+		opts, err := natsStreaming.subscriptionOptions(nil)
 		assert.NotEmpty(t, err)
 		assert.Nil(t, opts)
 	})
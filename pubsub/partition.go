@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// PartitionKeyMetadata is the metadata key name carrying the ordering key of a message.
+const PartitionKeyMetadata = "partitionKey"
+
+// ErrExecutorClosed is returned when submitting work to a closed PartitionedExecutor.
+var ErrExecutorClosed = errors.New("partitioned executor is closed")
+
+// PartitionedExecutor runs functions on a fixed set of workers. Functions submitted with
+// the same key always run on the same worker, in submission order, while functions with
+// different keys may run in parallel. Functions with an empty key have no ordering
+// guarantee and are spread across the workers.
+type PartitionedExecutor struct {
+	workers []chan func()
+	next    uint32
+	wg      sync.WaitGroup
+	lock    sync.RWMutex
+	closed  bool
+}
+
+// NewPartitionedExecutor starts an executor with the given number of workers, each
+// buffering up to queueDepth pending functions.
+func NewPartitionedExecutor(workers int, queueDepth int) *PartitionedExecutor {
+	if workers <= 0 {
+		workers = DefaultPartitionedWorkers
+	}
+	if queueDepth < 0 {
+		queueDepth = 0
+	}
+
+	e := &PartitionedExecutor{
+		workers: make([]chan func(), workers),
+	}
+	for i := range e.workers {
+		ch := make(chan func(), queueDepth)
+		e.workers[i] = ch
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			for fn := range ch {
+				fn()
+			}
+		}()
+	}
+
+	return e
+}
+
+// Submit queues fn on the worker that owns key. It blocks while that worker's queue is full,
+// until ctx is done.
+func (e *PartitionedExecutor) Submit(ctx context.Context, key string, fn func()) error {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if e.closed {
+		return ErrExecutorClosed
+	}
+
+	select {
+	case e.workers[e.workerFor(key)] <- fn:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting new functions and waits for the queued ones to complete.
+func (e *PartitionedExecutor) Close() {
+	e.lock.Lock()
+	if e.closed {
+		e.lock.Unlock()
+
+		return
+	}
+	e.closed = true
+	for _, ch := range e.workers {
+		close(ch)
+	}
+	e.lock.Unlock()
+
+	e.wg.Wait()
+}
+
+func (e *PartitionedExecutor) workerFor(key string) int {
+	if key == "" {
+		return int(atomic.AddUint32(&e.next, 1) % uint32(len(e.workers)))
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(len(e.workers)))
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionedExecutor(t *testing.T) {
+	t.Run("same key is processed in order", func(t *testing.T) {
+		e := NewPartitionedExecutor(4, 10)
+
+		var lock sync.Mutex
+		got := map[string][]int{}
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("key-%d", i%5)
+			n := i
+			err := e.Submit(context.Background(), key, func() {
+				lock.Lock()
+				got[key] = append(got[key], n)
+				lock.Unlock()
+			})
+			assert.NoError(t, err)
+		}
+		e.Close()
+
+		assert.Len(t, got, 5)
+		for _, seq := range got {
+			assert.Len(t, seq, 20)
+			for i := 1; i < len(seq); i++ {
+				assert.Less(t, seq[i-1], seq[i])
+			}
+		}
+	})
+
+	t.Run("submit after close", func(t *testing.T) {
+		e := NewPartitionedExecutor(1, 0)
+		e.Close()
+
+		err := e.Submit(context.Background(), "a", func() {})
+		assert.ErrorIs(t, err, ErrExecutorClosed)
+	})
+
+	t.Run("submit honours context", func(t *testing.T) {
+		e := NewPartitionedExecutor(1, 0)
+		block := make(chan struct{})
+		assert.NoError(t, e.Submit(context.Background(), "a", func() { <-block }))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := e.Submit(ctx, "a", func() {})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(block)
+		e.Close()
+	})
+}
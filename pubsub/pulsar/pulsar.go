@@ -24,6 +24,7 @@ import (
 	"github.com/apache/pulsar-client-go/pulsar"
 	lru "github.com/hashicorp/golang-lru"
 
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -33,7 +34,6 @@ const (
 	host                    = "host"
 	consumerID              = "consumerID"
 	enableTLS               = "enableTLS"
-	deliverAt               = "deliverAt"
 	deliverAfter            = "deliverAfter"
 	disableBatching         = "disableBatching"
 	batchingMaxPublishDelay = "batchingMaxPublishDelay"
@@ -245,11 +245,13 @@ func parsePublishMetadata(req *pubsub.PublishRequest) (
 	msg = &pulsar.ProducerMessage{
 		Payload: req.Data,
 	}
-	if val, ok := req.Metadata[deliverAt]; ok {
-		msg.DeliverAt, err = time.Parse(time.RFC3339, val)
-		if err != nil {
-			return nil, err
-		}
+	// deliverAfter takes precedence in the client, over delaySeconds as well.
+	deliverAt, delayed, err := contrib_metadata.TryGetDeliverAt(req.Metadata, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if delayed {
+		msg.DeliverAt = deliverAt
 	}
 	if val, ok := req.Metadata[deliverAfter]; ok {
 		msg.DeliverAfter, err = time.ParseDuration(val)
@@ -272,6 +274,17 @@ func (p *Pulsar) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler)
 		MessageChannel:   channel,
 	}
 
+	startFrom, err := pubsub.StartFrom(req.Metadata)
+	if err != nil {
+		return err
+	}
+	if startFrom != nil && startFrom.Kind == pubsub.StartFromSequence {
+		return fmt.Errorf("startFrom %s is not supported", startFrom.Kind)
+	}
+	if startFrom != nil && startFrom.Kind == pubsub.StartFromEarliest {
+		options.SubscriptionInitialPosition = pulsar.SubscriptionPositionEarliest
+	}
+
 	consumer, err := p.client.Subscribe(options)
 	if err != nil {
 		p.logger.Debugf("Could not subscribe to %s, full topic name in pulsar is %s", req.Topic, topic)
@@ -279,11 +292,37 @@ func (p *Pulsar) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler)
 		return err
 	}
 
+	if err = seekStartPosition(consumer, startFrom); err != nil {
+		consumer.Close()
+
+		return fmt.Errorf("could not seek subscription of %s: %w", req.Topic, err)
+	}
+
 	go p.listenMessage(req.Topic, consumer, handler)
 
 	return nil
 }
 
+// seekStartPosition moves the subscription cursor to the startFrom of the subscription,
+// whether the subscription was created or already existed.
+func seekStartPosition(consumer pulsar.Consumer, startFrom *pubsub.StartPosition) error {
+	if startFrom == nil {
+		return nil
+	}
+
+	// Seek does not accept the earliest and latest message IDs, seek by time instead.
+	switch startFrom.Kind {
+	case pubsub.StartFromEarliest:
+		return consumer.SeekByTime(time.Unix(0, 0))
+	case pubsub.StartFromLatest:
+		return consumer.SeekByTime(time.Now())
+	case pubsub.StartFromTime:
+		return consumer.SeekByTime(startFrom.Time)
+	default:
+		return fmt.Errorf("startFrom %s is not supported", startFrom.Kind)
+	}
+}
+
 func (p *Pulsar) listenMessage(originTopic string, consumer pulsar.Consumer, handler pubsub.Handler) {
 	defer consumer.Close()
 
@@ -342,7 +381,7 @@ func (p *Pulsar) Close() error {
 }
 
 func (p *Pulsar) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureDelayedDelivery}
 }
 
 // formatTopic formats the topic into pulsar's structure with tenant and namespace.
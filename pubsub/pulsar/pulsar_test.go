@@ -60,6 +60,21 @@ func TestParsePublishMetadata(t *testing.T) {
 		msg.DeliverAt.Format(time.RFC3339))
 }
 
+func TestParsePublishMetadataDelaySeconds(t *testing.T) {
+	m := &pubsub.PublishRequest{}
+	m.Metadata = map[string]string{
+		"delaySeconds": "90",
+	}
+	msg, err := parsePublishMetadata(m)
+
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now().Add(90*time.Second), msg.DeliverAt, time.Second)
+
+	m.Metadata["delaySeconds"] = "-1"
+	_, err = parsePublishMetadata(m)
+	assert.Error(t, err)
+}
+
 func TestMissingHost(t *testing.T) {
 	m := pubsub.Metadata{}
 	m.Properties = map[string]string{"host": ""}
@@ -35,9 +35,12 @@ type metadata struct {
 	prefetchCount    uint8 // Prefetch deactivated if 0
 	reconnectWait    time.Duration
 	concurrency      pubsub.ConcurrencyMode
+	partitionWorkers int
 	maxLen           int64
 	maxLenBytes      int64
 	exchangeKind     string
+	delayedExchange  bool
+	cloudEventsMode  pubsub.CloudEventsMode
 }
 
 // createMetadata creates a new instance from the pubsub metadata.
@@ -131,12 +134,30 @@ func createMetadata(pubSubMetadata pubsub.Metadata) (*metadata, error) {
 		}
 	}
 
+	if val, found := pubSubMetadata.Properties[metadataDelayedExchange]; found && val != "" {
+		if boolVal, err := strconv.ParseBool(val); err == nil {
+			result.delayedExchange = boolVal
+		}
+	}
+
 	c, err := pubsub.Concurrency(pubSubMetadata.Properties)
 	if err != nil {
 		return &result, err
 	}
 	result.concurrency = c
 
+	w, err := pubsub.PartitionedWorkers(pubSubMetadata.Properties)
+	if err != nil {
+		return &result, fmt.Errorf("%s %s", errorMessagePrefix, err)
+	}
+	result.partitionWorkers = w
+
+	mode, err := pubsub.CloudEventsContentMode(pubSubMetadata.Properties)
+	if err != nil {
+		return &result, fmt.Errorf("%s %s", errorMessagePrefix, err)
+	}
+	result.cloudEventsMode = mode
+
 	return &result, nil
 }
 
@@ -66,6 +66,19 @@ func TestCreateMetadata(t *testing.T) {
 		assert.Equal(t, int64(0), m.maxLen)
 		assert.Equal(t, int64(0), m.maxLenBytes)
 		assert.Equal(t, fanoutExchangeKind, m.exchangeKind)
+		assert.Equal(t, false, m.delayedExchange)
+	})
+
+	t.Run("delayedExchange is set", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[metadataDelayedExchange] = "true"
+
+		// act
+		m, err := createMetadata(pubsub.Metadata{Properties: fakeProperties})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, true, m.delayedExchange)
 	})
 
 	t.Run("host is not given", func(t *testing.T) {
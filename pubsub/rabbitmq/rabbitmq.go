@@ -15,6 +15,7 @@ package rabbitmq
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -24,6 +25,8 @@ import (
 
 	"github.com/streadway/amqp"
 
+	"github.com/dapr/components-contrib/contenttype"
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
@@ -50,6 +53,7 @@ const (
 	metadataMaxLen               = "maxLen"
 	metadataMaxLenBytes          = "maxLenBytes"
 	metadataExchangeKind         = "exchangeKind"
+	metadataDelayedExchange      = "delayedExchange"
 
 	defaultReconnectWaitSeconds = 3
 	publishMaxRetries           = 3
@@ -62,6 +66,11 @@ const (
 	argDeadLetterExchange = "x-dead-letter-exchange"
 	queueModeLazy         = "lazy"
 	reqMetadataRoutingKey = "routingKey"
+
+	// delayedExchangeKind, argDelayedType and headerDelay are defined by the rabbitmq_delayed_message_exchange plugin.
+	delayedExchangeKind = "x-delayed-message"
+	argDelayedType      = "x-delayed-type"
+	headerDelay         = "x-delay"
 )
 
 // RabbitMQ allows sending/receiving messages in pub/sub format.
@@ -78,6 +87,7 @@ type rabbitMQ struct {
 
 	logger        logger.Logger
 	backOffConfig retry.Config
+	stats         pubsub.StatsRecorder
 	ctx           context.Context
 	cancel        context.CancelFunc
 }
@@ -86,6 +96,7 @@ type rabbitMQ struct {
 type rabbitMQChannelBroker interface {
 	Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error
 	QueueDeclare(name string, durable bool, autoDelete bool, exclusive bool, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueInspect(name string) (amqp.Queue, error)
 	QueueBind(name string, key string, exchange string, noWait bool, args amqp.Table) error
 	Consume(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
 	Nack(tag uint64, multiple bool, requeue bool) error
@@ -97,9 +108,19 @@ type rabbitMQChannelBroker interface {
 
 // interface used to allow unit testing.
 type rabbitMQConnectionBroker interface {
+	Channel() (rabbitMQChannelBroker, error)
 	Close() error
 }
 
+// amqpConnection adapts amqp.Connection to rabbitMQConnectionBroker.
+type amqpConnection struct {
+	*amqp.Connection
+}
+
+func (c amqpConnection) Channel() (rabbitMQChannelBroker, error) {
+	return c.Connection.Channel()
+}
+
 // NewRabbitMQ creates a new RabbitMQ pub/sub.
 func NewRabbitMQ(logger logger.Logger) pubsub.PubSub {
 	return &rabbitMQ{
@@ -122,7 +143,7 @@ func dial(host string) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error)
 		return nil, nil, err
 	}
 
-	return conn, ch, nil
+	return amqpConnection{conn}, ch, nil
 }
 
 // Init does metadata parsing and connection creation.
@@ -192,7 +213,7 @@ func (r *rabbitMQ) doReconnect(connectionCount int) error {
 	return nil
 }
 
-func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, error) {
+func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest, msg amqp.Publishing) (rabbitMQChannelBroker, int, error) {
 	r.channelMutex.Lock()
 	defer r.channelMutex.Unlock()
 
@@ -200,7 +221,7 @@ func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroke
 		return r.channel, r.connectionCount, errors.New(errorChannelNotInitialized)
 	}
 
-	if err := r.ensureExchangeDeclared(r.channel, req.Topic, r.metadata.exchangeKind); err != nil {
+	if err := r.ensureTopicExchangeDeclared(r.channel, req.Topic); err != nil {
 		r.logger.Errorf("%s publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, err)
 
 		return r.channel, r.connectionCount, err
@@ -210,11 +231,7 @@ func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroke
 		routingKey = val
 	}
 
-	if err := r.channel.Publish(req.Topic, routingKey, false, false, amqp.Publishing{
-		ContentType:  "text/plain",
-		Body:         req.Data,
-		DeliveryMode: r.metadata.deliveryMode,
-	}); err != nil {
+	if err := r.channel.Publish(req.Topic, routingKey, false, false, msg); err != nil {
 		r.logger.Errorf("%s publishing to %s failed in channel.Publish: %v", logMessagePrefix, req.Topic, err)
 
 		return r.channel, r.connectionCount, err
@@ -226,10 +243,33 @@ func (r *rabbitMQ) publishSync(req *pubsub.PublishRequest) (rabbitMQChannelBroke
 func (r *rabbitMQ) Publish(req *pubsub.PublishRequest) error {
 	r.logger.Debugf("%s publishing message to %s", logMessagePrefix, req.Topic)
 
+	headers, err := r.publishHeaders(req)
+	if err != nil {
+		return err
+	}
+	msg := amqp.Publishing{
+		ContentType:  "text/plain",
+		Headers:      headers,
+		Body:         req.Data,
+		DeliveryMode: r.metadata.deliveryMode,
+	}
+	if r.metadata.cloudEventsMode == pubsub.CloudEventsBinary {
+		// Raw payloads are not cloud events and are sent as they are.
+		if ce, ceErr := pubsub.BinaryCloudEventFromJSON(req.Data, pubsub.AMQPCloudEventHeaderPrefix); ceErr == nil {
+			for k, v := range ce.Headers {
+				msg.Headers[k] = v
+			}
+			msg.Body = ce.Data
+			if ce.ContentType != "" {
+				msg.ContentType = ce.ContentType
+			}
+		}
+	}
+
 	attempt := 0
 	for {
 		attempt++
-		channel, connectionCount, err := r.publishSync(req)
+		channel, connectionCount, err := r.publishSync(req, msg)
 		if err == nil {
 			return nil
 		}
@@ -248,19 +288,42 @@ func (r *rabbitMQ) Publish(req *pubsub.PublishRequest) error {
 	}
 }
 
+// publishHeaders builds the message headers carrying the partition key and the delivery delay.
+func (r *rabbitMQ) publishHeaders(req *pubsub.PublishRequest) (amqp.Table, error) {
+	headers := amqp.Table{}
+	if val, ok := req.Metadata[pubsub.PartitionKeyMetadata]; ok && val != "" {
+		headers[pubsub.PartitionKeyMetadata] = val
+	}
+
+	deliverAt, ok, err := contrib_metadata.TryGetDeliverAt(req.Metadata, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s %s", errorMessagePrefix, err)
+	}
+	if ok {
+		if !r.metadata.delayedExchange {
+			return nil, fmt.Errorf("%s delayed delivery requires the %s option", errorMessagePrefix, metadataDelayedExchange)
+		}
+		if delay := time.Until(deliverAt); delay > 0 {
+			headers[headerDelay] = delay.Milliseconds()
+		}
+	}
+
+	return headers, nil
+}
+
 func (r *rabbitMQ) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
 	if r.metadata.consumerID == "" {
 		return errors.New("consumerID is required for subscriptions")
 	}
 
-	queueName := fmt.Sprintf("%s-%s", r.metadata.consumerID, req.Topic)
+	queueName := r.queueName(req.Topic)
 	r.logger.Infof("%s subscribe to topic/queue '%s/%s'", logMessagePrefix, req.Topic, queueName)
 
 	ackCh := make(chan struct{}, 1)
 	ctx, cancel := context.WithTimeout(r.ctx, time.Minute)
 	defer cancel()
 
-	go r.subscribeForever(req, queueName, handler, ackCh)
+	go r.subscribeForever(req, queueName, r.stats.Handler(req.Topic, handler), ackCh)
 
 	select {
 	case <-ctx.Done():
@@ -270,9 +333,14 @@ func (r *rabbitMQ) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler
 	}
 }
 
+// queueName returns the name of the queue of the subscriptions to topic.
+func (r *rabbitMQ) queueName(topic string) string {
+	return fmt.Sprintf("%s-%s", r.metadata.consumerID, topic)
+}
+
 // this function call should be wrapped by channelMutex.
 func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub.SubscribeRequest, queueName string) (*amqp.Queue, error) {
-	err := r.ensureExchangeDeclared(channel, req.Topic, r.metadata.exchangeKind)
+	err := r.ensureTopicExchangeDeclared(channel, req.Topic)
 	if err != nil {
 		r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, queueName, err)
 
@@ -285,7 +353,7 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		// declare dead letter exchange
 		dlxName := fmt.Sprintf(defaultDeadLetterExchangeFormat, queueName)
 		dlqName := fmt.Sprintf(defaultDeadLetterQueueFormat, queueName)
-		err = r.ensureExchangeDeclared(channel, dlxName, fanoutExchangeKind)
+		err = r.ensureExchangeDeclared(channel, dlxName, fanoutExchangeKind, nil)
 		if err != nil {
 			r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, dlqName, err)
 
@@ -424,6 +492,12 @@ func (r *rabbitMQ) subscribeForever(req pubsub.SubscribeRequest, queueName strin
 
 func (r *rabbitMQ) listenMessages(channel rabbitMQChannelBroker, msgs <-chan amqp.Delivery, topic string, handler pubsub.Handler) error {
 	var err error
+	var executor *pubsub.PartitionedExecutor
+	if r.metadata.concurrency == pubsub.Partitioned {
+		executor = pubsub.NewPartitionedExecutor(r.metadata.partitionWorkers, 1)
+		defer executor.Close()
+	}
+
 	for d := range msgs {
 		switch r.metadata.concurrency {
 		case pubsub.Single:
@@ -432,6 +506,14 @@ func (r *rabbitMQ) listenMessages(channel rabbitMQChannelBroker, msgs <-chan amq
 			go func(channel rabbitMQChannelBroker, d amqp.Delivery, topic string, handler pubsub.Handler) {
 				err = r.handleMessage(channel, d, topic, handler)
 			}(channel, d, topic, handler)
+		case pubsub.Partitioned:
+			d := d
+			key, _ := d.Headers[pubsub.PartitionKeyMetadata].(string)
+			if submitErr := executor.Submit(r.ctx, key, func() {
+				r.handleMessage(channel, d, topic, handler)
+			}); submitErr != nil {
+				return submitErr
+			}
 		}
 		if (err != nil) && mustReconnect(channel, err) {
 			return err
@@ -446,6 +528,15 @@ func (r *rabbitMQ) handleMessage(channel rabbitMQChannelBroker, d amqp.Delivery,
 		Data:  d.Body,
 		Topic: topic,
 	}
+	if r.metadata.cloudEventsMode == pubsub.CloudEventsBinary {
+		if err := r.structuredCloudEvent(pubsubMsg, d); err != nil {
+			r.logger.Errorf("%s error decoding cloud event of message '%s' from topic '%s', %s", logMessagePrefix, d.MessageId, topic, err)
+		}
+	}
+
+	if d.Redelivered {
+		r.stats.Redelivered(topic, 1)
+	}
 
 	b := r.backOffConfig.NewBackOffWithContext(r.ctx)
 	err := retry.NotifyRecover(func() error {
@@ -477,11 +568,51 @@ func (r *rabbitMQ) handleMessage(channel rabbitMQChannelBroker, d amqp.Delivery,
 	return err
 }
 
+// structuredCloudEvent replaces the data of msg with the structured cloud event carried by
+// the application properties of d, if it has any.
+func (r *rabbitMQ) structuredCloudEvent(msg *pubsub.NewMessage, d amqp.Delivery) error {
+	headers := make(map[string]string, len(d.Headers))
+	for k, v := range d.Headers {
+		if str, ok := v.(string); ok {
+			headers[k] = str
+		}
+	}
+	if !pubsub.IsBinaryCloudEvent(headers, pubsub.AMQPCloudEventHeaderPrefix) {
+		return nil
+	}
+
+	ce, err := pubsub.FromBinaryCloudEvent(headers, d.ContentType, d.Body, pubsub.AMQPCloudEventHeaderPrefix)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(ce)
+	if err != nil {
+		return err
+	}
+
+	contentType := contenttype.CloudEventContentType
+	msg.Data = data
+	msg.ContentType = &contentType
+
+	return nil
+}
+
+// ensureTopicExchangeDeclared declares the exchange a topic is published to.
+// When delayed delivery is enabled the exchange is a delayed-message exchange routing as exchangeKind.
+// this function call should be wrapped by channelMutex.
+func (r *rabbitMQ) ensureTopicExchangeDeclared(channel rabbitMQChannelBroker, topic string) error {
+	if r.metadata.delayedExchange {
+		return r.ensureExchangeDeclared(channel, topic, delayedExchangeKind, amqp.Table{argDelayedType: r.metadata.exchangeKind})
+	}
+
+	return r.ensureExchangeDeclared(channel, topic, r.metadata.exchangeKind, nil)
+}
+
 // this function call should be wrapped by channelMutex.
-func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchange, exchangeKind string) error {
+func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchange, exchangeKind string, args amqp.Table) error {
 	if !r.containsExchange(exchange) {
 		r.logger.Debugf("%s declaring exchange '%s' of kind '%s'", logMessagePrefix, exchange, exchangeKind)
-		err := channel.ExchangeDeclare(exchange, exchangeKind, true, false, false, false, nil)
+		err := channel.ExchangeDeclare(exchange, exchangeKind, true, false, false, false, args)
 		if err != nil {
 			r.logger.Errorf("%s ensureExchangeDeclared: channel.ExchangeDeclare failed: %v", logMessagePrefix, err)
 
@@ -549,7 +680,43 @@ func (r *rabbitMQ) Close() error {
 	return err
 }
 
+var _ pubsub.StatsProvider = (*rabbitMQ)(nil)
+
+// Stats reports the statistics of the subscribed topics, with the messages ready in their queue as lag.
+// The queues are inspected on a channel of their own, as the broker closes the channel
+// on which a missing queue is inspected.
+func (r *rabbitMQ) Stats(ctx context.Context) (map[string]pubsub.TopicStats, error) {
+	r.channelMutex.RLock()
+	defer r.channelMutex.RUnlock()
+
+	if r.connection == nil {
+		return nil, errors.New(errorChannelNotInitialized)
+	}
+	channel, err := r.connection.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("%s error opening channel: %s", errorMessagePrefix, err)
+	}
+	defer channel.Close()
+
+	stats := r.stats.Stats()
+	for topic, s := range stats {
+		queueName := r.queueName(topic)
+		q, err := channel.QueueInspect(queueName)
+		if err != nil {
+			return nil, fmt.Errorf("%s error inspecting queue %s: %s", errorMessagePrefix, queueName, err)
+		}
+		s.Lag = int64(q.Messages)
+		stats[topic] = s
+	}
+
+	return stats, nil
+}
+
 func (r *rabbitMQ) Features() []pubsub.Feature {
+	if r.metadata != nil && r.metadata.delayedExchange {
+		return []pubsub.Feature{pubsub.FeatureDelayedDelivery}
+	}
+
 	return nil
 }
 
@@ -98,6 +98,23 @@ func TestConcurrencyMode(t *testing.T) {
 		assert.Equal(t, pubsub.Single, pubsubRabbitMQ.(*rabbitMQ).metadata.concurrency)
 	})
 
+	t.Run("partitioned", func(t *testing.T) {
+		broker := newBroker()
+		pubsubRabbitMQ := newRabbitMQTest(broker)
+		metadata := pubsub.Metadata{
+			Properties: map[string]string{
+				metadataHostKey:              "anyhost",
+				metadataConsumerIDKey:        "consumer",
+				pubsub.ConcurrencyKey:        string(pubsub.Partitioned),
+				pubsub.PartitionedWorkersKey: "3",
+			},
+		}
+		err := pubsubRabbitMQ.Init(metadata)
+		assert.Nil(t, err)
+		assert.Equal(t, pubsub.Partitioned, pubsubRabbitMQ.(*rabbitMQ).metadata.concurrency)
+		assert.Equal(t, 3, pubsubRabbitMQ.(*rabbitMQ).metadata.partitionWorkers)
+	})
+
 	t.Run("default", func(t *testing.T) {
 		broker := newBroker()
 		pubsubRabbitMQ := newRabbitMQTest(broker)
@@ -156,6 +173,81 @@ func TestPublishAndSubscribe(t *testing.T) {
 	assert.Equal(t, "foo bar", lastMessage)
 }
 
+func TestPublishBinaryCloudEvent(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	err := pubsubRabbitMQ.Init(pubsub.Metadata{
+		Properties: map[string]string{
+			metadataHostKey:           "anyhost",
+			metadataConsumerIDKey:     "consumer",
+			pubsub.CloudEventsModeKey: string(pubsub.CloudEventsBinary),
+		},
+	})
+	assert.Nil(t, err)
+
+	received := make(chan *pubsub.NewMessage, 1)
+	err = pubsubRabbitMQ.Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received <- msg
+
+		return nil
+	})
+	assert.Nil(t, err)
+
+	ce := []byte(`{"specversion":"1.0","id":"a1","source":"app","type":"order","datacontenttype":"application/json","data":{"orderId":1}}`)
+	err = pubsubRabbitMQ.Publish(&pubsub.PublishRequest{Topic: "orders", Data: ce})
+	assert.Nil(t, err)
+
+	msg := <-received
+	assert.Equal(t, "application/cloudevents+json", *msg.ContentType)
+	assert.JSONEq(t, string(ce), string(msg.Data))
+}
+
+func TestPublishDelayed(t *testing.T) {
+	t.Run("requires delayed exchange", func(t *testing.T) {
+		broker := newBroker()
+		pubsubRabbitMQ := newRabbitMQTest(broker)
+		err := pubsubRabbitMQ.Init(pubsub.Metadata{
+			Properties: map[string]string{
+				metadataHostKey: "anyhost",
+			},
+		})
+		assert.Nil(t, err)
+		assert.False(t, pubsub.FeatureDelayedDelivery.IsPresent(pubsubRabbitMQ.Features()))
+
+		err = pubsubRabbitMQ.Publish(&pubsub.PublishRequest{
+			Topic:    "delayedtopic",
+			Data:     []byte("hello world"),
+			Metadata: map[string]string{"delaySeconds": "10"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("sets delay header", func(t *testing.T) {
+		broker := newBroker()
+		pubsubRabbitMQ := newRabbitMQTest(broker)
+		err := pubsubRabbitMQ.Init(pubsub.Metadata{
+			Properties: map[string]string{
+				metadataHostKey:         "anyhost",
+				metadataDelayedExchange: "true",
+			},
+		})
+		assert.Nil(t, err)
+		assert.True(t, pubsub.FeatureDelayedDelivery.IsPresent(pubsubRabbitMQ.Features()))
+
+		err = pubsubRabbitMQ.Publish(&pubsub.PublishRequest{
+			Topic:    "delayedtopic",
+			Data:     []byte("hello world"),
+			Metadata: map[string]string{"delaySeconds": "10"},
+		})
+		assert.Nil(t, err)
+
+		msg := <-broker.buffer
+		delay, ok := msg.Headers[headerDelay].(int64)
+		assert.True(t, ok)
+		assert.InDelta(t, 10000, delay, 1000)
+	})
+}
+
 func TestPublishReconnect(t *testing.T) {
 	broker := newBroker()
 	pubsubRabbitMQ := newRabbitMQTest(broker)
@@ -310,17 +402,24 @@ func TestSubscribeReconnect(t *testing.T) {
 	assert.Equal(t, 4, broker.closeCount)   // two counts for each connection closure - one for connection, one for channel
 }
 
-func createAMQPMessage(body []byte) amqp.Delivery {
-	return amqp.Delivery{Body: body}
+func createAMQPMessage(body []byte, headers amqp.Table) amqp.Delivery {
+	return amqp.Delivery{Body: body, Headers: headers}
 }
 
 type rabbitMQInMemoryBroker struct {
 	buffer chan amqp.Delivery
 
 	connectCount int
+	channelCount int
 	closeCount   int
 }
 
+func (r *rabbitMQInMemoryBroker) Channel() (rabbitMQChannelBroker, error) {
+	r.channelCount++
+
+	return r, nil
+}
+
 func (r *rabbitMQInMemoryBroker) Qos(prefetchCount, prefetchSize int, global bool) error {
 	return nil
 }
@@ -330,7 +429,9 @@ func (r *rabbitMQInMemoryBroker) Publish(exchange string, key string, mandatory
 		return errors.New(errorChannelConnection)
 	}
 
-	r.buffer <- createAMQPMessage(msg.Body)
+	d := createAMQPMessage(msg.Body, msg.Headers)
+	d.ContentType = msg.ContentType
+	r.buffer <- d
 
 	return nil
 }
@@ -339,6 +440,10 @@ func (r *rabbitMQInMemoryBroker) QueueDeclare(name string, durable bool, autoDel
 	return amqp.Queue{Name: name}, nil
 }
 
+func (r *rabbitMQInMemoryBroker) QueueInspect(name string) (amqp.Queue, error) {
+	return amqp.Queue{Name: name, Messages: len(r.buffer)}, nil
+}
+
 func (r *rabbitMQInMemoryBroker) QueueBind(name string, key string, exchange string, noWait bool, args amqp.Table) error {
 	return nil
 }
@@ -364,3 +469,33 @@ func (r *rabbitMQInMemoryBroker) Close() error {
 
 	return nil
 }
+
+func TestStats(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{
+		Properties: map[string]string{
+			metadataHostKey:       "anyhost",
+			metadataConsumerIDKey: "consumer",
+		},
+	}
+	err := pubsubRabbitMQ.Init(metadata)
+	assert.Nil(t, err)
+
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return errors.New("handler failed")
+	}
+	err = pubsubRabbitMQ.Subscribe(pubsub.SubscribeRequest{Topic: "mytopic"}, handler)
+	assert.Nil(t, err)
+
+	err = pubsubRabbitMQ.Publish(&pubsub.PublishRequest{Topic: "mytopic", Data: []byte("hello world")})
+	assert.Nil(t, err)
+
+	provider := pubsubRabbitMQ.(pubsub.StatsProvider)
+	assert.Eventually(t, func() bool {
+		stats, err := provider.Stats(context.Background())
+
+		return err == nil && stats["mytopic"].LastError == "handler failed"
+	}, time.Second, 10*time.Millisecond)
+	assert.Positive(t, broker.channelCount, "queues are inspected on their own channel")
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+var streamIDRegexp = regexp.MustCompile(`^\d+-\d+$`)
+
+// StreamStats describes the state of the consumer group of a stream.
+type StreamStats struct {
+	Stream string
+	// Length is the number of entries in the stream.
+	Length int64
+	// Pending is the number of entries delivered to the group but not acknowledged yet.
+	Pending int64
+	// Lag is the number of entries not delivered to the group yet. Before Redis 7, which reports
+	// it, the lag is counted from the stream, up to maxStreamLag.
+	Lag int64
+	// Consumers is the number of consumers in the group.
+	Consumers int64
+}
+
+// StreamStatsProvider is implemented by the redis streams pub/sub to report
+// consumer group statistics of a stream.
+type StreamStatsProvider interface {
+	StreamStats(ctx context.Context, stream string) (StreamStats, error)
+}
+
+var (
+	_ StreamStatsProvider  = (*redisStreams)(nil)
+	_ pubsub.StatsProvider = (*redisStreams)(nil)
+)
+
+// parseStreamStartID converts the streamStartID setting to a stream ID.
+// It accepts `$`, a stream ID, a unix timestamp in milliseconds or an RFC3339 time.
+func parseStreamStartID(val string) (string, error) {
+	if val == "$" || val == "0" || streamIDRegexp.MatchString(val) {
+		return val, nil
+	}
+
+	if ms, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return fmt.Sprintf("%d-0", ms), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return fmt.Sprintf("%d-0", t.UnixMilli()), nil
+	}
+
+	return "", errors.New("expected $, 0, a stream ID, a unix timestamp in milliseconds or an RFC3339 time")
+}
+
+// startFromStreamID converts the startFrom of a subscription to a stream ID.
+func startFromStreamID(startFrom *pubsub.StartPosition) (string, error) {
+	switch startFrom.Kind {
+	case pubsub.StartFromEarliest:
+		return "0", nil
+	case pubsub.StartFromLatest:
+		return "$", nil
+	case pubsub.StartFromTime:
+		return fmt.Sprintf("%d-0", startFrom.Time.UnixMilli()), nil
+	default:
+		return "", fmt.Errorf("startFrom %s is not supported", startFrom.Kind)
+	}
+}
+
+// removeIdleConsumersLoop periodically removes consumers of the group
+// based on the `consumerIdleTimeout` setting.
+func (r *redisStreams) removeIdleConsumersLoop(stream string) {
+	if r.metadata.consumerIdleTimeout == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.metadata.consumerIdleTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+
+		case <-ticker.C:
+			r.removeIdleConsumers(stream)
+		}
+	}
+}
+
+// removeIdleConsumers removes the consumers of the group, other than this instance, that have been
+// idle longer than `consumerIdleTimeout`, like those of the instances replaced by a deployment.
+// Consumers that still own pending messages are kept so that those messages can be reclaimed first.
+func (r *redisStreams) removeIdleConsumers(stream string) {
+	consumers, err := r.client.XInfoConsumers(r.ctx, stream, r.metadata.consumerID).Result()
+	if err != nil {
+		r.logger.Errorf("redis streams: error retrieving consumers of stream %s: %s", stream, err)
+
+		return
+	}
+
+	for _, consumer := range consumers {
+		if consumer.Name == r.metadata.consumerName || consumer.Pending > 0 {
+			continue
+		}
+		if time.Duration(consumer.Idle)*time.Millisecond < r.metadata.consumerIdleTimeout {
+			continue
+		}
+
+		r.logger.Debugf("redis streams: removing idle consumer %s from stream %s", consumer.Name, stream)
+		if err = r.client.XGroupDelConsumer(r.ctx, stream, r.metadata.consumerID, consumer.Name).Err(); err != nil {
+			r.logger.Errorf("redis streams: error removing idle consumer %s from stream %s: %s", consumer.Name, stream, err)
+		}
+	}
+}
+
+// StreamStats reports the consumer group statistics of a stream using `XINFO`.
+// The replies are parsed here, as their fields differ between Redis versions.
+func (r *redisStreams) StreamStats(ctx context.Context, stream string) (StreamStats, error) {
+	stats := StreamStats{Stream: stream}
+
+	reply, err := r.client.Do(ctx, "XINFO", "STREAM", stream).Slice()
+	if err != nil {
+		return stats, fmt.Errorf("redis streams: error retrieving info of stream %s: %s", stream, err)
+	}
+	info := replyFields(reply)
+	stats.Length, _ = info["length"].(int64)
+	lastGeneratedID, _ := info["last-generated-id"].(string)
+
+	groups, err := r.client.Do(ctx, "XINFO", "GROUPS", stream).Slice()
+	if err != nil {
+		return stats, fmt.Errorf("redis streams: error retrieving groups of stream %s: %s", stream, err)
+	}
+
+	for _, g := range groups {
+		reply, _ := g.([]interface{})
+		group := replyFields(reply)
+		if name, _ := group["name"].(string); name != r.metadata.consumerID {
+			continue
+		}
+
+		stats.Pending, _ = group["pending"].(int64)
+		stats.Consumers, _ = group["consumers"].(int64)
+		// Redis 7 reports the lag, unless entries were deleted from the stream.
+		if lag, ok := group["lag"].(int64); ok {
+			stats.Lag = lag
+
+			return stats, nil
+		}
+		lastDeliveredID, _ := group["last-delivered-id"].(string)
+		stats.Lag, err = r.streamLag(ctx, stream, lastDeliveredID, lastGeneratedID)
+		if err != nil {
+			return stats, err
+		}
+
+		return stats, nil
+	}
+
+	return stats, fmt.Errorf("redis streams: consumer group %s not found for stream %s", r.metadata.consumerID, stream)
+}
+
+// replyFields returns the fields of a reply made of name and value pairs.
+func replyFields(reply []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(reply)/2)
+	for i := 0; i+1 < len(reply); i += 2 {
+		if name, ok := reply[i].(string); ok {
+			fields[name] = reply[i+1]
+		}
+	}
+
+	return fields
+}
+
+// Stats reports the statistics of the subscribed streams, with the lag of their consumer group.
+func (r *redisStreams) Stats(ctx context.Context) (map[string]pubsub.TopicStats, error) {
+	stats := r.stats.Stats()
+	for stream, s := range stats {
+		streamStats, err := r.StreamStats(ctx, stream)
+		if err != nil {
+			return nil, err
+		}
+		s.Lag = streamStats.Lag
+		stats[stream] = s
+	}
+
+	return stats, nil
+}
+
+// streamLag counts the entries added to the stream after lastDeliveredID, up to maxStreamLag.
+func (r *redisStreams) streamLag(ctx context.Context, stream, lastDeliveredID, lastGeneratedID string) (int64, error) {
+	if lastDeliveredID == lastGeneratedID {
+		return 0, nil
+	}
+
+	start := "-"
+	if lastDeliveredID != "0-0" {
+		next, err := nextStreamID(lastDeliveredID)
+		if err != nil {
+			return 0, err
+		}
+		start = next
+	}
+
+	entries, err := r.client.XRangeN(ctx, stream, start, "+", maxStreamLag).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("redis streams: error computing lag of stream %s: %s", stream, err)
+	}
+
+	return int64(len(entries)), nil
+}
+
+// maxStreamLag caps the entries read to count the lag of a consumer group, to bound the cost of
+// the statistics of a stream with a large backlog.
+const maxStreamLag = 1000
+
+// nextStreamID returns the smallest stream ID greater than id.
+func nextStreamID(id string) (string, error) {
+	var ms, seq uint64
+	if _, err := fmt.Sscanf(id, "%d-%d", &ms, &seq); err != nil {
+		return "", fmt.Errorf("redis streams: invalid stream ID %s", id)
+	}
+
+	return fmt.Sprintf("%d-%d", ms, seq+1), nil
+}
@@ -15,11 +15,15 @@ package redis
 
 import (
 	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
 )
 
 type metadata struct {
-	// The consumer identifier
+	// The consumer identifier, naming the consumer group
 	consumerID string
+	// The name of the instance in the consumer group, the hostname by default
+	consumerName string
 	// The interval between checking for pending messages to redelivery (0 disables redelivery)
 	redeliverInterval time.Duration
 	// The amount time a message must be pending before attempting to redeliver it (0 disables redelivery)
@@ -31,4 +35,17 @@ type metadata struct {
 
 	// the max len of stream
 	maxLenApprox int64
+	// the max age of stream entries, older entries are trimmed on publish (0 disables it)
+	maxAge time.Duration
+
+	// The ID the consumer group starts reading from when it is created
+	streamStartID string
+	// The idle time after which other consumers of the group are removed (0 disables it)
+	consumerIdleTimeout time.Duration
+
+	// The interval between checking for delayed messages that are due (0 disables delayed delivery)
+	delayedPollInterval time.Duration
+
+	// Whether messages sharing a partition key must be processed in order
+	concurrencyMode pubsub.ConcurrencyMode
 }
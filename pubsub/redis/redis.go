@@ -15,26 +15,42 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	contrib_metadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
 
 const (
-	consumerID        = "consumerID"
-	enableTLS         = "enableTLS"
-	processingTimeout = "processingTimeout"
-	redeliverInterval = "redeliverInterval"
-	queueDepth        = "queueDepth"
-	concurrency       = "concurrency"
-	maxLenApprox      = "maxLenApprox"
+	consumerID          = "consumerID"
+	consumerName        = "consumerName"
+	enableTLS           = "enableTLS"
+	processingTimeout   = "processingTimeout"
+	redeliverInterval   = "redeliverInterval"
+	queueDepth          = "queueDepth"
+	concurrency         = "concurrency"
+	maxLenApprox        = "maxLenApprox"
+	delayedPollInterval = "delayedPollInterval"
+	streamStartID       = "streamStartID"
+	maxAge              = "maxAge"
+	consumerIdleTimeout = "consumerIdleTimeout"
+
+	// partitionKeyField is the stream entry field holding the ordering key of a message.
+	partitionKeyField = "partitionKey"
+
+	// delayedMessagesKey is the sorted set where delayed messages are parked until they are due,
+	// scored by their delivery time in milliseconds.
+	delayedMessagesKey = "dapr:delayed-messages"
 )
 
 // redisStreams handles consuming from a Redis stream using
@@ -49,7 +65,9 @@ type redisStreams struct {
 	clientSettings *rediscomponent.Settings
 	logger         logger.Logger
 
-	queue chan redisMessageWrapper
+	queue    chan redisMessageWrapper
+	executor *pubsub.PartitionedExecutor
+	stats    pubsub.StatsRecorder
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -58,9 +76,19 @@ type redisStreams struct {
 // redisMessageWrapper encapsulates the message identifier,
 // pubsub message, and handler to send to the queue channel for processing.
 type redisMessageWrapper struct {
-	messageID string
-	message   pubsub.NewMessage
-	handler   pubsub.Handler
+	messageID    string
+	partitionKey string
+	message      pubsub.NewMessage
+	handler      pubsub.Handler
+}
+
+// delayedMessage is a message parked in the delayed messages sorted set.
+type delayedMessage struct {
+	// ID makes every parked message a distinct member of the sorted set.
+	ID           string `json:"id"`
+	Topic        string `json:"topic"`
+	Data         []byte `json:"data"`
+	PartitionKey string `json:"partitionKey,omitempty"`
 }
 
 // NewRedisStreams returns a new redis streams pub-sub implementation.
@@ -71,10 +99,11 @@ func NewRedisStreams(logger logger.Logger) pubsub.PubSub {
 func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 	// Default values
 	m := metadata{
-		processingTimeout: 60 * time.Second,
-		redeliverInterval: 15 * time.Second,
-		queueDepth:        100,
-		concurrency:       10,
+		processingTimeout:   60 * time.Second,
+		redeliverInterval:   15 * time.Second,
+		queueDepth:          100,
+		concurrency:         10,
+		delayedPollInterval: time.Second,
 	}
 
 	if val, ok := meta.Properties[consumerID]; ok && val != "" {
@@ -83,6 +112,16 @@ func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 		return m, errors.New("redis streams error: missing consumerID")
 	}
 
+	// The consumer name identifies the instance in the consumer group, stable across restarts.
+	m.consumerName = meta.Properties[consumerName]
+	if m.consumerName == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = uuid.New().String()
+		}
+		m.consumerName = hostname
+	}
+
 	if val, ok := meta.Properties[processingTimeout]; ok && val != "" {
 		if processingTimeoutMs, err := strconv.ParseUint(val, 10, 64); err == nil {
 			m.processingTimeout = time.Duration(processingTimeoutMs) * time.Millisecond
@@ -127,6 +166,50 @@ func parseRedisMetadata(meta pubsub.Metadata) (metadata, error) {
 		m.maxLenApprox = maxLenApprox
 	}
 
+	if val, ok := meta.Properties[delayedPollInterval]; ok && val != "" {
+		if delayedPollIntervalMs, err := strconv.ParseUint(val, 10, 64); err == nil {
+			m.delayedPollInterval = time.Duration(delayedPollIntervalMs) * time.Millisecond
+		} else if d, err := time.ParseDuration(val); err == nil {
+			m.delayedPollInterval = d
+		} else {
+			return m, fmt.Errorf("redis streams error: can't parse delayedPollInterval field: %s", err)
+		}
+	}
+
+	m.streamStartID = "0"
+	if val, ok := meta.Properties[streamStartID]; ok && val != "" {
+		startID, err := parseStreamStartID(val)
+		if err != nil {
+			return m, fmt.Errorf("redis streams error: invalid streamStartID %s, %s", val, err)
+		}
+		m.streamStartID = startID
+	}
+
+	if val, ok := meta.Properties[maxAge]; ok && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return m, fmt.Errorf("redis streams error: can't parse maxAge field: %s", err)
+		}
+		if m.maxLenApprox > 0 {
+			return m, errors.New("redis streams error: maxAge and maxLenApprox can't be used together")
+		}
+		m.maxAge = d
+	}
+
+	if val, ok := meta.Properties[consumerIdleTimeout]; ok && val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return m, fmt.Errorf("redis streams error: can't parse consumerIdleTimeout field: %s", err)
+		}
+		m.consumerIdleTimeout = d
+	}
+
+	concurrencyMode, err := pubsub.Concurrency(meta.Properties)
+	if err != nil {
+		return m, fmt.Errorf("redis streams error: %s", err)
+	}
+	m.concurrencyMode = concurrencyMode
+
 	return m, nil
 }
 
@@ -146,6 +229,17 @@ func (r *redisStreams) Init(metadata pubsub.Metadata) error {
 	if _, err = r.client.Ping(r.ctx).Result(); err != nil {
 		return fmt.Errorf("redis streams: error connecting to redis at %s: %s", r.clientSettings.Host, err)
 	}
+	if r.metadata.delayedPollInterval > 0 {
+		go r.deliverDelayedMessagesLoop()
+	}
+
+	if r.metadata.concurrencyMode == pubsub.Partitioned {
+		// Each worker owns a subset of the partition keys and processes their messages in order.
+		r.executor = pubsub.NewPartitionedExecutor(int(r.metadata.concurrency), int(r.metadata.queueDepth))
+
+		return nil
+	}
+
 	r.queue = make(chan redisMessageWrapper, int(r.metadata.queueDepth))
 
 	for i := uint(0); i < r.metadata.concurrency; i++ {
@@ -156,11 +250,68 @@ func (r *redisStreams) Init(metadata pubsub.Metadata) error {
 }
 
 func (r *redisStreams) Publish(req *pubsub.PublishRequest) error {
-	_, err := r.client.XAdd(r.ctx, &redis.XAddArgs{
-		Stream:       req.Topic,
+	partitionKey := req.Metadata[pubsub.PartitionKeyMetadata]
+
+	deliverAt, ok, err := contrib_metadata.TryGetDeliverAt(req.Metadata, time.Now())
+	if err != nil {
+		return fmt.Errorf("redis streams: error from publish: %s", err)
+	}
+	if ok && r.metadata.delayedPollInterval == 0 {
+		return errors.New("redis streams: error from publish: delayed delivery is disabled")
+	}
+	if ok && deliverAt.After(time.Now()) {
+		return r.parkDelayedMessage(req.Topic, req.Data, partitionKey, deliverAt)
+	}
+
+	return r.addToStream(req.Topic, req.Data, partitionKey)
+}
+
+func (r *redisStreams) addToStream(stream string, data []byte, partitionKey string) error {
+	_, err := r.client.XAdd(r.ctx, r.xaddArgs(stream, data, partitionKey)).Result()
+	if err != nil {
+		return fmt.Errorf("redis streams: error from publish: %s", err)
+	}
+
+	return nil
+}
+
+func (r *redisStreams) xaddArgs(stream string, data []byte, partitionKey string) *redis.XAddArgs {
+	values := map[string]interface{}{"data": data}
+	if partitionKey != "" {
+		values[partitionKeyField] = partitionKey
+	}
+
+	args := &redis.XAddArgs{
+		Stream:       stream,
 		MaxLenApprox: r.metadata.maxLenApprox,
-		Values:       map[string]interface{}{"data": req.Data},
-	}).Result()
+		Values:       values,
+	}
+	if r.metadata.maxAge > 0 {
+		// Trim entries older than maxAge, stream IDs start with their creation time in milliseconds.
+		args.MinID = strconv.FormatInt(time.Now().Add(-r.metadata.maxAge).UnixMilli(), 10)
+		args.Approx = true
+	}
+
+	return args
+}
+
+// parkDelayedMessage stores a message in the delayed messages sorted set
+// until `deliverDelayedMessagesLoop` moves it to its stream.
+func (r *redisStreams) parkDelayedMessage(stream string, data []byte, partitionKey string, deliverAt time.Time) error {
+	member, err := json.Marshal(delayedMessage{
+		ID:           uuid.New().String(),
+		Topic:        stream,
+		Data:         data,
+		PartitionKey: partitionKey,
+	})
+	if err != nil {
+		return fmt.Errorf("redis streams: error from publish: %s", err)
+	}
+
+	err = r.client.ZAdd(r.ctx, delayedMessagesKey, &redis.Z{
+		Score:  float64(deliverAt.UnixMilli()),
+		Member: member,
+	}).Err()
 	if err != nil {
 		return fmt.Errorf("redis streams: error from publish: %s", err)
 	}
@@ -168,17 +319,146 @@ func (r *redisStreams) Publish(req *pubsub.PublishRequest) error {
 	return nil
 }
 
+// deliverDelayedMessagesLoop periodically moves delayed messages that are due
+// to their streams, based on the `delayedPollInterval` setting.
+func (r *redisStreams) deliverDelayedMessagesLoop() {
+	ticker := time.NewTicker(r.metadata.delayedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+
+		case <-ticker.C:
+			r.deliverDelayedMessages()
+		}
+	}
+}
+
+// deliverDelayedMessages moves the delayed messages that are due to their streams.
+// Every instance of the component runs it, so a message is only moved by the
+// instance that manages to remove it from the sorted set.
+func (r *redisStreams) deliverDelayedMessages() {
+	members, err := r.client.ZRangeByScoreWithScores(r.ctx, delayedMessagesKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: int64(r.metadata.queueDepth),
+	}).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			r.logger.Errorf("redis streams: error retrieving delayed messages: %s", err)
+		}
+
+		return
+	}
+
+	for _, z := range members {
+		member, _ := z.Member.(string)
+
+		var msg delayedMessage
+		if err = json.Unmarshal([]byte(member), &msg); err != nil {
+			r.logger.Errorf("redis streams: error decoding delayed message: %s", err)
+
+			continue
+		}
+
+		if r.clientSettings != nil && r.clientSettings.RedisType == rediscomponent.ClusterType {
+			err = r.moveDelayedMessageCluster(z, msg)
+		} else {
+			err = r.moveDelayedMessage(member, msg)
+		}
+		if err != nil {
+			r.logger.Errorf("redis streams: error delivering delayed message %s to %s: %s", msg.ID, msg.Topic, err)
+		}
+	}
+}
+
+// moveDelayedMessageScript adds a delayed message to its stream and removes it from the sorted
+// set in one step, so that it is neither lost nor delivered twice. Scripts are not rolled back
+// on errors, the message is only removed once it is added.
+var moveDelayedMessageScript = redis.NewScript(`
+if not redis.call("ZSCORE", KEYS[1], ARGV[1]) then
+	return false
+end
+local id = redis.call("XADD", KEYS[2], unpack(ARGV, 2))
+redis.call("ZREM", KEYS[1], ARGV[1])
+return id
+`)
+
+func (r *redisStreams) moveDelayedMessage(member string, msg delayedMessage) error {
+	args := r.xaddArgs(msg.Topic, msg.Data, msg.PartitionKey)
+	argv := []interface{}{member}
+	if args.MaxLenApprox > 0 {
+		argv = append(argv, "MAXLEN", "~", args.MaxLenApprox)
+	}
+	if args.MinID != "" {
+		argv = append(argv, "MINID", "~", args.MinID)
+	}
+	argv = append(argv, "*")
+	for field, value := range args.Values.(map[string]interface{}) {
+		argv = append(argv, field, value)
+	}
+
+	err := moveDelayedMessageScript.Run(r.ctx, r.client, []string{delayedMessagesKey, msg.Topic}, argv...).Err()
+	if errors.Is(err, redis.Nil) {
+		// Claimed by another instance.
+		return nil
+	}
+
+	return err
+}
+
+// moveDelayedMessageCluster moves a delayed message in a redis cluster, where the sorted set and
+// the stream may live on different nodes and can't be updated by one script. The message is
+// parked again when it can't be added to its stream.
+func (r *redisStreams) moveDelayedMessageCluster(z redis.Z, msg delayedMessage) error {
+	removed, err := r.client.ZRem(r.ctx, delayedMessagesKey, z.Member).Result()
+	if err != nil || removed == 0 {
+		// Not claimed, or claimed by another instance.
+		return err
+	}
+
+	if err = r.addToStream(msg.Topic, msg.Data, msg.PartitionKey); err != nil {
+		if parkErr := r.client.ZAdd(r.ctx, delayedMessagesKey, &z).Err(); parkErr != nil {
+			return fmt.Errorf("%s, and error parking it again: %s", err, parkErr)
+		}
+	}
+
+	return err
+}
+
 func (r *redisStreams) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
-	err := r.client.XGroupCreateMkStream(r.ctx, req.Topic, r.metadata.consumerID, "0").Err()
-	// Ignore BUSYGROUP errors
-	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+	startFrom, err := pubsub.StartFrom(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("redis streams: %s", err)
+	}
+
+	startID := r.metadata.streamStartID
+	if startFrom != nil {
+		if startID, err = startFromStreamID(startFrom); err != nil {
+			return fmt.Errorf("redis streams: %s", err)
+		}
+	}
+
+	err = r.client.XGroupCreateMkStream(r.ctx, req.Topic, r.metadata.consumerID, startID).Err()
+	if err != nil && err.Error() == "BUSYGROUP Consumer Group name already exists" {
+		// An existing group resumes where it stopped, unless the subscription sets its start.
+		err = nil
+		if startFrom != nil {
+			err = r.client.XGroupSetID(r.ctx, req.Topic, r.metadata.consumerID, startID).Err()
+		}
+	}
+	if err != nil {
 		r.logger.Errorf("redis streams: %s", err)
 
 		return err
 	}
 
+	handler = r.stats.Handler(req.Topic, handler)
 	go r.pollNewMessagesLoop(req.Topic, handler)
 	go r.reclaimPendingMessagesLoop(req.Topic, handler)
+	go r.removeIdleConsumersLoop(req.Topic)
 
 	return nil
 }
@@ -190,6 +470,14 @@ func (r *redisStreams) enqueueMessages(stream string, handler pubsub.Handler, ms
 	for _, msg := range msgs {
 		rmsg := createRedisMessageWrapper(stream, handler, msg)
 
+		if r.executor != nil {
+			if err := r.executor.Submit(r.ctx, rmsg.partitionKey, func() { r.processMessage(rmsg) }); err != nil {
+				return
+			}
+
+			continue
+		}
+
 		select {
 		// Might block if the queue is full so we need the r.ctx.Done below.
 		case r.queue <- rmsg:
@@ -214,13 +502,19 @@ func createRedisMessageWrapper(stream string, handler pubsub.Handler, msg redis.
 		}
 	}
 
+	var partitionKey string
+	if keyValue, ok := msg.Values[partitionKeyField].(string); ok {
+		partitionKey = keyValue
+	}
+
 	return redisMessageWrapper{
 		message: pubsub.NewMessage{
 			Topic: stream,
 			Data:  data,
 		},
-		messageID: msg.ID,
-		handler:   handler,
+		messageID:    msg.ID,
+		partitionKey: partitionKey,
+		handler:      handler,
 	}
 }
 
@@ -278,7 +572,7 @@ func (r *redisStreams) pollNewMessagesLoop(stream string, handler pubsub.Handler
 		// Read messages
 		streams, err := r.client.XReadGroup(r.ctx, &redis.XReadGroupArgs{
 			Group:    r.metadata.consumerID,
-			Consumer: r.metadata.consumerID,
+			Consumer: r.metadata.consumerName,
 			Streams:  []string{stream, ">"},
 			Count:    int64(r.metadata.queueDepth),
 			Block:    time.Duration(r.clientSettings.ReadTimeout),
@@ -358,7 +652,7 @@ func (r *redisStreams) reclaimPendingMessages(stream string, handler pubsub.Hand
 		claimResult, err := r.client.XClaim(r.ctx, &redis.XClaimArgs{
 			Stream:   stream,
 			Group:    r.metadata.consumerID,
-			Consumer: r.metadata.consumerID,
+			Consumer: r.metadata.consumerName,
 			MinIdle:  r.metadata.processingTimeout,
 			Messages: msgIDs,
 		}).Result()
@@ -369,6 +663,7 @@ func (r *redisStreams) reclaimPendingMessages(stream string, handler pubsub.Hand
 		}
 
 		// Enqueue claimed messages
+		r.stats.Redelivered(stream, int64(len(claimResult)))
 		r.enqueueMessages(stream, handler, claimResult)
 
 		// If the Redis nil error is returned, it means somes message in the pending
@@ -398,7 +693,7 @@ func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(stream string,
 		claimResultSingleMsg, err := r.client.XClaim(r.ctx, &redis.XClaimArgs{
 			Stream:   stream,
 			Group:    r.metadata.consumerID,
-			Consumer: r.metadata.consumerID,
+			Consumer: r.metadata.consumerName,
 			MinIdle:  r.metadata.processingTimeout,
 			Messages: []string{pendingID},
 		}).Result()
@@ -422,10 +717,17 @@ func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(stream string,
 
 func (r *redisStreams) Close() error {
 	r.cancel()
+	if r.executor != nil {
+		r.executor.Close()
+	}
 
 	return r.client.Close()
 }
 
 func (r *redisStreams) Features() []pubsub.Feature {
-	return nil
+	if r.metadata.delayedPollInterval == 0 {
+		return nil
+	}
+
+	return []pubsub.Feature{pubsub.FeatureDelayedDelivery}
 }
@@ -17,12 +17,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -49,7 +54,61 @@ func TestParseRedisMetadata(t *testing.T) {
 		// assert
 		assert.NoError(t, err)
 		assert.Equal(t, fakeProperties[consumerID], m.consumerID)
+		hostname, _ := os.Hostname()
+		assert.Equal(t, hostname, m.consumerName)
 		assert.Equal(t, int64(1000), m.maxLenApprox)
+		assert.Equal(t, pubsub.Parallel, m.concurrencyMode)
+		assert.Equal(t, "0", m.streamStartID)
+	})
+
+	t.Run("consumer group settings", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		delete(fakeProperties, maxLenApprox)
+		fakeProperties[streamStartID] = "2022-05-01T10:00:00Z"
+		fakeProperties[maxAge] = "24h"
+		fakeProperties[consumerIdleTimeout] = "1h"
+
+		// act
+		m, err := parseRedisMetadata(pubsub.Metadata{Properties: fakeProperties})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "1651399200000-0", m.streamStartID)
+		assert.Equal(t, 24*time.Hour, m.maxAge)
+		assert.Equal(t, time.Hour, m.consumerIdleTimeout)
+	})
+
+	t.Run("maxAge and maxLenApprox are exclusive", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[maxAge] = "24h"
+
+		// act
+		_, err := parseRedisMetadata(pubsub.Metadata{Properties: fakeProperties})
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("partitioned concurrency mode", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[pubsub.ConcurrencyKey] = string(pubsub.Partitioned)
+
+		// act
+		m, err := parseRedisMetadata(pubsub.Metadata{Properties: fakeProperties})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, pubsub.Partitioned, m.concurrencyMode)
+	})
+
+	t.Run("consumerName is given", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[consumerName] = "replica-1"
+
+		m, err := parseRedisMetadata(pubsub.Metadata{Properties: fakeProperties})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "replica-1", m.consumerName)
 	})
 
 	t.Run("consumerID is not given", func(t *testing.T) {
@@ -107,6 +166,229 @@ func TestProcessStreams(t *testing.T) {
 	assert.Equal(t, 3, messageCount)
 }
 
+func TestProcessStreamsPartitioned(t *testing.T) {
+	var lock sync.Mutex
+	received := map[string][]string{}
+	keyOf := func(id string) string {
+		return fmt.Sprintf("key-%c", id[len(id)-1]%2+'0')
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(6)
+
+	fakeHandler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		defer wg.Done()
+
+		lock.Lock()
+		key := keyOf(string(msg.Data))
+		received[key] = append(received[key], string(msg.Data))
+		lock.Unlock()
+
+		// return fake error to skip executing redis client command
+		return errors.New("fake error")
+	}
+
+	// act
+	testRedisStream := &redisStreams{logger: logger.NewLogger("test")}
+	testRedisStream.ctx, testRedisStream.cancel = context.WithCancel(context.Background())
+	testRedisStream.executor = pubsub.NewPartitionedExecutor(3, 10)
+	msgs := make([]redis.XMessage, 6)
+	for i := range msgs {
+		msgs[i] = redis.XMessage{
+			ID: fmt.Sprintf("%d", i),
+			Values: map[string]interface{}{
+				"data":            fmt.Sprintf("%d", i),
+				partitionKeyField: keyOf(fmt.Sprintf("%d", i)),
+			},
+		}
+	}
+	testRedisStream.enqueueMessages("stream", fakeHandler, msgs)
+
+	// Wait for the handler to finish processing
+	wg.Wait()
+	testRedisStream.executor.Close()
+
+	// assert
+	assert.Equal(t, []string{"0", "2", "4"}, received["key-0"])
+	assert.Equal(t, []string{"1", "3", "5"}, received["key-1"])
+}
+
+func TestDelayedDelivery(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	testRedisStream := &redisStreams{
+		logger:   logger.NewLogger("test"),
+		client:   redis.NewClient(&redis.Options{Addr: s.Addr()}),
+		metadata: metadata{queueDepth: 10, delayedPollInterval: time.Second},
+	}
+	testRedisStream.ctx, testRedisStream.cancel = context.WithCancel(context.Background())
+	defer testRedisStream.cancel()
+
+	t.Run("future message is parked", func(t *testing.T) {
+		err := testRedisStream.Publish(&pubsub.PublishRequest{
+			Topic:    "delayed",
+			Data:     []byte("later"),
+			Metadata: map[string]string{"delaySeconds": "60"},
+		})
+		assert.NoError(t, err)
+
+		parked, err := testRedisStream.client.ZCard(testRedisStream.ctx, delayedMessagesKey).Result()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), parked)
+		assert.False(t, s.Exists("delayed"))
+	})
+
+	t.Run("due message is moved to its stream", func(t *testing.T) {
+		err := testRedisStream.parkDelayedMessage("due", []byte{0x00, 0xff}, "key", time.Now().Add(-time.Second))
+		assert.NoError(t, err)
+
+		testRedisStream.deliverDelayedMessages()
+
+		entries, err := testRedisStream.client.XRange(testRedisStream.ctx, "due", "-", "+").Result()
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, string([]byte{0x00, 0xff}), entries[0].Values["data"])
+		assert.Equal(t, "key", entries[0].Values[partitionKeyField])
+
+		parked, err := testRedisStream.client.ZCard(testRedisStream.ctx, delayedMessagesKey).Result()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), parked)
+	})
+
+	for name, settings := range map[string]*rediscomponent.Settings{
+		"message stays parked when its stream can't be written":               nil,
+		"message is parked again when its stream can't be written in cluster": {RedisType: rediscomponent.ClusterType},
+	} {
+		t.Run(name, func(t *testing.T) {
+			testRedisStream.clientSettings = settings
+			defer func() { testRedisStream.clientSettings = nil }()
+
+			s.Set("broken", "not a stream")
+			err := testRedisStream.parkDelayedMessage("broken", []byte("data"), "", time.Now().Add(-time.Second))
+			assert.NoError(t, err)
+
+			testRedisStream.deliverDelayedMessages()
+
+			parked, err := testRedisStream.client.ZCard(testRedisStream.ctx, delayedMessagesKey).Result()
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), parked)
+
+			s.Del("broken")
+			testRedisStream.deliverDelayedMessages()
+
+			entries, err := testRedisStream.client.XRange(testRedisStream.ctx, "broken", "-", "+").Result()
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1)
+			parked, err = testRedisStream.client.ZCard(testRedisStream.ctx, delayedMessagesKey).Result()
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), parked)
+			s.Del("broken")
+		})
+	}
+}
+
+func TestParseStreamStartID(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+		err      bool
+	}{
+		{"$", "$", false},
+		{"0", "0", false},
+		{"1651399200000-5", "1651399200000-5", false},
+		{"1651399200000", "1651399200000-0", false},
+		{"2022-05-01T10:00:00Z", "1651399200000-0", false},
+		{"yesterday", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			id, err := parseStreamStartID(tt.in)
+
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, id)
+			}
+		})
+	}
+}
+
+func TestStartFromStreamID(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       pubsub.StartPosition
+		expected string
+		err      bool
+	}{
+		{"earliest", pubsub.StartPosition{Kind: pubsub.StartFromEarliest}, "0", false},
+		{"latest", pubsub.StartPosition{Kind: pubsub.StartFromLatest}, "$", false},
+		{"time", pubsub.StartPosition{Kind: pubsub.StartFromTime, Time: time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)}, "1651399200000-0", false},
+		{"sequence", pubsub.StartPosition{Kind: pubsub.StartFromSequence, Sequence: 42}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := startFromStreamID(&tt.in)
+
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, id)
+			}
+		})
+	}
+}
+
+func TestNextStreamID(t *testing.T) {
+	id, err := nextStreamID("1651399200000-5")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1651399200000-6", id)
+
+	_, err = nextStreamID("invalid")
+	assert.Error(t, err)
+}
+
+func TestStreamLag(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	testRedisStream := &redisStreams{client: redis.NewClient(&redis.Options{Addr: s.Addr()})}
+	ctx := context.Background()
+	var ids []string
+	for i := 0; i < maxStreamLag+10; i++ {
+		id, err := testRedisStream.client.XAdd(ctx, &redis.XAddArgs{Stream: "lag", Values: []interface{}{"data", i}}).Result()
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	last := ids[len(ids)-1]
+
+	lag, err := testRedisStream.streamLag(ctx, "lag", ids[len(ids)-4], last)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), lag)
+
+	lag, err = testRedisStream.streamLag(ctx, "lag", last, last)
+	require.NoError(t, err)
+	assert.Zero(t, lag)
+
+	// The count stops at maxStreamLag.
+	lag, err = testRedisStream.streamLag(ctx, "lag", "0-0", last)
+	require.NoError(t, err)
+	assert.Equal(t, int64(maxStreamLag), lag)
+}
+
+func TestReplyFields(t *testing.T) {
+	fields := replyFields([]interface{}{"name", "group", "lag", int64(3), "entries-read", nil, "dangling"})
+
+	assert.Equal(t, map[string]interface{}{"name": "group", "lag": int64(3), "entries-read": nil}, fields)
+}
+
 func generateRedisStreamTestData(topicCount, messageCount int, data string) []redis.XMessage {
 	generateXMessage := func(id int) redis.XMessage {
 		return redis.XMessage{
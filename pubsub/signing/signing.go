@@ -0,0 +1,350 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package signing wraps a pub/sub to sign the cloud events it publishes and verify the ones it receives.
+//
+// The signature covers the cloud event without its signature attributes, encoded as JSON with
+// sorted keys. It is carried, base64 encoded, by the `signature` extension attribute, along with
+// the `signaturealg` and `signaturekeyid` attributes naming the algorithm and the key.
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	SignatureField      = "signature"
+	SignatureAlgField   = "signaturealg"
+	SignatureKeyIDField = "signaturekeyid"
+)
+
+// Algorithm is a signature algorithm.
+type Algorithm string
+
+const (
+	// HMACSHA256 signs with a shared secret, the value of the key secret.
+	HMACSHA256 Algorithm = "hmac-sha256"
+	// Ed25519 signs with a private key, a base64 encoded seed or private key, and verifies
+	// with a base64 encoded public key, or the private key.
+	Ed25519 Algorithm = "ed25519"
+)
+
+var (
+	// ErrUnsigned is returned for received messages without signature when signatures are required.
+	ErrUnsigned = errors.New("message is not signed")
+	// ErrInvalidSignature is returned for received messages whose signature does not match.
+	ErrInvalidSignature = errors.New("invalid message signature")
+)
+
+// DefaultKeyCacheTTL is the time keys read from the secret store are cached by default.
+const DefaultKeyCacheTTL = 5 * time.Minute
+
+// Options configures the signature of messages. Keys are secrets of SecretStore, read again once
+// their cache entry expires.
+type Options struct {
+	Algorithm   Algorithm
+	SecretStore secretstores.SecretStore
+	// KeyCacheTTL is the time keys are cached, DefaultKeyCacheTTL by default.
+	KeyCacheTTL time.Duration
+	// KeyID is the name of the secret signing published messages.
+	KeyID string
+	// TrustedKeyIDs are the names of the secrets verifying received messages, KeyID by default.
+	TrustedKeyIDs []string
+	// RequireSignature rejects the received messages that are not signed.
+	RequireSignature bool
+	// DeadLetter receives the rejected messages. Without it, those messages are dropped.
+	DeadLetter pubsub.Handler
+}
+
+type signingPubSub struct {
+	pubsub.PubSub
+	opts   Options
+	logger logger.Logger
+
+	keys map[string]cachedKey
+	lock sync.Mutex
+	now  func() time.Time
+}
+
+type cachedKey struct {
+	value   []byte
+	expires time.Time
+}
+
+// New returns a pub/sub signing the cloud events it publishes and rejecting
+// the messages it receives with a missing or invalid signature.
+func New(inner pubsub.PubSub, opts Options, logger logger.Logger) pubsub.PubSub {
+	if len(opts.TrustedKeyIDs) == 0 && opts.KeyID != "" {
+		opts.TrustedKeyIDs = []string{opts.KeyID}
+	}
+	if opts.KeyCacheTTL <= 0 {
+		opts.KeyCacheTTL = DefaultKeyCacheTTL
+	}
+
+	return &signingPubSub{
+		PubSub: inner,
+		opts:   opts,
+		logger: logger,
+		keys:   make(map[string]cachedKey),
+		now:    time.Now,
+	}
+}
+
+func (s *signingPubSub) Publish(req *pubsub.PublishRequest) error {
+	cloudEvent, err := parseCloudEvent(req.Data)
+	if err != nil {
+		return fmt.Errorf("cannot sign message of topic %s: %w", req.Topic, err)
+	}
+
+	signature, err := s.sign(cloudEvent)
+	if err != nil {
+		return fmt.Errorf("cannot sign message of topic %s: %w", req.Topic, err)
+	}
+	cloudEvent[SignatureField] = signature
+	cloudEvent[SignatureAlgField] = string(s.opts.Algorithm)
+	cloudEvent[SignatureKeyIDField] = s.opts.KeyID
+
+	data, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+
+	signed := *req
+	signed.Data = data
+
+	return s.PubSub.Publish(&signed)
+}
+
+func (s *signingPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	return s.PubSub.Subscribe(req, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		err := s.verify(msg.Data)
+		if err == nil {
+			return handler(ctx, msg)
+		}
+		if !errors.Is(err, ErrUnsigned) && !errors.Is(err, ErrInvalidSignature) {
+			// The key could not be read, let the message be redelivered.
+			return fmt.Errorf("signing: cannot verify message of topic %s: %w", msg.Topic, err)
+		}
+
+		if s.opts.DeadLetter == nil {
+			s.logger.Errorf("signing: dropping message of topic %s: %s", msg.Topic, err)
+
+			return nil
+		}
+
+		s.logger.Warnf("signing: routing message of topic %s to the dead letter handler: %s", msg.Topic, err)
+
+		return s.opts.DeadLetter(ctx, msg)
+	})
+}
+
+func (s *signingPubSub) sign(cloudEvent map[string]interface{}) (string, error) {
+	payload, err := signedPayload(cloudEvent)
+	if err != nil {
+		return "", err
+	}
+	key, err := s.key(s.opts.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	var signature []byte
+	switch s.opts.Algorithm {
+	case HMACSHA256:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(payload)
+		signature = mac.Sum(nil)
+	case Ed25519:
+		privateKey, err := ed25519PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		signature = ed25519.Sign(privateKey, payload)
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %s", s.opts.Algorithm)
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// verify checks the signature of a received message.
+func (s *signingPubSub) verify(data []byte) error {
+	cloudEvent, err := parseCloudEvent(data)
+	if err != nil || cloudEvent[SignatureField] == nil {
+		if s.opts.RequireSignature {
+			return ErrUnsigned
+		}
+
+		return nil
+	}
+
+	alg, _ := cloudEvent[SignatureAlgField].(string)
+	keyID, _ := cloudEvent[SignatureKeyIDField].(string)
+	encoded, _ := cloudEvent[SignatureField].(string)
+	if Algorithm(alg) != s.opts.Algorithm {
+		return fmt.Errorf("%w: unexpected algorithm %s", ErrInvalidSignature, alg)
+	}
+	if !s.trusted(keyID) {
+		return fmt.Errorf("%w: untrusted key %s", ErrInvalidSignature, keyID)
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+
+	payload, err := signedPayload(cloudEvent)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	key, err := s.key(keyID)
+	if err != nil {
+		return err
+	}
+
+	var valid bool
+	switch s.opts.Algorithm {
+	case HMACSHA256:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(payload)
+		valid = hmac.Equal(signature, mac.Sum(nil))
+	case Ed25519:
+		publicKey, err := ed25519PublicKey(key)
+		if err != nil {
+			return err
+		}
+		valid = ed25519.Verify(publicKey, payload, signature)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (s *signingPubSub) trusted(keyID string) bool {
+	for _, id := range s.opts.TrustedKeyIDs {
+		if id == keyID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// key returns the value of the secret named keyID, reading it from the secret store
+// on first use and once its cache entry expires.
+func (s *signingPubSub) key(keyID string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	if key, ok := s.keys[keyID]; ok && now.Before(key.expires) {
+		return key.value, nil
+	}
+
+	if s.opts.SecretStore == nil {
+		return nil, errors.New("no secret store to read signing keys from")
+	}
+	resp, err := s.opts.SecretStore.GetSecret(secretstores.GetSecretRequest{Name: keyID})
+	if err != nil {
+		return nil, fmt.Errorf("error reading signing key %s: %w", keyID, err)
+	}
+
+	value, ok := resp.Data[keyID]
+	if !ok && len(resp.Data) == 1 {
+		for _, v := range resp.Data {
+			value = v
+		}
+	}
+	if value == "" {
+		return nil, fmt.Errorf("signing key %s not found", keyID)
+	}
+
+	s.keys[keyID] = cachedKey{value: []byte(value), expires: now.Add(s.opts.KeyCacheTTL)}
+
+	return []byte(value), nil
+}
+
+func ed25519PrivateKey(key []byte) (ed25519.PrivateKey, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ed25519 key: %w", err)
+	}
+
+	switch len(decoded) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(decoded), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(decoded), nil
+	default:
+		return nil, fmt.Errorf("invalid ed25519 private key size %d", len(decoded))
+	}
+}
+
+func ed25519PublicKey(key []byte) (ed25519.PublicKey, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ed25519 key: %w", err)
+	}
+
+	switch len(decoded) {
+	case ed25519.PublicKeySize:
+		return ed25519.PublicKey(decoded), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(decoded).Public().(ed25519.PublicKey), nil
+	default:
+		return nil, fmt.Errorf("invalid ed25519 public key size %d", len(decoded))
+	}
+}
+
+// signedPayload encodes the cloud event without its signature attributes. Maps are encoded with sorted keys.
+func signedPayload(cloudEvent map[string]interface{}) ([]byte, error) {
+	unsigned := make(map[string]interface{}, len(cloudEvent))
+	for k, v := range cloudEvent {
+		switch k {
+		case SignatureField, SignatureAlgField, SignatureKeyIDField:
+		default:
+			unsigned[k] = v
+		}
+	}
+
+	return json.Marshal(unsigned)
+}
+
+func parseCloudEvent(data []byte) (map[string]interface{}, error) {
+	var cloudEvent map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cloudEvent); err != nil {
+		return nil, fmt.Errorf("message is not a cloud event: %w", err)
+	}
+	if cloudEvent[pubsub.SpecVersionField] == nil {
+		return nil, errors.New("message is not a cloud event")
+	}
+
+	return cloudEvent, nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	inmemory "github.com/dapr/components-contrib/pubsub/in-memory"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeSecretStore map[string]string
+
+func (f fakeSecretStore) Init(metadata secretstores.Metadata) error {
+	return nil
+}
+
+func (f fakeSecretStore) GetSecret(req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	return secretstores.GetSecretResponse{Data: map[string]string{req.Name: f[req.Name]}}, nil
+}
+
+func (f fakeSecretStore) BulkGetSecret(req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	return secretstores.BulkGetSecretResponse{}, nil
+}
+
+func cloudEvent(t *testing.T, data string) []byte {
+	t.Helper()
+
+	ce := pubsub.NewCloudEventsEnvelope("id", "app", "order", "", "orders", "pubsub", "application/json", []byte(data), "", "")
+	b, err := json.Marshal(ce)
+	require.NoError(t, err)
+
+	return b
+}
+
+type received struct {
+	messages     [][]byte
+	deadLettered [][]byte
+}
+
+func subscribe(t *testing.T, ps pubsub.PubSub, opts Options, l logger.Logger) *received {
+	t.Helper()
+
+	r := &received{}
+	opts.DeadLetter = func(ctx context.Context, msg *pubsub.NewMessage) error {
+		r.deadLettered = append(r.deadLettered, msg.Data)
+
+		return nil
+	}
+	err := New(ps, opts, l).Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		r.messages = append(r.messages, msg.Data)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return r
+}
+
+func newBus(t *testing.T, l logger.Logger) pubsub.PubSub {
+	t.Helper()
+
+	bus := inmemory.New(l)
+	require.NoError(t, bus.Init(pubsub.Metadata{}))
+	t.Cleanup(func() { bus.Close() })
+
+	return bus
+}
+
+func TestHMAC(t *testing.T) {
+	l := logger.NewLogger("test")
+	bus := newBus(t, l)
+	store := fakeSecretStore{"publisher": "shared-secret", "other": "other-secret"}
+
+	r := subscribe(t, bus, Options{Algorithm: HMACSHA256, SecretStore: store, TrustedKeyIDs: []string{"publisher"}, RequireSignature: true}, l)
+	publisher := New(bus, Options{Algorithm: HMACSHA256, SecretStore: store, KeyID: "publisher"}, l)
+	untrusted := New(bus, Options{Algorithm: HMACSHA256, SecretStore: store, KeyID: "other"}, l)
+
+	require.NoError(t, publisher.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, `{"orderId":1}`)}))
+	require.Len(t, r.messages, 1)
+
+	var ce map[string]interface{}
+	require.NoError(t, json.Unmarshal(r.messages[0], &ce))
+	assert.Equal(t, "hmac-sha256", ce[SignatureAlgField])
+	assert.Equal(t, "publisher", ce[SignatureKeyIDField])
+	assert.NotEmpty(t, ce[SignatureField])
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := bytes.Replace(r.messages[0], []byte(`"orderId":1`), []byte(`"orderId":2`), 1)
+		require.NoError(t, bus.Publish(&pubsub.PublishRequest{Topic: "orders", Data: tampered}))
+		assert.Len(t, r.messages, 1)
+		assert.Len(t, r.deadLettered, 1)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		require.NoError(t, bus.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, `{"orderId":3}`)}))
+		assert.Len(t, r.messages, 1)
+		assert.Len(t, r.deadLettered, 2)
+	})
+
+	t.Run("untrusted key", func(t *testing.T) {
+		require.NoError(t, untrusted.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, `{"orderId":4}`)}))
+		assert.Len(t, r.messages, 1)
+		assert.Len(t, r.deadLettered, 3)
+	})
+}
+
+func TestEd25519(t *testing.T) {
+	l := logger.NewLogger("test")
+	bus := newBus(t, l)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	publisherStore := fakeSecretStore{"partner": base64.StdEncoding.EncodeToString(privateKey.Seed())}
+	subscriberStore := fakeSecretStore{"partner": base64.StdEncoding.EncodeToString(publicKey)}
+
+	r := subscribe(t, bus, Options{Algorithm: Ed25519, SecretStore: subscriberStore, KeyID: "partner"}, l)
+	publisher := New(bus, Options{Algorithm: Ed25519, SecretStore: publisherStore, KeyID: "partner"}, l)
+
+	require.NoError(t, publisher.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, `{"orderId":1.50}`)}))
+	assert.Len(t, r.messages, 1)
+	assert.Empty(t, r.deadLettered)
+
+	// Unsigned messages are accepted unless signatures are required.
+	require.NoError(t, bus.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, `{"orderId":2}`)}))
+	assert.Len(t, r.messages, 2)
+}
+
+func TestPublishRawPayload(t *testing.T) {
+	l := logger.NewLogger("test")
+	publisher := New(newBus(t, l), Options{Algorithm: HMACSHA256, SecretStore: fakeSecretStore{"k": "secret"}, KeyID: "k"}, l)
+
+	err := publisher.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte("raw")})
+	assert.Error(t, err)
+}
+
+type failingSecretStore struct {
+	fakeSecretStore
+}
+
+func (f failingSecretStore) GetSecret(req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	return secretstores.GetSecretResponse{}, errors.New("store unavailable")
+}
+
+type handlerPubSub struct {
+	pubsub.PubSub
+	handler pubsub.Handler
+}
+
+func (h *handlerPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	h.handler = handler
+
+	return nil
+}
+
+func TestKeyLookupError(t *testing.T) {
+	l := logger.NewLogger("test")
+	store := fakeSecretStore{"k": "secret"}
+	publisher := New(&handlerPubSub{}, Options{Algorithm: HMACSHA256, SecretStore: store, KeyID: "k"}, l).(*signingPubSub)
+	ce, err := parseCloudEvent(cloudEvent(t, `{"orderId":1}`))
+	require.NoError(t, err)
+	signature, err := publisher.sign(ce)
+	require.NoError(t, err)
+	ce[SignatureField] = signature
+	ce[SignatureAlgField] = string(HMACSHA256)
+	ce[SignatureKeyIDField] = "k"
+	data, err := json.Marshal(ce)
+	require.NoError(t, err)
+
+	inner := &handlerPubSub{}
+	deadLettered := 0
+	opts := Options{
+		Algorithm:   HMACSHA256,
+		SecretStore: failingSecretStore{store},
+		KeyID:       "k",
+		DeadLetter: func(ctx context.Context, msg *pubsub.NewMessage) error {
+			deadLettered++
+
+			return nil
+		},
+	}
+	require.NoError(t, New(inner, opts, l).Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	}))
+
+	err = inner.handler(context.Background(), &pubsub.NewMessage{Topic: "orders", Data: data})
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidSignature))
+	assert.Zero(t, deadLettered)
+}
+
+type countingSecretStore struct {
+	fakeSecretStore
+	reads int
+}
+
+func (c *countingSecretStore) GetSecret(req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	c.reads++
+
+	return c.fakeSecretStore.GetSecret(req)
+}
+
+func TestKeyCacheTTL(t *testing.T) {
+	store := &countingSecretStore{fakeSecretStore: fakeSecretStore{"k": "secret"}}
+	s := New(&handlerPubSub{}, Options{Algorithm: HMACSHA256, SecretStore: store, KeyID: "k", KeyCacheTTL: time.Minute}, logger.NewLogger("test")).(*signingPubSub)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	_, err := s.key("k")
+	require.NoError(t, err)
+	store.fakeSecretStore["k"] = "rotated"
+	key, err := s.key("k")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(key))
+	assert.Equal(t, 1, store.reads)
+
+	now = now.Add(time.Minute)
+	key, err = s.key("k")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", string(key))
+	assert.Equal(t, 2, store.reads)
+}
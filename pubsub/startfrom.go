@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// StartFromKind is the kind of position a subscription starts reading from.
+type StartFromKind string
+
+const (
+	// StartFromKey is the subscription metadata key name for the StartPosition.
+	StartFromKey                    = "startFrom"
+	StartFromEarliest StartFromKind = "earliest"
+	StartFromLatest   StartFromKind = "latest"
+	StartFromTime     StartFromKind = "time"
+	StartFromSequence StartFromKind = "sequence"
+)
+
+// StartPosition is the position a subscription starts reading from.
+// Components translate it to their native seek whenever the subscription is made, moving an existing
+// consumer group, or durable subscription, too. It replays, or skips, messages again on every
+// subscribe, by every replica, so it should be removed from the subscription once it caught up.
+// Without it, subscriptions resume where they stopped. The durable subscriptions of jetstream and
+// NATS streaming are the exception: they can't be moved without removing them, so they only start
+// from it when created.
+type StartPosition struct {
+	Kind StartFromKind
+	// Time is set for StartFromTime.
+	Time time.Time
+	// Sequence is the sequence number or offset set for StartFromSequence.
+	Sequence uint64
+}
+
+// StartFrom takes a subscription metadata object and returns the StartPosition configured,
+// or nil when none is. Accepted values are earliest, latest, an RFC3339 time or a sequence number.
+func StartFrom(metadata map[string]string) (*StartPosition, error) {
+	val, ok := metadata[StartFromKey]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	switch val {
+	case string(StartFromEarliest):
+		return &StartPosition{Kind: StartFromEarliest}, nil
+	case string(StartFromLatest):
+		return &StartPosition{Kind: StartFromLatest}, nil
+	}
+
+	if seq, err := strconv.ParseUint(val, 10, 64); err == nil {
+		return &StartPosition{Kind: StartFromSequence, Sequence: seq}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return &StartPosition{Kind: StartFromTime, Time: t}, nil
+	}
+
+	return nil, fmt.Errorf("invalid %s %s", StartFromKey, val)
+}
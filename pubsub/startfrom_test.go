@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartFrom(t *testing.T) {
+	t.Run("not set", func(t *testing.T) {
+		p, err := StartFrom(map[string]string{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, p)
+	})
+
+	t.Run("earliest", func(t *testing.T) {
+		p, err := StartFrom(map[string]string{StartFromKey: "earliest"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, StartFromEarliest, p.Kind)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		p, err := StartFrom(map[string]string{StartFromKey: "latest"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, StartFromLatest, p.Kind)
+	})
+
+	t.Run("time", func(t *testing.T) {
+		p, err := StartFrom(map[string]string{StartFromKey: "2022-05-01T10:00:00Z"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, StartFromTime, p.Kind)
+		assert.Equal(t, time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC), p.Time)
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		p, err := StartFrom(map[string]string{StartFromKey: "42"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, StartFromSequence, p.Kind)
+		assert.Equal(t, uint64(42), p.Sequence)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p, err := StartFrom(map[string]string{StartFromKey: "yesterday"})
+
+		assert.Error(t, err)
+		assert.Nil(t, p)
+	})
+}
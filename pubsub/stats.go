@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TopicStats are the statistics of the subscription to a topic.
+type TopicStats struct {
+	Topic string
+	// Lag is the number of messages of the topic waiting to be delivered to the subscriber.
+	Lag int64
+	// InFlight is the number of messages being processed by the handler.
+	InFlight int64
+	// Redeliveries is the number of messages delivered again since the subscription started.
+	Redeliveries int64
+	// LastError is the last error returned by the handler, empty if it never failed.
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// StatsProvider is implemented by the pub/subs reporting statistics about their subscriptions,
+// for autoscaling and alerts.
+type StatsProvider interface {
+	// Stats returns the statistics of the subscribed topics, by topic.
+	Stats(ctx context.Context) (map[string]TopicStats, error)
+}
+
+// StatsRecorder keeps the statistics that components observe while delivering messages.
+// Components compute the lag, which depends on the broker. The zero value is ready to use.
+type StatsRecorder struct {
+	topics map[string]*TopicStats
+	lock   sync.Mutex
+}
+
+// Subscribed starts recording the statistics of topic.
+func (r *StatsRecorder) Subscribed(topic string) {
+	r.update(topic, func(*TopicStats) {})
+}
+
+// Handler returns handler recording the messages it processes and its errors.
+func (r *StatsRecorder) Handler(topic string, handler Handler) Handler {
+	r.Subscribed(topic)
+
+	return func(ctx context.Context, msg *NewMessage) error {
+		done := r.Processing(topic)
+		err := handler(ctx, msg)
+		done(err)
+
+		return err
+	}
+}
+
+// Processing records the start of the processing of a message of topic. The returned function records its end.
+func (r *StatsRecorder) Processing(topic string) func(err error) {
+	r.update(topic, func(s *TopicStats) { s.InFlight++ })
+
+	return func(err error) {
+		r.update(topic, func(s *TopicStats) {
+			s.InFlight--
+			if err != nil {
+				s.LastError = err.Error()
+				s.LastErrorTime = time.Now()
+			}
+		})
+	}
+}
+
+// Redelivered records count messages of topic delivered again.
+func (r *StatsRecorder) Redelivered(topic string, count int64) {
+	r.update(topic, func(s *TopicStats) { s.Redeliveries += count })
+}
+
+// SetLag records the lag of topic.
+func (r *StatsRecorder) SetLag(topic string, lag int64) {
+	r.update(topic, func(s *TopicStats) { s.Lag = lag })
+}
+
+// AddLag adds delta to the lag of topic.
+func (r *StatsRecorder) AddLag(topic string, delta int64) {
+	r.update(topic, func(s *TopicStats) { s.Lag += delta })
+}
+
+// Stats returns a copy of the recorded statistics.
+func (r *StatsRecorder) Stats() map[string]TopicStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	stats := make(map[string]TopicStats, len(r.topics))
+	for topic, s := range r.topics {
+		stats[topic] = *s
+	}
+
+	return stats
+}
+
+func (r *StatsRecorder) update(topic string, f func(*TopicStats)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.topics == nil {
+		r.topics = make(map[string]*TopicStats)
+	}
+	s, ok := r.topics[topic]
+	if !ok {
+		s = &TopicStats{Topic: topic}
+		r.topics[topic] = s
+	}
+	f(s)
+}
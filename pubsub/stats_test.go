@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsRecorder(t *testing.T) {
+	var r StatsRecorder
+	assert.Empty(t, r.Stats())
+
+	var inFlight int64
+	handler := r.Handler("orders", func(ctx context.Context, msg *NewMessage) error {
+		inFlight = r.Stats()["orders"].InFlight
+		if string(msg.Data) == "bad" {
+			return errors.New("bad order")
+		}
+
+		return nil
+	})
+	assert.Contains(t, r.Stats(), "orders")
+
+	assert.NoError(t, handler(context.Background(), &NewMessage{Data: []byte("good")}))
+	assert.Equal(t, int64(1), inFlight)
+	assert.Error(t, handler(context.Background(), &NewMessage{Data: []byte("bad")}))
+
+	r.Redelivered("orders", 2)
+	r.SetLag("orders", 10)
+	r.AddLag("orders", -3)
+
+	stats := r.Stats()["orders"]
+	assert.Equal(t, "orders", stats.Topic)
+	assert.Equal(t, int64(0), stats.InFlight)
+	assert.Equal(t, int64(2), stats.Redeliveries)
+	assert.Equal(t, int64(7), stats.Lag)
+	assert.Equal(t, "bad order", stats.LastError)
+	assert.False(t, stats.LastErrorTime.IsZero())
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// ErrSchemaNotFound is wrapped by the errors of loaders when a reference does not resolve to a schema.
+// Other errors are considered transient.
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// Loader returns the JSON schema documents referenced by topics, types and `dataschema` attributes.
+type Loader interface {
+	Load(ref string) ([]byte, error)
+}
+
+// FileLoader loads schemas from the files under Dir. References are paths relative to Dir,
+// absolute paths or `file://` URLs. As references can come from the `dataschema` attribute
+// of events, those resolving outside of Dir are rejected.
+type FileLoader struct {
+	Dir string
+}
+
+func (l FileLoader) Load(ref string) ([]byte, error) {
+	dir, err := filepath.Abs(l.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	path := ref
+	if strings.Contains(ref, "://") {
+		u, err := url.Parse(ref)
+		if err != nil || u.Scheme != "file" {
+			return nil, fmt.Errorf("cannot load schema %s from a file: %w", ref, ErrSchemaNotFound)
+		}
+		path = u.Path
+	}
+	if filepath.IsAbs(path) {
+		if path, err = filepath.Rel(dir, path); err != nil {
+			return nil, fmt.Errorf("schema %s is outside of %s: %w", ref, l.Dir, ErrSchemaNotFound)
+		}
+	}
+	path = filepath.Clean(path)
+	if path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("schema %s is outside of %s: %w", ref, l.Dir, ErrSchemaNotFound)
+	}
+
+	// SecureJoin keeps symbolic links from escaping dir.
+	path, err = securejoin.SecureJoin(dir, path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, err)
+	}
+
+	return doc, err
+}
+
+// StateStoreLoader loads schemas from a state store, using references as keys.
+type StateStoreLoader struct {
+	Store state.Store
+}
+
+func (l StateStoreLoader) Load(ref string) ([]byte, error) {
+	resp, err := l.Store.Get(&state.GetRequest{Key: ref})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || len(resp.Data) == 0 {
+		return nil, fmt.Errorf("schema %s not found in the state store: %w", ref, ErrSchemaNotFound)
+	}
+
+	return resp.Data, nil
+}
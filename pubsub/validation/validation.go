@@ -0,0 +1,240 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation wraps a pub/sub to validate the data of events against JSON schemas.
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+// Options selects the schemas events are validated against. The schema of an event is the one
+// referenced by its `dataschema` attribute if UseDataSchema is set, else the one of its type,
+// else the one of its topic. Events without a schema are not validated.
+type Options struct {
+	Loader Loader
+	// Topics maps topics to the schema of their events.
+	Topics map[string]string
+	// Types maps cloud event types to the schema of their data.
+	Types map[string]string
+	// UseDataSchema validates cloud events against the schema referenced by their `dataschema` attribute.
+	UseDataSchema bool
+	// DeadLetter receives the messages failing validation on the subscriber side.
+	// Without it, those messages are dropped. Messages whose `dataschema` attribute
+	// references a missing or invalid schema fail validation too, while messages whose
+	// schema can't be loaded for other reasons are returned to the component to be
+	// delivered again.
+	DeadLetter pubsub.Handler
+}
+
+// ValidationError is returned when an event does not match its schema.
+type ValidationError struct {
+	Schema string
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("event does not match schema %s: %s", e.Schema, strings.Join(e.Errors, "; "))
+}
+
+var errInvalidSchema = errors.New("schema validation: invalid schema")
+
+type validatingPubSub struct {
+	pubsub.PubSub
+	opts   Options
+	logger logger.Logger
+
+	schemas map[string]*gojsonschema.Schema
+	lock    sync.RWMutex
+}
+
+// New returns a pub/sub rejecting the publication of invalid events and routing
+// the invalid events it receives to opts.DeadLetter.
+func New(inner pubsub.PubSub, opts Options, logger logger.Logger) pubsub.PubSub {
+	return &validatingPubSub{
+		PubSub:  inner,
+		opts:    opts,
+		logger:  logger,
+		schemas: make(map[string]*gojsonschema.Schema),
+	}
+}
+
+func (v *validatingPubSub) Publish(req *pubsub.PublishRequest) error {
+	if err := v.validate(req.Topic, req.Data); err != nil {
+		return err
+	}
+
+	return v.PubSub.Publish(req)
+}
+
+func (v *validatingPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	return v.PubSub.Subscribe(req, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		err := v.validate(msg.Topic, msg.Data)
+		if err == nil {
+			return handler(ctx, msg)
+		}
+
+		// Other errors loading the schema may be transient, the message is delivered again.
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			return err
+		}
+
+		if v.opts.DeadLetter == nil {
+			v.logger.Errorf("schema validation: dropping invalid message of topic %s: %s", msg.Topic, err)
+
+			return nil
+		}
+
+		v.logger.Warnf("schema validation: routing invalid message of topic %s to the dead letter handler: %s", msg.Topic, err)
+
+		return v.opts.DeadLetter(ctx, msg)
+	})
+}
+
+// validate checks the data of a cloud event, or a raw payload, against its schema.
+func (v *validatingPubSub) validate(topic string, data []byte) error {
+	var cloudEvent map[string]interface{}
+	if err := unmarshal(data, &cloudEvent); err != nil || cloudEvent[pubsub.SpecVersionField] == nil {
+		cloudEvent = nil
+	}
+
+	ref, fromEvent := v.schemaRef(topic, cloudEvent)
+	if ref == "" {
+		return nil
+	}
+
+	doc, err := eventData(data, cloudEvent)
+	if err != nil {
+		return &ValidationError{Schema: ref, Errors: []string{err.Error()}}
+	}
+
+	schema, err := v.schema(ref)
+	if err != nil {
+		// Delivering again an event referencing a schema that doesn't exist won't change the outcome.
+		if fromEvent && (errors.Is(err, ErrSchemaNotFound) || errors.Is(err, errInvalidSchema)) {
+			return &ValidationError{Schema: ref, Errors: []string{err.Error()}}
+		}
+
+		return err
+	}
+
+	result, err := schema.Validate(gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		return &ValidationError{Schema: ref, Errors: []string{err.Error()}}
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	verr := &ValidationError{Schema: ref}
+	for _, e := range result.Errors() {
+		verr.Errors = append(verr.Errors, e.String())
+	}
+
+	return verr
+}
+
+// schemaRef returns the reference of the schema of an event, and whether the event supplied it.
+func (v *validatingPubSub) schemaRef(topic string, cloudEvent map[string]interface{}) (string, bool) {
+	if cloudEvent != nil {
+		if ref, ok := cloudEvent[pubsub.DataSchemaField].(string); ok && ref != "" && v.opts.UseDataSchema {
+			return ref, true
+		}
+		if typ, ok := cloudEvent[pubsub.TypeField].(string); ok && v.opts.Types[typ] != "" {
+			return v.opts.Types[typ], false
+		}
+	}
+
+	return v.opts.Topics[topic], false
+}
+
+// schema returns the compiled schema referenced by ref, loading it on first use.
+func (v *validatingPubSub) schema(ref string) (*gojsonschema.Schema, error) {
+	v.lock.RLock()
+	schema, ok := v.schemas[ref]
+	v.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	if v.opts.Loader == nil {
+		return nil, fmt.Errorf("schema validation: no loader for schema %s", ref)
+	}
+	doc, err := v.opts.Loader.Load(ref)
+	if err != nil {
+		return nil, fmt.Errorf("schema validation: error loading schema %s: %w", ref, err)
+	}
+	schema, err = gojsonschema.NewSchema(gojsonschema.NewBytesLoader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %s", errInvalidSchema, ref, err)
+	}
+
+	v.lock.Lock()
+	v.schemas[ref] = schema
+	v.lock.Unlock()
+
+	return schema, nil
+}
+
+// eventData returns the JSON document to validate: the data of the cloud event, or the whole payload.
+// Data that cloud event envelopes kept as a string because it was not valid JSON is validated as
+// a string, so that schemas expecting objects reject it.
+func eventData(data []byte, cloudEvent map[string]interface{}) (interface{}, error) {
+	var raw []byte
+	switch {
+	case cloudEvent == nil:
+		raw = data
+	case cloudEvent[pubsub.DataBase64Field] != nil:
+		encoded, _ := cloudEvent[pubsub.DataBase64Field].(string)
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", pubsub.DataBase64Field, err)
+		}
+		raw = decoded
+	default:
+		return cloudEvent[pubsub.DataField], nil
+	}
+
+	var doc interface{}
+	if err := unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("data is not valid JSON: %w", err)
+	}
+
+	return doc, nil
+}
+
+func unmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON document")
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	inmemory "github.com/dapr/components-contrib/pubsub/in-memory"
+	"github.com/dapr/kit/logger"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"properties": {"orderId": {"type": "integer"}},
+	"required": ["orderId"]
+}`
+
+func cloudEvent(t *testing.T, typ, dataSchema string, data []byte) []byte {
+	t.Helper()
+
+	ce := pubsub.NewCloudEventsEnvelope("id", "app", typ, "", "orders", "pubsub", "application/json", data, "", "")
+	if dataSchema != "" {
+		ce[pubsub.DataSchemaField] = dataSchema
+	}
+	b, err := json.Marshal(ce)
+	require.NoError(t, err)
+
+	return b
+}
+
+func newValidatingPubSub(t *testing.T, opts Options) pubsub.PubSub {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "order.json"), []byte(orderSchema), 0o600))
+	opts.Loader = FileLoader{Dir: dir}
+
+	l := logger.NewLogger("test")
+	v := New(inmemory.New(l), opts, l)
+	require.NoError(t, v.Init(pubsub.Metadata{}))
+	t.Cleanup(func() { v.Close() })
+
+	return v
+}
+
+func TestPublish(t *testing.T) {
+	v := newValidatingPubSub(t, Options{Topics: map[string]string{"orders": "order.json"}})
+
+	t.Run("valid event", func(t *testing.T) {
+		err := v.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, "", "", []byte(`{"orderId":1}`))})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid event", func(t *testing.T) {
+		err := v.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, "", "", []byte(`{"orderId":"one"}`))})
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.Equal(t, "order.json", verr.Schema)
+	})
+
+	t.Run("data that is not JSON", func(t *testing.T) {
+		err := v.Publish(&pubsub.PublishRequest{Topic: "orders", Data: cloudEvent(t, "", "", []byte(`{orderId:1`))})
+		assert.Error(t, err)
+	})
+
+	t.Run("raw payload", func(t *testing.T) {
+		assert.NoError(t, v.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte(`{"orderId":1}`)}))
+		assert.Error(t, v.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte(`{}`)}))
+	})
+
+	t.Run("topic without schema", func(t *testing.T) {
+		err := v.Publish(&pubsub.PublishRequest{Topic: "other", Data: []byte(`anything`)})
+		assert.NoError(t, err)
+	})
+}
+
+func TestSchemaSelection(t *testing.T) {
+	v := newValidatingPubSub(t, Options{
+		Types:         map[string]string{"order.created": "order.json"},
+		UseDataSchema: true,
+	})
+
+	assert.Error(t, v.Publish(&pubsub.PublishRequest{Topic: "any", Data: cloudEvent(t, "order.created", "", []byte(`{}`))}))
+	assert.NoError(t, v.Publish(&pubsub.PublishRequest{Topic: "any", Data: cloudEvent(t, "order.deleted", "", []byte(`{}`))}))
+	assert.Error(t, v.Publish(&pubsub.PublishRequest{Topic: "any", Data: cloudEvent(t, "", "order.json", []byte(`{}`))}))
+	assert.Error(t, v.Publish(&pubsub.PublishRequest{Topic: "any", Data: cloudEvent(t, "", "missing.json", []byte(`{}`))}))
+}
+
+func TestSubscribe(t *testing.T) {
+	var deadLettered [][]byte
+	v := newValidatingPubSub(t, Options{
+		Topics: map[string]string{"orders": "order.json"},
+		DeadLetter: func(ctx context.Context, msg *pubsub.NewMessage) error {
+			deadLettered = append(deadLettered, msg.Data)
+
+			return nil
+		},
+	})
+
+	var received [][]byte
+	err := v.Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received = append(received, msg.Data)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Publishes that bypass validation, like a producer of another team would.
+	inner := v.(*validatingPubSub).PubSub
+	require.NoError(t, inner.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte(`{"orderId":1}`)}))
+	require.NoError(t, inner.Publish(&pubsub.PublishRequest{Topic: "orders", Data: []byte(`{"orderId":"one"}`)}))
+
+	assert.Equal(t, [][]byte{[]byte(`{"orderId":1}`)}, received)
+	assert.Equal(t, [][]byte{[]byte(`{"orderId":"one"}`)}, deadLettered)
+}
+
+// subscribingPubSub keeps the handler of its subscription.
+type subscribingPubSub struct {
+	pubsub.PubSub
+	handler pubsub.Handler
+}
+
+func (s *subscribingPubSub) Subscribe(req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	s.handler = handler
+
+	return nil
+}
+
+func TestSubscribeSchemaError(t *testing.T) {
+	inner := &subscribingPubSub{}
+	v := New(inner, Options{
+		Topics: map[string]string{"orders": "missing.json"},
+		Loader: FileLoader{Dir: t.TempDir()},
+	}, logger.NewLogger("test"))
+
+	var delivered int
+	err := v.Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		delivered++
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The error loading the schema is returned to the component instead of dropping the message.
+	err = inner.handler(context.Background(), &pubsub.NewMessage{Topic: "orders", Data: []byte(`{"orderId":1}`)})
+	assert.Error(t, err)
+	assert.Zero(t, delivered)
+}
+
+func TestSubscribeDataSchemaError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.json"), []byte(`{"type": 1}`), 0o600))
+
+	inner := &subscribingPubSub{}
+	var deadLettered int
+	v := New(inner, Options{
+		UseDataSchema: true,
+		Loader:        FileLoader{Dir: dir},
+		DeadLetter: func(ctx context.Context, msg *pubsub.NewMessage) error {
+			deadLettered++
+
+			return nil
+		},
+	}, logger.NewLogger("test"))
+	require.NoError(t, v.Subscribe(pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	}))
+
+	// Schemas referenced by events that are missing or invalid are not delivered again.
+	for _, ref := range []string{"missing.json", "invalid.json", "../outside.json"} {
+		err := inner.handler(context.Background(), &pubsub.NewMessage{Topic: "orders", Data: cloudEvent(t, "", ref, []byte(`{"orderId":1}`))})
+		assert.NoError(t, err, ref)
+	}
+	assert.Equal(t, 3, deadLettered)
+}
+
+func TestFileLoader(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "schemas")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "orders"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders", "order.json"), []byte(orderSchema), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(root, "secret"), filepath.Join(dir, "link.json")))
+
+	loader := FileLoader{Dir: dir}
+	for _, ref := range []string{
+		"orders/order.json",
+		"./orders/../orders/order.json",
+		filepath.Join(dir, "orders", "order.json"),
+		"file://" + filepath.Join(dir, "orders", "order.json"),
+	} {
+		doc, err := loader.Load(ref)
+		assert.NoError(t, err, ref)
+		assert.Equal(t, orderSchema, string(doc), ref)
+	}
+
+	for _, ref := range []string{
+		"../secret",
+		"orders/../../secret",
+		filepath.Join(root, "secret"),
+		"file://" + filepath.Join(root, "secret"),
+		"http://example.com/order.json",
+		"missing.json",
+	} {
+		_, err := loader.Load(ref)
+		assert.ErrorIs(t, err, ErrSchemaNotFound, ref)
+	}
+
+	// A link out of the directory resolves under it.
+	_, err := loader.Load("link.json")
+	assert.Error(t, err)
+}